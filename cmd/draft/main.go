@@ -0,0 +1,324 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/adrg/frontmatter"
+	"github.com/zagvozdeen/malicious-learning/internal/config"
+	"github.com/zagvozdeen/malicious-learning/internal/converter"
+	"github.com/zagvozdeen/malicious-learning/internal/db"
+	"github.com/zagvozdeen/malicious-learning/internal/db/null"
+	"github.com/zagvozdeen/malicious-learning/internal/llm"
+	"github.com/zagvozdeen/malicious-learning/internal/logger"
+	"github.com/zagvozdeen/malicious-learning/internal/prompts"
+	"github.com/zagvozdeen/malicious-learning/internal/store"
+	"github.com/zagvozdeen/malicious-learning/internal/store/enum"
+	"gopkg.in/yaml.v3"
+)
+
+// cardPattern matches card files of the default locale, translations such as
+// 1_cell.en.md are not drafted.
+var cardPattern = regexp.MustCompile(`^\d+_[a-z0-9_]*\.md$`)
+
+// card is a card file to draft an answer for.
+type card struct {
+	file string
+	cd   converter.CardDescription
+}
+
+// cardFile is a card file of the course, empty files have no answer yet.
+type cardFile struct {
+	cd    converter.CardDescription
+	empty bool
+}
+
+// Asks the language model for answers to empty cards created by cmd/filler or
+// to questions of a text file, and writes them as drafts for review. Cards
+// with an answer are skipped, so an interrupted run is resumed by running it
+// again.
+func main() {
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	cfg := config.New()
+	log, stop := logger.New(cfg)
+	defer stop()
+
+	dir := flag.String("dir", "", "subfolder under data/courses to draft")
+	questions := flag.String("questions", "", "text file with a question per line, empty cards of -dir are drafted without it")
+	module := flag.String("module", "Практика", "module of cards without one")
+	concurrency := flag.Int("concurrency", 4, "number of concurrent requests")
+	dryRun := flag.Bool("dry-run", false, "list cards to draft without requests and writes")
+	flag.Parse()
+
+	log.Info("start", "dir", *dir, "questions", *questions, "concurrency", *concurrency, "dry_run", *dryRun)
+
+	// A dry run makes no requests, so it works without the database.
+	var storage store.Storage
+	if !*dryRun {
+		pool := db.New(ctx, cfg, log)
+		defer pool.Close()
+		storage = store.New(cfg, log, pool)
+	}
+
+	if err := run(ctx, cfg, log, storage, *dir, *questions, *module, *concurrency, *dryRun); err != nil {
+		log.Error("Run error", slog.Any("error", err))
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, cfg *config.Config, log *slog.Logger, storage store.Storage, dir, questions, module string, concurrency int, dryRun bool) error {
+	if dir == "" {
+		return errors.New("missing required -dir")
+	}
+	if concurrency < 1 {
+		return errors.New("-concurrency must be >= 1")
+	}
+	basePath := filepath.Join("data", "courses", dir)
+	course, err := readCourse(basePath)
+	if err != nil {
+		return err
+	}
+	cards, err := readCards(basePath)
+	if err != nil {
+		return err
+	}
+
+	var todo []card
+	if questions == "" {
+		todo = emptyCards(log, cards, module)
+	} else {
+		todo, err = questionCards(questions, cards, module)
+		if err != nil {
+			return err
+		}
+	}
+	log.Info("found cards to draft", "count", len(todo))
+	if dryRun {
+		for _, c := range todo {
+			log.Info("would draft", "file", c.file, "question", c.cd.Name)
+		}
+		return nil
+	}
+
+	if cfg.LLMProvider == "openai" && (cfg.NeuroAPI == "" || cfg.NeuroToken == "") {
+		return errors.New("missing NEURO_API or NEURO_TOKEN env")
+	}
+	provider, err := llm.New(cfg, log)
+	if err != nil {
+		return err
+	}
+	prices, err := llm.ParsePrices(cfg.LLMPrices)
+	if err != nil {
+		return fmt.Errorf("failed to parse llm prices: %w", err)
+	}
+	metered := llm.NewMetered(cfg, provider, storage, prices)
+	tmpl, err := prompts.Latest(prompts.Draft)
+	if err != nil {
+		return err
+	}
+
+	// The quota is daily, the rest of the cards would fail the same way.
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	var drafted, failed atomic.Int64
+	sem := make(chan struct{}, concurrency)
+	wg := sync.WaitGroup{}
+	for _, c := range todo {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Go(func() {
+			defer func() { <-sem }()
+			log.Info("requesting answer", "file", c.file, "question", c.cd.Name)
+			err := draft(ctx, metered, tmpl, cfg.LLMDraftModel, course, basePath, c)
+			if errors.Is(err, llm.ErrQuotaExceeded) {
+				cancel(err)
+			}
+			if err != nil {
+				log.Error("failed to draft", "file", c.file, "err", err)
+				failed.Add(1)
+				return
+			}
+			log.Info("drafted", "file", c.file)
+			drafted.Add(1)
+		})
+	}
+	wg.Wait()
+
+	log.Info("done", "drafted", drafted.Load(), "failed", failed.Load())
+	if ctx.Err() != nil {
+		return fmt.Errorf("context error: %w", context.Cause(ctx))
+	}
+	if failed.Load() > 0 {
+		return fmt.Errorf("failed to draft %d cards", failed.Load())
+	}
+	return nil
+}
+
+func readCourse(basePath string) (string, error) {
+	b, err := os.ReadFile(filepath.Join(basePath, "0_index.yaml"))
+	if err != nil {
+		return "", fmt.Errorf("failed to read course index: %w", err)
+	}
+	course := &converter.CourseDescription{}
+	err = yaml.Unmarshal(b, course)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse course index: %w", err)
+	}
+	if course.Name == "" {
+		return "", fmt.Errorf("course %s has no name", basePath)
+	}
+	return course.Name, nil
+}
+
+// readCards returns card files of the course with their front-matter and
+// whether they have an answer.
+func readCards(basePath string) (map[string]*cardFile, error) {
+	entries, err := os.ReadDir(basePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dir %s: %w", basePath, err)
+	}
+	cards := make(map[string]*cardFile)
+	for _, entry := range entries {
+		if entry.IsDir() || !cardPattern.MatchString(entry.Name()) {
+			continue
+		}
+		b, err := os.ReadFile(filepath.Join(basePath, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file %s: %w", entry.Name(), err)
+		}
+		f := &cardFile{}
+		body, err := frontmatter.Parse(bytes.NewReader(b), &f.cd, frontmatter.NewFormat("---", "---", yaml.Unmarshal))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse front-matter of %s: %w", entry.Name(), err)
+		}
+		f.cd.Name = strings.TrimSpace(f.cd.Name)
+		f.empty = len(bytes.TrimSpace(body)) == 0
+		cards[entry.Name()] = f
+	}
+	return cards, nil
+}
+
+// emptyCards returns the cards without an answer, the cards of cmd/filler
+// must get a name before they are drafted.
+func emptyCards(log *slog.Logger, cards map[string]*cardFile, module string) []card {
+	var todo []card
+	for _, name := range slices.Sorted(maps.Keys(cards)) {
+		f := cards[name]
+		if !f.empty {
+			continue
+		}
+		if f.cd.Name == "" {
+			log.Info("skip empty name", "file", name)
+			continue
+		}
+		cd := f.cd
+		if cd.Module == "" {
+			cd.Module = module
+		}
+		todo = append(todo, card{file: name, cd: cd})
+	}
+	return todo
+}
+
+// questionCards returns new cards of the questions missing from the course.
+// Blank lines and lines starting with # are skipped.
+func questionCards(fileName string, cards map[string]*cardFile, module string) ([]card, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open questions: %w", err)
+	}
+	defer file.Close()
+
+	seen := make(map[string]bool, len(cards))
+	files := make([]string, 0, len(cards))
+	for _, name := range slices.Sorted(maps.Keys(cards)) {
+		seen[cards[name].cd.Name] = true
+		files = append(files, name)
+	}
+	var todo []card
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		question := strings.TrimSpace(scanner.Text())
+		if question == "" || strings.HasPrefix(question, "#") || seen[question] {
+			continue
+		}
+		seen[question] = true
+		name := converter.NewFileName(files)
+		files = append(files, name)
+		todo = append(todo, card{file: name, cd: converter.CardDescription{Name: question, Module: module}})
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read questions: %w", err)
+	}
+	return todo, nil
+}
+
+func draft(ctx context.Context, metered *llm.Metered, tmpl *prompts.Template, model, course, basePath string, c card) error {
+	prompt, err := tmpl.Execute(prompts.DraftData{Course: course, Module: c.cd.Module, Question: c.cd.Name})
+	if err != nil {
+		return err
+	}
+	res, err := metered.Chat(ctx, &store.ChatCompletions{
+		OwnerType:     enum.ChatOwnerTypeTool,
+		Feature:       tmpl.Name,
+		PromptVersion: null.WrapString(tmpl.Version),
+	}, llm.Request{
+		Model:    model,
+		Messages: []llm.Message{{Role: llm.RoleUser, Content: prompt}},
+	}, nil)
+	if err != nil {
+		return err
+	}
+	answer := strings.TrimSpace(res.Content)
+	if answer == "" {
+		return errors.New("empty answer from model")
+	}
+	c.cd.Draft = true
+	b, err := marshalCard(c.cd, answer)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(basePath, c.file), b, 0o644)
+}
+
+func marshalCard(cd converter.CardDescription, answer string) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	buf.WriteString("---\n")
+	enc := yaml.NewEncoder(buf)
+	enc.SetIndent(2)
+	err := enc.Encode(cd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal front-matter: %w", err)
+	}
+	err = enc.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal front-matter: %w", err)
+	}
+	buf.WriteString("---\n\n")
+	buf.WriteString(answer)
+	buf.WriteString("\n")
+	return buf.Bytes(), nil
+}
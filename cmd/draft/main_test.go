@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/adrg/frontmatter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zagvozdeen/malicious-learning/internal/converter"
+	"gopkg.in/yaml.v3"
+)
+
+func TestEmptyCards(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	cards := map[string]*cardFile{
+		"3_maps.md":     {cd: converter.CardDescription{Name: "Как устроены map?", Module: "Go"}, empty: true},
+		"1_slices.md":   {cd: converter.CardDescription{Name: "Что такое слайс?"}, empty: true},
+		"2_channels.md": {cd: converter.CardDescription{Name: "Что такое канал?", Module: "Go"}},
+		"4_.md":         {empty: true},
+	}
+	todo := emptyCards(log, cards, "Практика")
+	assert.Equal(t, []card{
+		{file: "1_slices.md", cd: converter.CardDescription{Name: "Что такое слайс?", Module: "Практика"}},
+		{file: "3_maps.md", cd: converter.CardDescription{Name: "Как устроены map?", Module: "Go"}},
+	}, todo)
+	assert.Empty(t, cards["1_slices.md"].cd.Module, "cards are not changed")
+}
+
+func TestQuestionCards(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "questions.txt")
+	content := "# Go\nЧто такое слайс?\n\n  Что такое канал?  \nЧто такое интерфейс?\nЧто такое канал?\n"
+	require.NoError(t, os.WriteFile(fileName, []byte(content), 0o644))
+	cards := map[string]*cardFile{
+		"1_slices.md": {cd: converter.CardDescription{Name: "Что такое слайс?", Module: "Go"}},
+		"7_maps.md":   {cd: converter.CardDescription{Name: "Как устроены map?", Module: "Go"}},
+	}
+	todo, err := questionCards(fileName, cards, "Практика")
+	require.NoError(t, err)
+	assert.Equal(t, []card{
+		{file: "8_.md", cd: converter.CardDescription{Name: "Что такое канал?", Module: "Практика"}},
+		{file: "9_.md", cd: converter.CardDescription{Name: "Что такое интерфейс?", Module: "Практика"}},
+	}, todo)
+
+	_, err = questionCards(filepath.Join(t.TempDir(), "missing.txt"), cards, "Практика")
+	assert.Error(t, err)
+}
+
+func TestMarshalCard(t *testing.T) {
+	b, err := marshalCard(converter.CardDescription{Name: "Что такое: слайс?", Module: "Go", Draft: true}, "Слайс ссылается на массив.")
+	require.NoError(t, err)
+	assert.Equal(t, "---\nname: 'Что такое: слайс?'\nmodule: Go\ntags: []\ndraft: true\n---\n\nСлайс ссылается на массив.\n", string(b))
+
+	cd := converter.CardDescription{}
+	body, err := frontmatter.Parse(bytes.NewReader(b), &cd, frontmatter.NewFormat("---", "---", yaml.Unmarshal))
+	require.NoError(t, err)
+	assert.Equal(t, converter.CardDescription{Name: "Что такое: слайс?", Module: "Go", Tags: []string{}, Draft: true}, cd)
+	assert.Equal(t, "Слайс ссылается на массив.", string(bytes.TrimSpace(body)))
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/zagvozdeen/malicious-learning/internal/config"
+	"github.com/zagvozdeen/malicious-learning/internal/converter"
+	"github.com/zagvozdeen/malicious-learning/internal/db"
+	"github.com/zagvozdeen/malicious-learning/internal/logger"
+	"github.com/zagvozdeen/malicious-learning/internal/store"
+)
+
+// Writes cards published in the app back to data/courses, so they can be
+// committed and the repository stays the source of truth.
+func main() {
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	cfg := config.New()
+	log, stop := logger.New(cfg)
+	defer stop()
+
+	dir := flag.String("dir", "data/courses", "courses directory to write to")
+	flag.Parse()
+
+	if err := run(ctx, cfg, log, *dir); err != nil {
+		log.Error("Run error", slog.Any("error", err))
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, cfg *config.Config, log *slog.Logger, dir string) error {
+	pool := db.New(ctx, cfg, log)
+	defer pool.Close()
+	storage := store.New(cfg, log, pool)
+
+	changed, err := converter.ExportDrafts(ctx, storage, dir)
+	if err != nil {
+		return err
+	}
+	for _, name := range changed {
+		log.Info("changed", "file", name)
+	}
+	log.Info("done", "changed", len(changed))
+	return nil
+}
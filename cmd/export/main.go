@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/zagvozdeen/malicious-learning/internal/config"
+	"github.com/zagvozdeen/malicious-learning/internal/db"
+	"github.com/zagvozdeen/malicious-learning/internal/db/null"
+	"github.com/zagvozdeen/malicious-learning/internal/exporter"
+	"github.com/zagvozdeen/malicious-learning/internal/logger"
+	"github.com/zagvozdeen/malicious-learning/internal/store"
+)
+
+func main() {
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	cfg := config.New()
+	log, stop := logger.New(cfg)
+	defer stop()
+
+	course := flag.String("course", "", "slug of the course to export")
+	format := flag.String("format", string(exporter.FormatAnki), "apkg, csv, html or md")
+	out := flag.String("out", "", "output file, <course>.<format> by default")
+	userID := flag.Int("forgotten-by", 0, "export only cards forgotten by the user with this id")
+	flag.Parse()
+
+	log.Info("start", "course", *course, "format", *format, "out", *out)
+
+	if err := run(ctx, cfg, log, *course, *format, *out, *userID); err != nil {
+		log.Error("Run error", slog.Any("error", err))
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, cfg *config.Config, log *slog.Logger, course, format, out string, userID int) error {
+	if course == "" {
+		return fmt.Errorf("missing required -course")
+	}
+	f, err := exporter.ParseFormat(format)
+	if err != nil {
+		return err
+	}
+	if out == "" {
+		out = f.Filename(course)
+	}
+
+	pool := db.New(ctx, cfg, log)
+	defer pool.Close()
+	storage := store.New(cfg, log, pool)
+
+	file, err := os.Create(out)
+	if err != nil {
+		return fmt.Errorf("create %s: %v", out, err)
+	}
+	err = exporter.New(storage).Export(ctx, file, f, course, null.WrapInt(userID))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(out)
+		return fmt.Errorf("failed to export %s: %w", course, err)
+	}
+
+	log.Info("done", "file", out)
+	return nil
+}
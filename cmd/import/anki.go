@@ -0,0 +1,182 @@
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"database/sql"
+	"encoding/json/v2"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	_ "modernc.org/sqlite"
+)
+
+var ankiMediaRe = regexp.MustCompile(`<img[^>]+src="([^"]+)"`)
+
+// readAnki reads notes of an Anki package: a zip with the collection SQLite
+// database, a "media" JSON index and media files named by their index.
+func readAnki(ctx context.Context, file string) ([]note, error) {
+	zr, err := zip.OpenReader(file)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+	var collection *zip.File
+	for _, name := range []string{"collection.anki21", "collection.anki2"} {
+		if f, ok := files[name]; ok {
+			collection = f
+			break
+		}
+	}
+	if collection == nil {
+		return nil, errors.New("collection.anki2 not found, export the deck with \"Support older Anki versions\"")
+	}
+
+	media, err := readAnkiMedia(files)
+	if err != nil {
+		return nil, err
+	}
+
+	tmp, err := os.CreateTemp("", "import-*.anki2")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	err = extractZipFile(collection, tmp)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract collection: %w", err)
+	}
+
+	db, err := sql.Open("sqlite", "file:"+tmp.Name()+"?mode=ro")
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	decks, err := readAnkiDecks(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT n.flds, n.tags, MIN(c.did)
+		FROM notes n
+		LEFT JOIN cards c ON c.nid = n.id
+		GROUP BY n.id
+		ORDER BY n.id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query notes: %w", err)
+	}
+	defer rows.Close()
+
+	var notes []note
+	for rows.Next() {
+		var flds, tags string
+		var did sql.NullInt64
+		if err = rows.Scan(&flds, &tags, &did); err != nil {
+			return nil, err
+		}
+		fields := strings.Split(flds, "\x1f")
+		if len(fields) < 2 {
+			continue
+		}
+		n := newNote(htmlToMarkdown(fields[0]), htmlToMarkdown(fields[1]), decks[did.Int64], splitTags(tags, " "))
+		for _, m := range ankiMediaRe.FindAllStringSubmatch(fields[0]+fields[1], -1) {
+			if content, ok := media[m[1]]; ok {
+				if n.Media == nil {
+					n.Media = make(map[string][]byte)
+				}
+				n.Media[m[1]] = content
+			}
+		}
+		notes = append(notes, n)
+	}
+	return notes, rows.Err()
+}
+
+// readAnkiDecks maps deck ids to the last part of "Parent::Child" names.
+func readAnkiDecks(ctx context.Context, db *sql.DB) (map[int64]string, error) {
+	var raw string
+	err := db.QueryRowContext(ctx, "SELECT decks FROM col").Scan(&raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query decks: %w", err)
+	}
+	var decks map[string]struct {
+		Name string `json:"name"`
+	}
+	if err = json.Unmarshal([]byte(raw), &decks); err != nil {
+		return nil, fmt.Errorf("failed to parse decks: %w", err)
+	}
+	names := make(map[int64]string, len(decks))
+	for id, deck := range decks {
+		n, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			continue
+		}
+		parts := strings.Split(deck.Name, "::")
+		if name := strings.TrimSpace(parts[len(parts)-1]); name != "Default" {
+			names[n] = name
+		}
+	}
+	return names, nil
+}
+
+func readAnkiMedia(files map[string]*zip.File) (map[string][]byte, error) {
+	media := make(map[string][]byte)
+	f, ok := files["media"]
+	if !ok {
+		return media, nil
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	var index map[string]string
+	err = json.UnmarshalRead(rc, &index)
+	rc.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse media index: %w", err)
+	}
+	for key, name := range index {
+		mf, ok := files[key]
+		if !ok || filepath.Base(name) != name {
+			continue
+		}
+		rc, err = mf.Open()
+		if err != nil {
+			return nil, err
+		}
+		b, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		media[name] = b
+	}
+	return media, nil
+}
+
+func extractZipFile(f *zip.File, w io.Writer) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	_, err = io.Copy(w, rc)
+	return err
+}
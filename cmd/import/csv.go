@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// readCSV reads a table with a header row. The "question" and "answer" columns
+// are required, "module" and "tags" (separated by spaces) are optional. Cells
+// may contain markdown or HTML.
+func readCSV(file string, comma rune) ([]note, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.Comma = comma
+	r.LazyQuotes = true
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, errors.New("empty file")
+	}
+
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\uFEFF")))] = i
+	}
+	for _, name := range []string{"question", "answer"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("missing %q column in header", name)
+		}
+	}
+	cell := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	notes := make([]note, 0, len(records)-1)
+	for _, record := range records[1:] {
+		notes = append(notes, newNote(
+			htmlToMarkdown(cell(record, "question")),
+			htmlToMarkdown(cell(record, "answer")),
+			cell(record, "module"),
+			splitTags(cell(record, "tags"), " "),
+		))
+	}
+	return notes, nil
+}
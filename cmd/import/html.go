@@ -0,0 +1,70 @@
+package main
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	htmlTagRe     = regexp.MustCompile(`(?s)<[^>]*>`)
+	htmlPreRe     = regexp.MustCompile(`(?is)<pre[^>]*>(.*?)</pre>`)
+	htmlCodeRe    = regexp.MustCompile(`(?is)<code[^>]*>(.*?)</code>`)
+	htmlBoldRe    = regexp.MustCompile(`(?is)<(?:b|strong)(?:\s[^>]*)?>(.*?)</(?:b|strong)>`)
+	htmlItalicRe  = regexp.MustCompile(`(?is)<(?:i|em)(?:\s[^>]*)?>(.*?)</(?:i|em)>`)
+	htmlLinkRe    = regexp.MustCompile(`(?is)<a[^>]+href="([^"]*)"[^>]*>(.*?)</a>`)
+	htmlImgRe     = regexp.MustCompile(`(?is)<img[^>]+src="([^"]*)"[^>]*>`)
+	htmlItemRe    = regexp.MustCompile(`(?is)<li[^>]*>`)
+	htmlBreakRe   = regexp.MustCompile(`(?i)<br\s*/?>`)
+	htmlBlockRe   = regexp.MustCompile(`(?i)</?(?:div|p|ul|ol|h[1-6]|tr|table)[^>]*>`)
+	htmlNewlineRe = regexp.MustCompile(`\n{3,}`)
+	htmlMarkupRe  = regexp.MustCompile(`(?i)<(?:br|div|p|b|strong|i|em|a|img|ul|ol|li|pre|code|span)\b`)
+	assetSpaceRe  = regexp.MustCompile(`!\[\]\((assets/[^)<>\n]* [^)<>\n]*)\)`)
+
+	nameReplacer = strings.NewReplacer("**", "", "__", "", "`", "")
+)
+
+// htmlToMarkdown converts the HTML produced by Anki and spreadsheets to card
+// markdown. Plain text and markdown cells are returned as is.
+func htmlToMarkdown(s string) string {
+	if !htmlMarkupRe.MatchString(s) {
+		return strings.TrimSpace(s)
+	}
+	s = htmlPreRe.ReplaceAllStringFunc(s, func(m string) string {
+		code := htmlPreRe.FindStringSubmatch(m)[1]
+		code = htmlBreakRe.ReplaceAllString(code, "\n")
+		code = html.UnescapeString(htmlTagRe.ReplaceAllString(code, ""))
+		return "\n\n```\n" + strings.Trim(code, "\n") + "\n```\n\n"
+	})
+	s = htmlCodeRe.ReplaceAllString(s, "`$1`")
+	s = htmlBoldRe.ReplaceAllString(s, "**$1**")
+	s = htmlItalicRe.ReplaceAllString(s, "_${1}_")
+	s = htmlLinkRe.ReplaceAllString(s, "[$2]($1)")
+	s = htmlImgRe.ReplaceAllString(s, "![](assets/$1)")
+	s = htmlItemRe.ReplaceAllString(s, "\n- ")
+	s = htmlBreakRe.ReplaceAllString(s, "\n")
+	s = htmlBlockRe.ReplaceAllString(s, "\n\n")
+	s = html.UnescapeString(htmlTagRe.ReplaceAllString(s, ""))
+	// Destinations with spaces are wrapped in <> after the tags are stripped,
+	// so file names with spaces stay links.
+	s = assetSpaceRe.ReplaceAllString(s, "![](<$1>)")
+	s = strings.ReplaceAll(s, "\u00a0", " ")
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.TrimSpace(htmlNewlineRe.ReplaceAllString(strings.Join(lines, "\n"), "\n\n"))
+}
+
+// newNote uses a one-line question as the card name. A longer question, for
+// example with code, goes to the "## Вопрос" section and its first line
+// becomes the name.
+func newNote(question, answer, module string, tags []string) note {
+	n := note{Module: strings.TrimSpace(module), Tags: tags, Body: answer}
+	first, _, multiline := strings.Cut(question, "\n")
+	n.Name = strings.TrimSpace(strings.Trim(nameReplacer.Replace(first), "*_#"))
+	if multiline || strings.Contains(question, "![") {
+		n.Body = "## Вопрос\n\n" + question + "\n\n## Ответ\n\n" + answer
+	}
+	return n
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/zagvozdeen/malicious-learning/internal/config"
+	"github.com/zagvozdeen/malicious-learning/internal/logger"
+	"gopkg.in/yaml.v3"
+)
+
+// note is a single imported question before it is written as a card file.
+type note struct {
+	Name   string
+	Module string
+	Tags   []string
+	Body   string
+	// Media maps file names referenced by the body to their content.
+	Media map[string][]byte
+}
+
+type frontMatter struct {
+	Name   string   `yaml:"name"`
+	Module string   `yaml:"module"`
+	Tags   []string `yaml:"tags,omitempty"`
+}
+
+var cardFileRe = regexp.MustCompile(`^(\d+)_.*\.md$`)
+
+func main() {
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	cfg := config.New()
+	log, stop := logger.New(cfg)
+	defer stop()
+
+	dir := flag.String("dir", "", "subfolder under data/courses to import into")
+	file := flag.String("file", "", "path to .apkg, .csv or .tsv file")
+	module := flag.String("module", "", "module for notes without a deck or module column")
+	tags := flag.String("tags", "", "comma separated tags added to every card")
+	dryRun := flag.Bool("dry-run", false, "only print what would be created")
+	flag.Parse()
+
+	log.Info("start", "dir", *dir, "file", *file, "dry_run", *dryRun)
+
+	if err := run(ctx, log, *dir, *file, *module, splitTags(*tags, ","), *dryRun); err != nil {
+		log.Error("Run error", slog.Any("error", err))
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, log *slog.Logger, dir, file, module string, tags []string, dryRun bool) error {
+	if dir == "" {
+		return fmt.Errorf("missing required -dir")
+	}
+	if file == "" {
+		return fmt.Errorf("missing required -file")
+	}
+
+	basePath := filepath.Join("data", "courses", dir)
+	info, err := os.Stat(basePath)
+	if err != nil {
+		return fmt.Errorf("cannot access %s: %v", basePath, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", basePath)
+	}
+
+	var notes []note
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".apkg", ".colpkg":
+		notes, err = readAnki(ctx, file)
+	case ".csv":
+		notes, err = readCSV(file, ',')
+	case ".tsv", ".txt":
+		notes, err = readCSV(file, '\t')
+	default:
+		return fmt.Errorf("unsupported file format %s", filepath.Ext(file))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", file, err)
+	}
+
+	next, err := nextCardNumber(basePath)
+	if err != nil {
+		return err
+	}
+
+	created := 0
+	skipped := 0
+	for _, n := range notes {
+		if ctx.Err() != nil {
+			return fmt.Errorf("context error: %v", ctx.Err())
+		}
+		if n.Module == "" {
+			n.Module = module
+		}
+		n.Tags = append(n.Tags, tags...)
+		if n.Name == "" || n.Module == "" {
+			log.Warn("skip note without name or module", "name", n.Name, "module", n.Module)
+			skipped++
+			continue
+		}
+		filename := filepath.Join(basePath, fmt.Sprintf("%d_%s.md", next, slugify(n.Name)))
+		if dryRun {
+			log.Info("would create", "file", filename, "name", n.Name, "module", n.Module, "tags", n.Tags, "media", len(n.Media))
+			next++
+			created++
+			continue
+		}
+		if err = writeNote(basePath, filename, n); err != nil {
+			return err
+		}
+		log.Info("created", "file", filename)
+		next++
+		created++
+	}
+
+	log.Info("done", "created", created, "skipped", skipped, "dry_run", dryRun)
+	return nil
+}
+
+// nextCardNumber returns the number following the biggest N of N_*.md files.
+func nextCardNumber(basePath string) (int, error) {
+	entries, err := os.ReadDir(basePath)
+	if err != nil {
+		return 0, fmt.Errorf("read dir %s: %v", basePath, err)
+	}
+	last := 0
+	for _, entry := range entries {
+		m := cardFileRe.FindStringSubmatch(entry.Name())
+		if m == nil {
+			continue
+		}
+		n, err := strconv.Atoi(m[1])
+		if err != nil {
+			continue
+		}
+		last = max(last, n)
+	}
+	return last + 1, nil
+}
+
+func writeNote(basePath, filename string, n note) error {
+	fm := &strings.Builder{}
+	enc := yaml.NewEncoder(fm)
+	enc.SetIndent(2)
+	err := enc.Encode(frontMatter{Name: n.Name, Module: n.Module, Tags: n.Tags})
+	if err != nil {
+		return fmt.Errorf("marshal front-matter of %s: %v", filename, err)
+	}
+	for name, content := range n.Media {
+		if err = writeAsset(filepath.Join(basePath, "assets", name), content); err != nil {
+			return err
+		}
+	}
+	file, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return fmt.Errorf("create %s: %v", filename, err)
+	}
+	if _, err = fmt.Fprintf(file, "---\n%s---\n%s\n", fm.String(), strings.TrimSpace(n.Body)); err != nil {
+		file.Close()
+		return fmt.Errorf("write %s: %v", filename, err)
+	}
+	if err = file.Close(); err != nil {
+		return fmt.Errorf("close %s: %v", filename, err)
+	}
+	return nil
+}
+
+// writeAsset creates the media file. An existing file is kept when it has the
+// same content, so notes may share media, and is never overwritten otherwise:
+// cards of the course may show it.
+func writeAsset(mediaPath string, content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(mediaPath), 0o755); err != nil {
+		return fmt.Errorf("create assets dir: %v", err)
+	}
+	existing, err := os.ReadFile(mediaPath)
+	if err == nil {
+		if !bytes.Equal(existing, content) {
+			return fmt.Errorf("%s already exists with other content, rename the media and import again", mediaPath)
+		}
+		return nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("read %s: %v", mediaPath, err)
+	}
+	if err = os.WriteFile(mediaPath, content, 0o644); err != nil {
+		return fmt.Errorf("write %s: %v", mediaPath, err)
+	}
+	return nil
+}
+
+func splitTags(s, sep string) []string {
+	var tags []string
+	for _, tag := range strings.Split(s, sep) {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+var translit = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e", 'ж': "zh",
+	'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+	'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "h", 'ц': "ts",
+	'ч': "ch", 'ш': "sh", 'щ': "sch", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu",
+	'я': "ya",
+}
+
+// slugify makes a snake_case file name of up to five words from the question.
+// An empty slug produces N_.md files that cmd/renamer can rename later.
+func slugify(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if t, ok := translit[r]; ok {
+			b.WriteString(t)
+			continue
+		}
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			b.WriteRune(r)
+			continue
+		}
+		b.WriteRune(' ')
+	}
+	words := strings.Fields(b.String())
+	if len(words) > 5 {
+		words = words[:5]
+	}
+	return strings.Join(words, "_")
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"database/sql"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeApkg writes a package with the tables readAnki reads and a "map png.png"
+// media file.
+func writeApkg(t *testing.T, file string) {
+	collection := filepath.Join(t.TempDir(), "collection.anki2")
+	db, err := sql.Open("sqlite", "file:"+collection)
+	require.NoError(t, err)
+	for _, q := range []string{
+		"CREATE TABLE col (decks text not null)",
+		"CREATE TABLE notes (id integer primary key, flds text not null, tags text not null)",
+		"CREATE TABLE cards (id integer primary key, nid integer not null, did integer not null)",
+		`INSERT INTO col VALUES ('{"1":{"name":"Default"},"7":{"name":"Go::Каналы"}}')`,
+		"INSERT INTO notes VALUES (1, 'Что такое <b>канал</b>?' || char(31) || 'Труба &amp; очередь.<br><img src=\"map png.png\">', ' go ')",
+		"INSERT INTO notes VALUES (2, 'Без ответа', '')",
+		"INSERT INTO notes VALUES (3, 'Как закрыть канал?' || char(31) || '<code>close(ch)</code>', '')",
+		"INSERT INTO cards VALUES (1, 1, 7)",
+		"INSERT INTO cards VALUES (2, 3, 1)",
+	} {
+		_, err = db.Exec(q)
+		require.NoError(t, err, q)
+	}
+	require.NoError(t, db.Close())
+
+	f, err := os.Create(file)
+	require.NoError(t, err)
+	zw := zip.NewWriter(f)
+	for name, content := range map[string]string{"media": `{"0":"map png.png","1":"../evil.png"}`, "0": "png", "1": "evil"} {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = io.WriteString(w, content)
+		require.NoError(t, err)
+	}
+	w, err := zw.Create("collection.anki2")
+	require.NoError(t, err)
+	b, err := os.ReadFile(collection)
+	require.NoError(t, err)
+	_, err = w.Write(b)
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	require.NoError(t, f.Close())
+}
+
+func TestReadAnki(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "deck.apkg")
+	writeApkg(t, file)
+
+	notes, err := readAnki(context.Background(), file)
+	require.NoError(t, err)
+	assert.Equal(t, []note{
+		{
+			Name:   "Что такое канал?",
+			Module: "Каналы",
+			Tags:   []string{"go"},
+			Body:   "Труба & очередь.\n![](<assets/map png.png>)",
+			Media:  map[string][]byte{"map png.png": []byte("png")},
+		},
+		{Name: "Как закрыть канал?", Body: "`close(ch)`"},
+	}, notes, "notes with one field are skipped, media outside assets is not read")
+}
+
+func TestReadCSV(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "cards.csv")
+	content := "\uFEFFQuestion,answer,module,tags\n" +
+		"Что такое слайс?,\"<p>Ссылка на <b>массив</b>.</p>\",Go,go slices\n" +
+		"\"Что выведет код?\n\n```go\nfmt.Println(1)\n```\",1,,\n"
+	require.NoError(t, os.WriteFile(file, []byte(content), 0o644))
+
+	notes, err := readCSV(file, ',')
+	require.NoError(t, err)
+	assert.Equal(t, []note{
+		{Name: "Что такое слайс?", Module: "Go", Tags: []string{"go", "slices"}, Body: "Ссылка на **массив**."},
+		{Name: "Что выведет код?", Body: "## Вопрос\n\nЧто выведет код?\n\n```go\nfmt.Println(1)\n```\n\n## Ответ\n\n1"},
+	}, notes)
+
+	require.NoError(t, os.WriteFile(file, []byte("question,module\nQ,Go\n"), 0o644))
+	_, err = readCSV(file, ',')
+	assert.EqualError(t, err, `missing "answer" column in header`)
+}
+
+func TestRun(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	file := filepath.Join(t.TempDir(), "deck.apkg")
+	writeApkg(t, file)
+	t.Chdir(t.TempDir())
+	course := filepath.Join("data", "courses", "go")
+	require.NoError(t, os.MkdirAll(filepath.Join(course, "assets"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(course, "4_slices.md"), nil, 0o644))
+
+	require.NoError(t, run(context.Background(), log, "go", file, "Практика", nil, false))
+	b, err := os.ReadFile(filepath.Join(course, "5_chto_takoe_kanal.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "---\nname: Что такое канал?\nmodule: Каналы\ntags:\n  - go\n---\nТруба & очередь.\n![](<assets/map png.png>)\n", string(b))
+	b, err = os.ReadFile(filepath.Join(course, "6_kak_zakryt_kanal.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "---\nname: Как закрыть канал?\nmodule: Практика\n---\n`close(ch)`\n", string(b))
+	b, err = os.ReadFile(filepath.Join(course, "assets", "map png.png"))
+	require.NoError(t, err)
+	assert.Equal(t, "png", string(b))
+
+	require.NoError(t, run(context.Background(), log, "go", file, "Практика", nil, false), "the same media is kept")
+	assert.FileExists(t, filepath.Join(course, "8_kak_zakryt_kanal.md"))
+
+	require.NoError(t, os.WriteFile(filepath.Join(course, "assets", "map png.png"), []byte("other"), 0o644))
+	err = run(context.Background(), log, "go", file, "Практика", nil, false)
+	assert.ErrorContains(t, err, "already exists with other content")
+	b, err = os.ReadFile(filepath.Join(course, "assets", "map png.png"))
+	require.NoError(t, err)
+	assert.Equal(t, "other", string(b), "media is not overwritten")
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+
+	"github.com/zagvozdeen/malicious-learning/data"
+	"github.com/zagvozdeen/malicious-learning/internal/config"
+	"github.com/zagvozdeen/malicious-learning/internal/converter"
+	"github.com/zagvozdeen/malicious-learning/internal/logger"
+)
+
+// Renders every course of data/courses like the converter does and lists
+// likely duplicate questions across courses. It fails on broken cards, like
+// a details block without the closing ":::" or a [[uid]] link to a missing
+// card, and when there are more duplicates than allowed, so it can run in CI.
+func main() {
+	cfg := config.New()
+	log, stop := logger.New(cfg)
+	defer stop()
+
+	limit := flag.Int("max-duplicates", 0, "number of likely duplicates allowed")
+	flag.Parse()
+
+	if err := run(log, data.Courses, *limit); err != nil {
+		log.Error("Run error", slog.Any("error", err))
+		os.Exit(1)
+	}
+}
+
+func run(log *slog.Logger, src fs.FS, limit int) error {
+	duplicates, err := converter.FindDuplicates(src)
+	if err != nil {
+		return err
+	}
+	for _, d := range duplicates {
+		log.Warn("Likely duplicate question", slog.String("cards", d.String()))
+	}
+	log.Info("done", "duplicates", len(duplicates), "max_duplicates", limit)
+	if len(duplicates) > limit {
+		return fmt.Errorf("found %d likely duplicates, %d allowed", len(duplicates), limit)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunDetails(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	src := fstest.MapFS{
+		"courses/go/1_slices.md": {Data: []byte("---\nname: Что такое слайс?\nmodule: Go\n---\n:::details Подробнее\n:::details Код\n```go\ns := []int{1}\n```\n:::\n:::\n")},
+	}
+	require.NoError(t, run(log, src, 0))
+
+	src["courses/go/2_maps.md"] = &fstest.MapFile{Data: []byte("---\nname: Что такое map?\nmodule: Go\n---\n:::details Подробнее\nХеш-таблица.\n")}
+	err := run(log, src, 0)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "2_maps.md")
+	assert.Contains(t, err.Error(), `details block is not closed with ":::"`)
+}
+
+func TestRunLinks(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	src := fstest.MapFS{
+		"courses/go/1_slices.md":   {Data: []byte("---\nname: Что такое слайс?\nmodule: Go\n---\nСм. [[2]] и [[algo:1]].\n")},
+		"courses/go/2_maps.md":     {Data: []byte("---\nname: Что такое map?\nmodule: Go\n---\nХеш-таблица.\n")},
+		"courses/algo/1_binary.md": {Data: []byte("---\nname: Бинарный поиск\nmodule: Алгоритмы\n---\nO(log n).\n")},
+	}
+	require.NoError(t, run(log, src, 0))
+
+	src["courses/go/2_maps.md"] = &fstest.MapFile{Data: []byte("---\nname: Что такое map?\nmodule: Go\n---\nСм. [[algo:7]].\n")}
+	err := run(log, src, 0)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "2_maps.md")
+	assert.Contains(t, err.Error(), "dangling link [[algo:7]]")
+}
+
+func TestRunDuplicates(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	src := fstest.MapFS{
+		"courses/algo/1_hash.md": {Data: []byte("---\nname: Хеш-таблица\nmodule: Algo\n---\nКак устроена хеш-таблица и разрешение коллизий цепочками.\n")},
+		"courses/go/1_map.md":    {Data: []byte("---\nname: Хеш-таблица\nmodule: Go\n---\nКак устроена хеш-таблица и разрешение коллизий цепочками.\n")},
+	}
+	assert.EqualError(t, run(log, src, 0), "found 1 likely duplicates, 0 allowed")
+	assert.NoError(t, run(log, src, 1))
+}
@@ -2,6 +2,8 @@ package main
 
 import (
 	"context"
+	"log/slog"
+	"os"
 	"os/signal"
 	"syscall"
 
@@ -9,6 +11,7 @@ import (
 	"github.com/zagvozdeen/malicious-learning/internal/api"
 	"github.com/zagvozdeen/malicious-learning/internal/config"
 	"github.com/zagvozdeen/malicious-learning/internal/db"
+	"github.com/zagvozdeen/malicious-learning/internal/llm"
 	"github.com/zagvozdeen/malicious-learning/internal/logger"
 	"github.com/zagvozdeen/malicious-learning/internal/store"
 )
@@ -25,6 +28,17 @@ func main() {
 	pool := db.New(ctx, cfg, log)
 	defer pool.Close()
 	storage := store.New(cfg, log, pool)
+	provider, err := llm.New(cfg, log)
+	if err != nil {
+		log.Error("Failed to create llm provider", slog.Any("err", err))
+		os.Exit(1)
+	}
 
-	api.New(ctx, cfg, log, storage, metrics).Run()
+	prices, err := llm.ParsePrices(cfg.LLMPrices)
+	if err != nil {
+		log.Error("Failed to parse llm prices", slog.Any("err", err))
+		os.Exit(1)
+	}
+
+	api.New(ctx, cfg, log, storage, metrics, provider, prices).Run()
 }
@@ -15,11 +15,13 @@ import (
 	"syscall"
 
 	"github.com/adrg/frontmatter"
-	"github.com/openai/openai-go/v3"
-	"github.com/openai/openai-go/v3/option"
 	"github.com/zagvozdeen/malicious-learning/data"
 	"github.com/zagvozdeen/malicious-learning/internal/config"
+	"github.com/zagvozdeen/malicious-learning/internal/db"
+	"github.com/zagvozdeen/malicious-learning/internal/llm"
 	"github.com/zagvozdeen/malicious-learning/internal/logger"
+	"github.com/zagvozdeen/malicious-learning/internal/store"
+	"github.com/zagvozdeen/malicious-learning/internal/store/enum"
 	"gopkg.in/yaml.v3"
 )
 
@@ -41,23 +43,28 @@ func main() {
 	cfg := config.New()
 	log, stop := logger.New(cfg)
 	defer stop()
+	pool := db.New(ctx, cfg, log)
+	defer pool.Close()
 
-	if err := run(ctx, cfg, log); err != nil {
+	if err := run(ctx, cfg, log, store.New(cfg, log, pool)); err != nil {
 		log.Error("renamer failed", slog.Any("err", err))
 		os.Exit(1)
 	}
 }
 
-func run(ctx context.Context, cfg *config.Config, log *slog.Logger) error {
-	if cfg.NeuroAPI == "" || cfg.NeuroToken == "" {
+func run(ctx context.Context, cfg *config.Config, log *slog.Logger, storage store.Storage) error {
+	if cfg.LLMProvider == "openai" && (cfg.NeuroAPI == "" || cfg.NeuroToken == "") {
 		return errors.New("missing NEURO_API or NEURO_TOKEN env")
 	}
-
-	client := openai.NewClient(
-		option.WithBaseURL(cfg.NeuroAPI),
-		option.WithAPIKey(cfg.NeuroToken),
-		//option.WithDebugLog(slog.NewLogLogger(log.Handler(), slog.LevelDebug)),
-	)
+	provider, err := llm.New(cfg, log)
+	if err != nil {
+		return err
+	}
+	prices, err := llm.ParsePrices(cfg.LLMPrices)
+	if err != nil {
+		return fmt.Errorf("failed to parse llm prices: %w", err)
+	}
+	metered := llm.NewMetered(cfg, provider, storage, prices)
 
 	log.Info("start renamer")
 	entries, err := data.Courses.ReadDir("courses")
@@ -127,7 +134,10 @@ func run(ctx context.Context, cfg *config.Config, log *slog.Logger) error {
 			}
 
 			log.Info("requesting slug", "course", courseSlug, "file", name, "question", question)
-			slug, err := requestSlug(ctx, client, question)
+			slug, err := requestSlug(ctx, metered, cfg.LLMRenamerModel, question)
+			if errors.Is(err, llm.ErrQuotaExceeded) {
+				return err
+			}
 			if err != nil {
 				log.Error("failed to request slug", "course", courseSlug, "file", name, "err", err)
 				skipped++
@@ -168,21 +178,19 @@ func run(ctx context.Context, cfg *config.Config, log *slog.Logger) error {
 	return nil
 }
 
-func requestSlug(ctx context.Context, client openai.Client, question string) (string, error) {
+func requestSlug(ctx context.Context, metered *llm.Metered, model, question string) (string, error) {
 	prompt := fmt.Sprintf("%s\nВопрос: %q", promptPrefix, question)
-	res, err := client.Chat.Completions.New(ctx, openai.ChatCompletionNewParams{
-		Messages: []openai.ChatCompletionMessageParamUnion{
-			openai.UserMessage(prompt),
-		},
-		Model: openai.ChatModelGPT5Mini,
-	})
+	res, err := metered.Chat(ctx, &store.ChatCompletions{
+		OwnerType: enum.ChatOwnerTypeTool,
+		Feature:   "renamer",
+	}, llm.Request{
+		Model:    model,
+		Messages: []llm.Message{{Role: llm.RoleUser, Content: prompt}},
+	}, nil)
 	if err != nil {
-		return "", fmt.Errorf("failed to create chat completion: %w", err)
-	}
-	if len(res.Choices) == 0 {
-		return "", errors.New("empty model choices")
+		return "", err
 	}
-	raw := strings.TrimSpace(res.Choices[0].Message.Content)
+	raw := strings.TrimSpace(res.Content)
 	return sanitizeSlug(raw), nil
 }
 
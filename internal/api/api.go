@@ -13,6 +13,7 @@ import (
 	"github.com/zagvozdeen/malicious-learning/internal/analytics"
 	"github.com/zagvozdeen/malicious-learning/internal/config"
 	"github.com/zagvozdeen/malicious-learning/internal/converter"
+	"github.com/zagvozdeen/malicious-learning/internal/llm"
 	"github.com/zagvozdeen/malicious-learning/internal/store"
 )
 
@@ -22,20 +23,30 @@ type Service struct {
 	log          *slog.Logger
 	store        store.Storage
 	processingTS sync.Map
+	jobs         chan struct{}
+	tutoring     sync.Map
 	metrics      analytics.Metrics
+	llm          *llm.Metered
 	bot          *bot.Bot
 	botStarted   chan struct{}
+	botReady     chan struct{}
+	// wg tracks background work, Run waits for it on shutdown.
+	wg sync.WaitGroup
 }
 
-func New(ctx context.Context, cfg *config.Config, log *slog.Logger, store store.Storage, metrics analytics.Metrics) *Service {
+func New(ctx context.Context, cfg *config.Config, log *slog.Logger, store store.Storage, metrics analytics.Metrics, provider llm.Provider, prices llm.Prices) *Service {
 	return &Service{
 		ctx:          ctx,
 		cfg:          cfg,
 		log:          log,
 		store:        store,
 		processingTS: sync.Map{},
+		jobs:         make(chan struct{}, 1),
+		tutoring:     sync.Map{},
 		metrics:      metrics,
+		llm:          llm.NewMetered(cfg, provider, store, prices),
 		botStarted:   make(chan struct{}, 1),
+		botReady:     make(chan struct{}),
 	}
 }
 
@@ -47,33 +58,41 @@ func (s *Service) Run() {
 		ErrorLog: slog.NewLogLogger(s.log.Handler(), slog.LevelDebug),
 	}
 	stop := make(chan error, 1)
-	wg := &sync.WaitGroup{}
-	wg.Go(func() {
+	s.wg.Go(func() {
 		stop <- server.ListenAndServe()
 		close(stop)
 	})
-	wg.Go(func() {
+	s.wg.Go(func() {
 		if err := s.createRootUser(); err != nil {
 			s.log.Warn("Failed to create root user", slog.Any("err", err))
 			return
 		}
 		s.log.Info("Root user created or already exists", slog.String("username", s.cfg.RootUserName))
 	})
-	wg.Go(func() {
+	s.wg.Go(func() {
 		if err := s.startBot(); err != nil {
 			s.log.Warn("Failed to start bot", slog.Any("err", err))
 			return
 		}
 		s.log.Info("Bot stopped")
 	})
-	wg.Go(func() {
-		if err := converter.Run(s.ctx, s.store); err != nil {
+	s.wg.Go(func() {
+		res, err := converter.Run(s.ctx, s.store)
+		if err != nil {
 			s.log.Warn("Failed to parse data", slog.Any("err", err))
 			return
 		}
-		s.log.Info("Questions parsed")
+		for _, d := range res.Duplicates {
+			s.log.Warn("Likely duplicate question", slog.String("cards", d.String()))
+		}
+		s.log.Info("Questions parsed", slog.Int("changes", len(res.Changelog)), slog.Int("duplicates", len(res.Duplicates)))
+		s.notifyChangelog(res.Changelog)
+	})
+	s.wg.Go(func() {
+		s.startJobs()
+		s.log.Info("Job workers have been stopped")
 	})
-	wg.Go(func() {
+	s.wg.Go(func() {
 		if err := s.startSendingMetrics(); err != nil {
 			s.log.Warn("Failed to start sending metrics", slog.Any("err", err))
 			return
@@ -94,7 +113,7 @@ func (s *Service) Run() {
 		s.log.Warn("Failed to shutdown server", slog.Any("err", err))
 	}
 	cancel()
-	wg.Wait()
+	s.wg.Wait()
 	s.log.Info("Server has been stopped")
 }
 
@@ -112,12 +131,57 @@ func (s *Service) getRoutes() *http.ServeMux {
 	mux.HandleFunc("GET /api/test-sessions", s.auth(s.getTestSessions))
 	mux.HandleFunc("GET /api/test-sessions/{uuid}", s.auth(s.getTestSession))
 	mux.HandleFunc("POST /api/test-sessions", s.auth(s.createTestSession))
+	mux.HandleFunc("POST /api/test-sessions/{uuid}/recommendations", s.auth(s.regenerateRecommendations))
 	mux.HandleFunc("PATCH /api/user-answers/{uuid}", s.auth(s.updateUserAnswer))
 	mux.HandleFunc("GET /api/leaderboard", s.auth(s.getLeaderboard))
 	mux.HandleFunc("GET /api/cards", s.auth(s.getCards))
+	mux.HandleFunc("GET /api/cards/{uuid}/related", s.auth(s.getRelatedCards))
+	mux.HandleFunc("GET /api/cards/{uuid}/similar", s.auth(s.getSimilarCards))
+	mux.HandleFunc("GET /api/cards/{uuid}/source", s.auth(s.getCardSource))
+	mux.HandleFunc("POST /api/cards/{uuid}/suggestions", s.auth(s.createSuggestion))
+	mux.HandleFunc("POST /api/cards/{uuid}/tutor-chats", s.auth(s.createTutorChat))
+	mux.HandleFunc("GET /api/tutor-chats/{uuid}", s.auth(s.getTutorChat))
+	mux.HandleFunc("POST /api/tutor-chats/{uuid}/messages", s.auth(s.sendTutorMessage))
+	mux.HandleFunc("GET /api/search", s.auth(s.searchCards))
+	mux.HandleFunc("GET /api/locales", s.auth(s.getLocales))
+	mux.HandleFunc("PATCH /api/locales", s.auth(s.updateLocale))
 	mux.HandleFunc("GET /api/courses", s.auth(s.getCourses))
+	mux.HandleFunc("GET /api/courses/{slug}/export", s.auth(s.exportCourse))
+	mux.HandleFunc("POST /api/decks", s.auth(s.createDeck))
+	mux.HandleFunc("PUT /api/decks/{slug}", s.auth(s.updateDeck))
+	mux.HandleFunc("GET /api/decks/{slug}/members", s.auth(s.getDeckMembers))
+	mux.HandleFunc("POST /api/decks/{slug}/members", s.auth(s.addDeckMember))
+	mux.HandleFunc("DELETE /api/decks/{slug}/members/{id}", s.auth(s.removeDeckMember))
+	mux.HandleFunc("GET /api/decks/invites", s.auth(s.getDeckInvites))
+	mux.HandleFunc("POST /api/decks/{slug}/invite", s.auth(s.acceptDeckInvite))
+	mux.HandleFunc("DELETE /api/decks/{slug}/invite", s.auth(s.declineDeckInvite))
 	mux.HandleFunc("GET /api/modules", s.auth(s.getModules))
 	mux.HandleFunc("GET /api/changes", s.auth(s.getChanges))
+	mux.HandleFunc("GET /api/changelog", s.auth(s.getChangelog))
+	mux.HandleFunc("GET /api/assets/{hash}", s.getAsset)
+	mux.HandleFunc("GET /api/code-themes", s.auth(s.getCodeThemes))
+	mux.HandleFunc("GET /api/code-themes/{name}", s.getCodeThemeCSS)
+
+	mux.HandleFunc("POST /api/editor/courses", s.editor(s.createCourse))
+	mux.HandleFunc("PATCH /api/editor/courses/{slug}", s.editor(s.updateCourse))
+	mux.HandleFunc("GET /api/editor/courses/{slug}/files", s.editor(s.getSourceFiles))
+	mux.HandleFunc("GET /api/editor/courses/{slug}/files/{file}", s.editor(s.getSource))
+	mux.HandleFunc("POST /api/editor/modules", s.editor(s.createModule))
+	mux.HandleFunc("DELETE /api/editor/modules/{id}", s.editor(s.deleteModule))
+	mux.HandleFunc("GET /api/editor/drafts", s.editor(s.getDrafts))
+	mux.HandleFunc("POST /api/editor/drafts", s.editor(s.createDraft))
+	mux.HandleFunc("GET /api/editor/drafts/{uuid}", s.editor(s.getDraft))
+	mux.HandleFunc("PATCH /api/editor/drafts/{uuid}", s.editor(s.updateDraft))
+	mux.HandleFunc("DELETE /api/editor/drafts/{uuid}", s.editor(s.discardDraft))
+	mux.HandleFunc("POST /api/editor/drafts/{uuid}/preview", s.editor(s.previewDraft))
+	mux.HandleFunc("POST /api/editor/drafts/{uuid}/publish", s.editor(s.publishDraft))
+	mux.HandleFunc("GET /api/editor/suggestions", s.editor(s.getSuggestions))
+	mux.HandleFunc("GET /api/editor/suggestions/patch", s.editor(s.getAcceptedPatches))
+	mux.HandleFunc("GET /api/editor/suggestions/{uuid}", s.editor(s.getSuggestion))
+	mux.HandleFunc("GET /api/editor/suggestions/{uuid}/patch", s.editor(s.getSuggestionPatch))
+	mux.HandleFunc("POST /api/editor/suggestions/{uuid}/accept", s.editor(s.acceptSuggestion))
+	mux.HandleFunc("POST /api/editor/suggestions/{uuid}/reject", s.editor(s.rejectSuggestion))
+	mux.HandleFunc("GET /api/admin/llm-usage", s.admin(s.getLLMUsage))
 
 	return mux
 }
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// getAsset serves a course asset by its content hash. Images are loaded by the
+// browser directly, so the route is public and responses are cached forever.
+func (s *Service) getAsset(w http.ResponseWriter, r *http.Request) {
+	hash := r.PathValue("hash")
+	if hash == "" {
+		http.Error(w, "missing hash", http.StatusBadRequest)
+		return
+	}
+	etag := strconv.Quote(hash)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		s.metrics.AppResponsesTotalInc(r.Pattern, http.StatusNotModified)
+		return
+	}
+	asset, err := s.store.GetAssetByHash(r.Context(), hash)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, "asset not found", http.StatusNotFound)
+			s.metrics.AppResponsesTotalInc(r.Pattern, http.StatusNotFound)
+			return
+		}
+		s.log.Error("Failed to load asset", slog.Any("err", err), slog.String("hash", hash))
+		http.Error(w, "failed to load asset", http.StatusInternalServerError)
+		s.metrics.AppResponsesTotalInc(r.Pattern, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", asset.MimeType)
+	// Assets share the origin of the app, they must never run as a page.
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; sandbox")
+	if !strings.HasPrefix(asset.MimeType, "image/") {
+		w.Header().Set("Content-Disposition", "attachment")
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(asset.Content)))
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	w.Header().Set("ETag", etag)
+	w.WriteHeader(http.StatusOK)
+	if _, err = w.Write(asset.Content); err != nil {
+		s.log.Warn("Failed to write asset", slog.Any("err", err))
+	}
+	s.metrics.AppResponsesTotalInc(r.Pattern, http.StatusOK)
+}
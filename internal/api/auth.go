@@ -113,6 +113,10 @@ func (s *Service) authTMA(r *http.Request, token string) (*store.User, core.Resp
 		}
 		return nil, core.Err(http.StatusInternalServerError, fmt.Errorf("failed to load user: %w", err))
 	}
+	err = s.syncLanguageCode(r.Context(), user, u.LanguageCode)
+	if err != nil {
+		s.log.Warn("Failed to sync language code", slog.Any("err", err))
+	}
 	return user, nil
 }
 
@@ -14,19 +14,68 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/zagvozdeen/malicious-learning/internal/db/null"
 	storemodels "github.com/zagvozdeen/malicious-learning/internal/store"
+	"github.com/zagvozdeen/malicious-learning/internal/store/enum"
 )
 
+type botCopy struct {
+	unknown []string
+	start   []string
+}
+
+// botCopies are replies of the bot in MarkdownV2 by the locale of the user.
+var botCopies = map[enum.Locale]botCopy{
+	enum.LocaleRu: {
+		unknown: []string{"Бот не поддерживает никаких команд, весь функционал находится в мини\\-приложении"},
+		start: []string{
+			"Добро пожаловать в бот *Malicious Learning*\\!",
+			"",
+			"С помощью этого бота ты можешь подготовиться к экзамену по машинному обучению\\. Внутри MiniApp ты найдёшь карточки с вопросами и ответами\\. А также у тебя будет персональная статистика, рассчитанная из ответов:",
+			"",
+			"\\- жми «Вспомнил» если знаешь ответ",
+			"\\- жми «Забыл» если не знаешь ответа",
+			"",
+			"Весь функционал находится в мини\\-приложении, открывай и готовься\\!",
+			"",
+			"Ещё сомневаешься или хочешь улучшить проект? [Код приложения](https://github.com/zagvozdeen/malicious-learning) публичный, доступен каждому\\. А если хочешь помочь улучшить ответы, то внутри есть инструкция, как это сделать, или можешь просто написать мне в личку\\.",
+			"",
+			"_[Связь с автором](https://t.me/denchik1170)_",
+		},
+	},
+	enum.LocaleEn: {
+		unknown: []string{"The bot has no commands, everything is inside the mini\\-app"},
+		start: []string{
+			"Welcome to *Malicious Learning*\\!",
+			"",
+			"This bot helps you prepare for the machine learning exam\\. Inside the MiniApp you will find cards with questions and answers\\. You will also get personal statistics based on your answers:",
+			"",
+			"\\- tap «Remember» if you know the answer",
+			"\\- tap «Forgot» if you do not",
+			"",
+			"Everything is inside the mini\\-app, open it and get ready\\!",
+			"",
+			"Not sure yet or want to improve the project? The [source code](https://github.com/zagvozdeen/malicious-learning) is public\\. If you want to help improve the answers, there is a guide inside, or just message me directly\\.",
+			"",
+			"_[Contact the author](https://t.me/denchik1170)_",
+		},
+	},
+}
+
 func (s *Service) startBot() error {
 	if !s.cfg.TelegramBotEnabled {
 		s.log.Info("Telegram bot disabled")
 		return nil
 	}
 	var err error
-	s.bot, err = bot.New(s.cfg.TelegramBotToken, bot.WithDefaultHandler(s.defaultHandler))
+	s.bot, err = bot.New(
+		s.cfg.TelegramBotToken,
+		bot.WithDefaultHandler(s.defaultHandler),
+		bot.WithCallbackQueryDataHandler(suggestionCallbackPrefix, bot.MatchTypePrefix, s.suggestionHandler),
+	)
 	if err != nil {
 		return err
 	}
 	s.botStarted <- struct{}{}
+	close(s.botReady)
 	s.bot.Start(s.ctx)
 	return nil
 }
@@ -40,9 +89,13 @@ func (s *Service) defaultHandler(ctx context.Context, b *bot.Bot, update *tgbotm
 		s.log.Warn("Failed to store telegram update", slog.Any("err", err))
 	}
 
+	locale := enum.DefaultLocale
 	if tgUser := extractTelegramUser(update); tgUser != nil {
-		if err := s.ensureTelegramUser(ctx, tgUser); err != nil {
+		user, err := s.ensureTelegramUser(ctx, tgUser)
+		if err != nil {
 			s.log.Warn("Failed to ensure telegram user", slog.Any("err", err))
+		} else {
+			locale = user.PreferredLocale()
 		}
 	}
 
@@ -51,23 +104,10 @@ func (s *Service) defaultHandler(ctx context.Context, b *bot.Bot, update *tgbotm
 		return
 	}
 
-	text := strings.TrimSpace(update.Message.Text)
-	reply := []string{"Бот не поддерживает никаких команд, весь функционал находится в мини\\-приложении"}
-	if text == "/start" {
-		reply = []string{
-			"Добро пожаловать в бот *Malicious Learning*\\!",
-			"",
-			"С помощью этого бота ты можешь подготовиться к экзамену по машинному обучению\\. Внутри MiniApp ты найдёшь карточки с вопросами и ответами\\. А также у тебя будет персональная статистика, рассчитанная из ответов:",
-			"",
-			"\\- жми «Вспомнил» если знаешь ответ",
-			"\\- жми «Забыл» если не знаешь ответа",
-			"",
-			"Весь функционал находится в мини\\-приложении, открывай и готовься\\!",
-			"",
-			"Ещё сомневаешься или хочешь улучшить проект? [Код приложения](https://github.com/zagvozdeen/malicious-learning) публичный, доступен каждому\\. А если хочешь помочь улучшить ответы, то внутри есть инструкция, как это сделать, или можешь просто написать мне в личку\\.",
-			"",
-			"_[Связь с автором](https://t.me/denchik1170)_",
-		}
+	copies := botCopies[locale]
+	reply := copies.unknown
+	if strings.TrimSpace(update.Message.Text) == "/start" {
+		reply = copies.start
 	}
 
 	disabledPreviewOptions := true
@@ -96,34 +136,37 @@ func (s *Service) storeTelegramUpdate(ctx context.Context, update *tgbotmodels.U
 	})
 }
 
-func (s *Service) ensureTelegramUser(ctx context.Context, tgUser *tgbotmodels.User) error {
-	_, err := s.store.GetUserByTID(ctx, tgUser.ID)
+func (s *Service) ensureTelegramUser(ctx context.Context, tgUser *tgbotmodels.User) (*storemodels.User, error) {
+	user, err := s.store.GetUserByTID(ctx, tgUser.ID)
 	if err == nil {
-		return nil
+		return user, s.syncLanguageCode(ctx, user, tgUser.LanguageCode)
 	}
 	if !errors.Is(err, pgx.ErrNoRows) {
-		return err
+		return nil, err
 	}
 
 	uid, err := uuid.NewV7()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	err = s.store.CreateUser(ctx, &storemodels.User{
-		TID:       null.WrapInt(int(tgUser.ID)),
-		UUID:      uid.String(),
-		FirstName: strings.TrimSpace(tgUser.FirstName),
-		LastName:  null.WrapString(strings.TrimSpace(tgUser.LastName)),
-		Username:  null.WrapString(strings.TrimSpace(tgUser.Username)),
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-	})
+	user = &storemodels.User{
+		TID:          null.WrapInt(int(tgUser.ID)),
+		UUID:         uid.String(),
+		FirstName:    strings.TrimSpace(tgUser.FirstName),
+		LastName:     null.WrapString(strings.TrimSpace(tgUser.LastName)),
+		Username:     null.WrapString(strings.TrimSpace(tgUser.Username)),
+		LanguageCode: null.WrapString(tgUser.LanguageCode),
+		Role:         enum.UserRoleUser,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	}
+	err = s.store.CreateUser(ctx, user)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	s.metrics.AppUsersCreatedCountInc()
-	return nil
+	return user, nil
 }
 
 func extractTelegramUser(update *tgbotmodels.Update) *tgbotmodels.User {
@@ -1,17 +1,105 @@
 package api
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/zagvozdeen/malicious-learning/internal/api/core"
 	"github.com/zagvozdeen/malicious-learning/internal/store"
 )
 
-func (s *Service) getCards(r *http.Request, _ *store.User) core.Response {
-	cards, err := s.store.GetAllCards(r.Context())
+func (s *Service) getCards(r *http.Request, user *store.User) core.Response {
+	cards, err := s.store.GetAllCards(r.Context(), user.ID)
 	if err != nil {
 		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to get all cars: %w", err))
 	}
+	err = s.localizeCards(r.Context(), user.PreferredLocale(), cards)
+	if err != nil {
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to localize cards: %w", err))
+	}
 	return core.Data(http.StatusOK, cards)
 }
+
+func (s *Service) getSimilarCards(r *http.Request, user *store.User) core.Response {
+	cardUUID := r.PathValue("uuid")
+	if err := uuid.Validate(cardUUID); err != nil {
+		return core.Err(http.StatusBadRequest, fmt.Errorf("invalid uuid: %w", err))
+	}
+	card, err := s.store.GetCardByUUID(r.Context(), cardUUID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return core.Err(http.StatusNotFound, fmt.Errorf("card not found: %w", err))
+		}
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to get card: %w", err))
+	}
+	ok, err := s.store.HasCourseAccess(r.Context(), card.CourseID, user.ID)
+	if err != nil {
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to check course access: %w", err))
+	}
+	if !ok {
+		return core.Err(http.StatusNotFound, fmt.Errorf("card %s is in a private deck", card.UUID))
+	}
+	similar, err := s.store.GetSimilarCards(r.Context(), card)
+	if err != nil {
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to get similar cards: %w", err))
+	}
+	cards := make([]store.Card, len(similar))
+	for i := range similar {
+		cards[i] = similar[i].Card
+	}
+	err = s.localizeCards(r.Context(), user.PreferredLocale(), cards)
+	if err != nil {
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to localize cards: %w", err))
+	}
+	for i := range similar {
+		similar[i].Card = cards[i]
+	}
+	return core.Data(http.StatusOK, similar)
+}
+
+type relatedCardsResponse struct {
+	Outgoing  []store.Card `json:"outgoing"`
+	Backlinks []store.Card `json:"backlinks"`
+}
+
+// getRelatedCards returns cards the card links to with [[uid]] links and cards
+// linking to it.
+func (s *Service) getRelatedCards(r *http.Request, user *store.User) core.Response {
+	cardUUID := r.PathValue("uuid")
+	if err := uuid.Validate(cardUUID); err != nil {
+		return core.Err(http.StatusBadRequest, fmt.Errorf("invalid uuid: %w", err))
+	}
+	card, err := s.store.GetCardByUUID(r.Context(), cardUUID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return core.Err(http.StatusNotFound, fmt.Errorf("card not found: %w", err))
+		}
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to get card: %w", err))
+	}
+	ok, err := s.store.HasCourseAccess(r.Context(), card.CourseID, user.ID)
+	if err != nil {
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to check course access: %w", err))
+	}
+	if !ok {
+		return core.Err(http.StatusNotFound, fmt.Errorf("card %s is in a private deck", card.UUID))
+	}
+	res := relatedCardsResponse{}
+	res.Outgoing, err = s.store.GetLinkedCards(r.Context(), card)
+	if err != nil {
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to get linked cards: %w", err))
+	}
+	res.Backlinks, err = s.store.GetBacklinkedCards(r.Context(), card)
+	if err != nil {
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to get backlinked cards: %w", err))
+	}
+	for _, cards := range [][]store.Card{res.Outgoing, res.Backlinks} {
+		err = s.localizeCards(r.Context(), user.PreferredLocale(), cards)
+		if err != nil {
+			return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to localize cards: %w", err))
+		}
+	}
+	return core.Data(http.StatusOK, res)
+}
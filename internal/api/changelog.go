@@ -0,0 +1,126 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/zagvozdeen/malicious-learning/internal/api/core"
+	"github.com/zagvozdeen/malicious-learning/internal/store"
+	"github.com/zagvozdeen/malicious-learning/internal/store/enum"
+)
+
+// changelogMessageLimit is the number of questions listed in a notification.
+const changelogMessageLimit = 20
+
+func (s *Service) getChangelog(r *http.Request, user *store.User) core.Response {
+	slug := r.URL.Query().Get("course_slug")
+	if slug == "" {
+		return core.Err(http.StatusBadRequest, fmt.Errorf("missing course_slug"))
+	}
+	_, err := s.getCourseForUser(r.Context(), slug, user)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return core.Err(http.StatusNotFound, fmt.Errorf("course not found: %w", err))
+		}
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to get course: %w", err))
+	}
+	changelog, err := s.store.GetChangelog(r.Context(), slug)
+	if err != nil {
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to get changelog: %w", err))
+	}
+	return core.Data(http.StatusOK, changelog)
+}
+
+// notifyChangelog posts a summary of the conversion to the bot group and sends
+// it to users who have sessions in the affected modules.
+func (s *Service) notifyChangelog(changelog []store.ChangelogEntry) {
+	if !s.cfg.ChangelogNotify || !s.cfg.TelegramBotEnabled || len(changelog) == 0 {
+		return
+	}
+	select {
+	case <-s.ctx.Done():
+		return
+	case <-s.botReady:
+	}
+	courses, err := s.store.GetCourses(s.ctx)
+	if err != nil {
+		s.log.Error("Failed to get courses for changelog", slog.Any("err", err))
+		return
+	}
+	for _, course := range courses {
+		var entries []store.ChangelogEntry
+		var moduleIDs []int
+		for _, entry := range changelog {
+			if entry.CourseID != course.ID {
+				continue
+			}
+			entries = append(entries, entry)
+			if !slices.Contains(moduleIDs, entry.ModuleID) {
+				moduleIDs = append(moduleIDs, entry.ModuleID)
+			}
+		}
+		if len(entries) == 0 {
+			continue
+		}
+		text := formatChangelog(course.Name, entries)
+		if s.cfg.TelegramBotGroup != 0 {
+			s.sendChangelog(s.cfg.TelegramBotGroup, text)
+		}
+		users, err := s.store.GetTelegramUsersByModuleIDs(s.ctx, course.ID, moduleIDs)
+		if err != nil {
+			s.log.Error("Failed to get users for changelog", slog.Any("err", err))
+			continue
+		}
+		for _, user := range users {
+			s.sendChangelog(user.TID.V, text)
+			// Telegram allows about 30 messages per second to different chats.
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-time.After(50 * time.Millisecond):
+			}
+		}
+	}
+}
+
+func (s *Service) sendChangelog(chatID int, text string) {
+	_, err := s.bot.SendMessage(s.ctx, &bot.SendMessageParams{
+		ChatID:    chatID,
+		Text:      text,
+		ParseMode: models.ParseModeMarkdown,
+	})
+	if err != nil {
+		s.log.Warn("Failed to send changelog", slog.Any("err", err), slog.Int("chat_id", chatID))
+	}
+}
+
+func formatChangelog(courseName string, entries []store.ChangelogEntry) string {
+	lines := []string{fmt.Sprintf("*Что нового в курсе «%s»\\:*", bot.EscapeMarkdown(courseName))}
+	listed := 0
+	for _, kind := range []enum.ChangeKind{enum.ChangeKindAdded, enum.ChangeKindChanged, enum.ChangeKindRemoved} {
+		var questions []string
+		for _, entry := range entries {
+			if entry.Kind == kind && listed < changelogMessageLimit {
+				questions = append(questions, "– "+bot.EscapeMarkdown(html.UnescapeString(entry.Question)))
+				listed++
+			}
+		}
+		if len(questions) > 0 {
+			lines = append(lines, "", fmt.Sprintf("*%s\\:*", kind.Title()))
+			lines = append(lines, questions...)
+		}
+	}
+	if rest := len(entries) - listed; rest > 0 {
+		lines = append(lines, "", fmt.Sprintf("_и ещё %d_", rest))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/zagvozdeen/malicious-learning/internal/api/core"
+	"github.com/zagvozdeen/malicious-learning/internal/converter"
+	"github.com/zagvozdeen/malicious-learning/internal/store"
+)
+
+func (s *Service) getCodeThemes(_ *http.Request, _ *store.User) core.Response {
+	return core.Data(http.StatusOK, converter.Themes)
+}
+
+// getCodeThemeCSS serves the stylesheet of a code theme. It is loaded with a
+// <link> tag, so the route is public like assets.
+func (s *Service) getCodeThemeCSS(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimSuffix(r.PathValue("name"), ".css")
+	css := &bytes.Buffer{}
+	if err := converter.WriteThemeCSS(css, name); err != nil {
+		http.Error(w, "theme not found", http.StatusNotFound)
+		s.metrics.AppResponsesTotalInc(r.Pattern, http.StatusNotFound)
+		return
+	}
+	sum := sha256.Sum256(css.Bytes())
+	etag := strconv.Quote(hex.EncodeToString(sum[:8]))
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		s.metrics.AppResponsesTotalInc(r.Pattern, http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "text/css; charset=utf-8")
+	w.Header().Set("Content-Length", strconv.Itoa(css.Len()))
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(css.Bytes()); err != nil {
+		s.log.Warn("Failed to write code theme", slog.Any("err", err))
+	}
+	s.metrics.AppResponsesTotalInc(r.Pattern, http.StatusOK)
+}
@@ -5,7 +5,9 @@ import (
 	"encoding/json/v2"
 	"errors"
 	"log/slog"
+	"mime"
 	"net/http"
+	"strconv"
 
 	"github.com/zagvozdeen/malicious-learning/internal/store"
 )
@@ -31,9 +33,16 @@ type FlushData struct {
 	data <-chan []byte
 }
 
+type FileData struct {
+	name        string
+	contentType string
+	data        []byte
+}
+
 var _ Response = (*ResponseError)(nil)
 var _ Response = (*ResponseData)(nil)
 var _ Response = (*FlushData)(nil)
+var _ Response = (*FileData)(nil)
 
 func Err(code int, err error) *ResponseError {
 	return &ResponseError{code: code, err: err}
@@ -47,6 +56,10 @@ func Flush(ctx context.Context, data <-chan []byte) *FlushData {
 	return &FlushData{ctx: ctx, data: data}
 }
 
+func File(name, contentType string, data []byte) *FileData {
+	return &FileData{name: name, contentType: contentType, data: data}
+}
+
 func (r *ResponseError) Response(w http.ResponseWriter, log *slog.Logger) int {
 	log.Debug("Internal error", slog.Any("err", r.err), slog.Int("code", r.code))
 	http.Error(w, r.err.Error(), r.code)
@@ -93,3 +106,14 @@ func (r *FlushData) Response(w http.ResponseWriter, log *slog.Logger) int {
 		}
 	}
 }
+
+func (r *FileData) Response(w http.ResponseWriter, log *slog.Logger) int {
+	w.Header().Set("Content-Type", r.contentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": r.name}))
+	w.Header().Set("Content-Length", strconv.Itoa(len(r.data)))
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(r.data); err != nil {
+		log.Error("Failed to write file", slog.Any("err", err))
+	}
+	return http.StatusOK
+}
@@ -1,17 +1,40 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/zagvozdeen/malicious-learning/internal/api/core"
 	"github.com/zagvozdeen/malicious-learning/internal/store"
 )
 
-func (s *Service) getCourses(r *http.Request, _ *store.User) core.Response {
-	courses, err := s.store.GetCourses(r.Context())
+func (s *Service) getCourses(r *http.Request, user *store.User) core.Response {
+	courses, err := s.store.GetCoursesForUser(r.Context(), user.ID)
 	if err != nil {
 		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to get courses: %w", err))
 	}
+	err = s.localizeCourses(r.Context(), user.PreferredLocale(), courses)
+	if err != nil {
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to localize courses: %w", err))
+	}
 	return core.Data(http.StatusOK, courses)
 }
+
+// getCourseForUser returns the course by slug, a private deck the user has no
+// access to is not found.
+func (s *Service) getCourseForUser(ctx context.Context, slug string, user *store.User) (*store.Course, error) {
+	course, err := s.store.GetCourseBySlug(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	ok, err := s.store.HasCourseAccess(ctx, course.ID, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, pgx.ErrNoRows
+	}
+	return course, nil
+}
@@ -0,0 +1,234 @@
+package api
+
+import (
+	"encoding/json/v2"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/zagvozdeen/malicious-learning/internal/api/core"
+	"github.com/zagvozdeen/malicious-learning/internal/converter"
+	"github.com/zagvozdeen/malicious-learning/internal/db/null"
+	"github.com/zagvozdeen/malicious-learning/internal/store"
+)
+
+// maxDeckUpload is the size limit of an uploaded deck zip.
+const maxDeckUpload = 20 << 20
+
+type deckResponse struct {
+	Course  *store.Course `json:"course"`
+	Cards   int           `json:"cards"`
+	Changes int           `json:"changes"`
+}
+
+// deckSlug is the course slug of the user's deck, the user id keeps decks of
+// different users and courses of data/courses apart.
+func deckSlug(user *store.User, slug string) string {
+	return fmt.Sprintf("u%d-%s", user.ID, slug)
+}
+
+// createDeck imports a zip in the data/courses/<slug> layout as a private
+// course of the user. The slug form field names the deck.
+func (s *Service) createDeck(r *http.Request, user *store.User) core.Response {
+	r.Body = http.MaxBytesReader(nil, r.Body, maxDeckUpload)
+	slug := strings.TrimSpace(r.FormValue("slug"))
+	if !courseSlugRe.MatchString(slug) {
+		return core.Err(http.StatusBadRequest, fmt.Errorf("invalid slug"))
+	}
+	slug = deckSlug(user, slug)
+	_, err := s.store.GetCourseBySlug(r.Context(), slug)
+	if err == nil {
+		return core.Err(http.StatusConflict, fmt.Errorf("deck %s already exists", slug))
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to get course: %w", err))
+	}
+	uid, err := uuid.NewV7()
+	if err != nil {
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to create uuid v7: %w", err))
+	}
+	course := &store.Course{
+		UUID:    uid.String(),
+		Slug:    slug,
+		OwnerID: null.WrapInt(user.ID),
+	}
+	return s.importDeck(r, course, http.StatusCreated)
+}
+
+// updateDeck re-uploads the deck, cards are matched by uid so progress on
+// unchanged cards is kept.
+func (s *Service) updateDeck(r *http.Request, user *store.User) core.Response {
+	r.Body = http.MaxBytesReader(nil, r.Body, maxDeckUpload)
+	course, res := s.getOwnDeck(r, user)
+	if res != nil {
+		return res
+	}
+	return s.importDeck(r, course, http.StatusOK)
+}
+
+func (s *Service) importDeck(r *http.Request, course *store.Course, status int) core.Response {
+	deck, err := readDeckUpload(r, course.Slug)
+	if err != nil {
+		return core.Err(http.StatusBadRequest, err)
+	}
+	cards, err := converter.ValidateDeck(deck, course.Slug)
+	if err != nil {
+		return core.Err(http.StatusUnprocessableEntity, fmt.Errorf("failed to convert deck: %w", err))
+	}
+	result, err := converter.Import(r.Context(), s.store, course, deck)
+	if errors.Is(err, converter.ErrCourseExists) {
+		return core.Err(http.StatusConflict, err)
+	}
+	if err != nil {
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to import deck: %w", err))
+	}
+	return core.Data(status, deckResponse{Course: course, Cards: cards, Changes: len(result.Changelog)})
+}
+
+func readDeckUpload(r *http.Request, slug string) (fs.FS, error) {
+	f, header, err := r.FormFile("file")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	defer f.Close()
+	return converter.ReadDeck(f, header.Size, slug)
+}
+
+// getOwnDeck returns the deck by slug, only the owner may change it.
+func (s *Service) getOwnDeck(r *http.Request, user *store.User) (*store.Course, core.Response) {
+	course, err := s.getCourseForUser(r.Context(), r.PathValue("slug"), user)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, core.Err(http.StatusNotFound, fmt.Errorf("deck not found: %w", err))
+		}
+		return nil, core.Err(http.StatusInternalServerError, fmt.Errorf("failed to get course: %w", err))
+	}
+	if !course.OwnerID.Valid || course.OwnerID.V != user.ID {
+		return nil, core.Err(http.StatusForbidden, fmt.Errorf("user %d does not own %s", user.ID, course.Slug))
+	}
+	return course, nil
+}
+
+func (s *Service) getDeckMembers(r *http.Request, user *store.User) core.Response {
+	course, res := s.getOwnDeck(r, user)
+	if res != nil {
+		return res
+	}
+	members, err := s.store.GetCourseMembers(r.Context(), course.ID)
+	if err != nil {
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to get members: %w", err))
+	}
+	return core.Data(http.StatusOK, members)
+}
+
+type addDeckMemberRequest struct {
+	Username string `json:"username"`
+}
+
+// addDeckMember invites the user by Telegram username, the deck is shared
+// once the user accepts the invite.
+func (s *Service) addDeckMember(r *http.Request, user *store.User) core.Response {
+	course, res := s.getOwnDeck(r, user)
+	if res != nil {
+		return res
+	}
+	var payload addDeckMemberRequest
+	if err := json.UnmarshalRead(r.Body, &payload); err != nil {
+		return core.Err(http.StatusBadRequest, fmt.Errorf("invalid json body: %w", err))
+	}
+	username := strings.TrimPrefix(strings.TrimSpace(payload.Username), "@")
+	if username == "" {
+		return core.Err(http.StatusBadRequest, fmt.Errorf("empty username"))
+	}
+	member, err := s.store.GetUserByUsername(r.Context(), username)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return core.Err(http.StatusNotFound, fmt.Errorf("user not found: %w", err))
+		}
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to get user: %w", err))
+	}
+	if member.ID == user.ID {
+		return core.Err(http.StatusBadRequest, fmt.Errorf("owner is not a member"))
+	}
+	err = s.store.AddCourseMember(r.Context(), course.ID, member.ID, time.Now())
+	if err != nil {
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to add member: %w", err))
+	}
+	return core.Data(http.StatusNoContent, nil)
+}
+
+func (s *Service) removeDeckMember(r *http.Request, user *store.User) core.Response {
+	course, res := s.getOwnDeck(r, user)
+	if res != nil {
+		return res
+	}
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return core.Err(http.StatusBadRequest, fmt.Errorf("invalid user id: %w", err))
+	}
+	err = s.store.RemoveCourseMember(r.Context(), course.ID, id)
+	if err != nil {
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to remove member: %w", err))
+	}
+	return core.Data(http.StatusNoContent, nil)
+}
+
+func (s *Service) getDeckInvites(r *http.Request, user *store.User) core.Response {
+	invites, err := s.store.GetDeckInvites(r.Context(), user.ID)
+	if err != nil {
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to get deck invites: %w", err))
+	}
+	return core.Data(http.StatusOK, invites)
+}
+
+// acceptDeckInvite shows the deck shared with the user, a deck is not shown
+// to a member before they agree to it.
+func (s *Service) acceptDeckInvite(r *http.Request, user *store.User) core.Response {
+	course, res := s.getSharedDeck(r)
+	if res != nil {
+		return res
+	}
+	err := s.store.AcceptCourseMember(r.Context(), course.ID, user.ID, time.Now())
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return core.Err(http.StatusNotFound, fmt.Errorf("invite not found: %w", err))
+		}
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to accept invite: %w", err))
+	}
+	return core.Data(http.StatusNoContent, nil)
+}
+
+// declineDeckInvite declines the invite or leaves the accepted deck.
+func (s *Service) declineDeckInvite(r *http.Request, user *store.User) core.Response {
+	course, res := s.getSharedDeck(r)
+	if res != nil {
+		return res
+	}
+	err := s.store.RemoveCourseMember(r.Context(), course.ID, user.ID)
+	if err != nil {
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to remove member: %w", err))
+	}
+	return core.Data(http.StatusNoContent, nil)
+}
+
+// getSharedDeck returns the deck by slug for its invited members, who do not
+// see it before they accept.
+func (s *Service) getSharedDeck(r *http.Request) (*store.Course, core.Response) {
+	course, err := s.store.GetCourseBySlug(r.Context(), r.PathValue("slug"))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, core.Err(http.StatusNotFound, fmt.Errorf("deck not found: %w", err))
+		}
+		return nil, core.Err(http.StatusInternalServerError, fmt.Errorf("failed to get course: %w", err))
+	}
+	if !course.OwnerID.Valid {
+		return nil, core.Err(http.StatusNotFound, fmt.Errorf("course %s is not a deck", course.Slug))
+	}
+	return course, nil
+}
@@ -0,0 +1,403 @@
+package api
+
+import (
+	"encoding/json/v2"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/zagvozdeen/malicious-learning/internal/api/core"
+	"github.com/zagvozdeen/malicious-learning/internal/converter"
+	"github.com/zagvozdeen/malicious-learning/internal/db/null"
+	"github.com/zagvozdeen/malicious-learning/internal/store"
+	"github.com/zagvozdeen/malicious-learning/internal/store/enum"
+)
+
+var (
+	courseSlugRe = regexp.MustCompile(`^[a-z0-9_-]+$`)
+	cardFileRe   = regexp.MustCompile(`^[0-9]+_[a-z0-9_]*(\.[a-z]{2})?\.md$`)
+)
+
+// editor allows the handler to users with the editor role only.
+func (s *Service) editor(fn core.HandlerFunc) http.HandlerFunc {
+	return s.auth(func(r *http.Request, user *store.User) core.Response {
+		if !user.Role.CanEdit() {
+			return core.Err(http.StatusForbidden, fmt.Errorf("user %d is not an editor", user.ID))
+		}
+		return fn(r, user)
+	})
+}
+
+func (s *Service) admin(fn core.HandlerFunc) http.HandlerFunc {
+	return s.auth(func(r *http.Request, user *store.User) core.Response {
+		if user.Role != enum.UserRoleAdmin {
+			return core.Err(http.StatusForbidden, fmt.Errorf("user %d is not an admin", user.ID))
+		}
+		return fn(r, user)
+	})
+}
+
+type createCourseRequest struct {
+	Slug string `json:"slug"`
+	Name string `json:"name"`
+}
+
+func (s *Service) createCourse(r *http.Request, _ *store.User) core.Response {
+	var payload createCourseRequest
+	if err := json.UnmarshalRead(r.Body, &payload); err != nil {
+		return core.Err(http.StatusBadRequest, fmt.Errorf("invalid json body: %w", err))
+	}
+	payload.Name = strings.TrimSpace(payload.Name)
+	if !courseSlugRe.MatchString(payload.Slug) || payload.Name == "" {
+		return core.Err(http.StatusBadRequest, fmt.Errorf("invalid slug or empty name"))
+	}
+	_, err := s.store.GetCourseBySlug(r.Context(), payload.Slug)
+	if err == nil {
+		return core.Err(http.StatusConflict, fmt.Errorf("course %s already exists", payload.Slug))
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to get course: %w", err))
+	}
+	uid, err := uuid.NewV7()
+	if err != nil {
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to create uuid v7: %w", err))
+	}
+	course := &store.Course{
+		UUID:      uid.String(),
+		Slug:      payload.Slug,
+		Name:      payload.Name,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	err = s.store.CreateCourse(r.Context(), course)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return core.Err(http.StatusConflict, fmt.Errorf("course %s already exists", payload.Slug))
+	}
+	if err != nil {
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to create course: %w", err))
+	}
+	return core.Data(http.StatusCreated, course)
+}
+
+type updateCourseRequest struct {
+	Name string `json:"name"`
+}
+
+func (s *Service) updateCourse(r *http.Request, _ *store.User) core.Response {
+	var payload updateCourseRequest
+	if err := json.UnmarshalRead(r.Body, &payload); err != nil {
+		return core.Err(http.StatusBadRequest, fmt.Errorf("invalid json body: %w", err))
+	}
+	payload.Name = strings.TrimSpace(payload.Name)
+	if payload.Name == "" {
+		return core.Err(http.StatusBadRequest, fmt.Errorf("empty name"))
+	}
+	course, err := s.store.GetCourseBySlug(r.Context(), r.PathValue("slug"))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return core.Err(http.StatusNotFound, fmt.Errorf("course not found: %w", err))
+		}
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to get course: %w", err))
+	}
+	if course.OwnerID.Valid {
+		return core.Err(http.StatusConflict, fmt.Errorf("course %s is a private deck, upload it instead", course.Slug))
+	}
+	course.Name = payload.Name
+	course.UpdatedAt = time.Now()
+	err = s.store.UpdateCourseName(r.Context(), course)
+	if err != nil {
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to update course: %w", err))
+	}
+	return core.Data(http.StatusOK, course)
+}
+
+type createModuleRequest struct {
+	Name string `json:"name"`
+}
+
+func (s *Service) createModule(r *http.Request, _ *store.User) core.Response {
+	var payload createModuleRequest
+	if err := json.UnmarshalRead(r.Body, &payload); err != nil {
+		return core.Err(http.StatusBadRequest, fmt.Errorf("invalid json body: %w", err))
+	}
+	payload.Name = strings.TrimSpace(payload.Name)
+	if payload.Name == "" {
+		return core.Err(http.StatusBadRequest, fmt.Errorf("empty name"))
+	}
+	_, err := s.store.GetModuleByName(r.Context(), payload.Name, null.Int{})
+	if err == nil {
+		return core.Err(http.StatusConflict, fmt.Errorf("module %q already exists", payload.Name))
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to get module: %w", err))
+	}
+	uid, err := uuid.NewV7()
+	if err != nil {
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to create uuid v7: %w", err))
+	}
+	module := &store.Module{
+		UUID:      uid.String(),
+		Name:      payload.Name,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	err = s.store.CreateModule(r.Context(), module)
+	if err != nil {
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to create module: %w", err))
+	}
+	return core.Data(http.StatusCreated, module)
+}
+
+func (s *Service) deleteModule(r *http.Request, _ *store.User) core.Response {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return core.Err(http.StatusBadRequest, fmt.Errorf("invalid module id: %w", err))
+	}
+	err = s.store.DeleteModule(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return core.Err(http.StatusNotFound, fmt.Errorf("module not found: %w", err))
+		}
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return core.Err(http.StatusConflict, fmt.Errorf("module has cards: %w", err))
+		}
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to delete module: %w", err))
+	}
+	return core.Data(http.StatusOK, nil)
+}
+
+func (s *Service) getSourceFiles(r *http.Request, _ *store.User) core.Response {
+	files, err := converter.SourceFiles(r.Context(), s.store, r.PathValue("slug"))
+	if err != nil {
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to list files: %w", err))
+	}
+	return core.Data(http.StatusOK, files)
+}
+
+type sourceResponse struct {
+	FileName string `json:"file_name"`
+	Content  string `json:"content"`
+}
+
+func (s *Service) getSource(r *http.Request, _ *store.User) core.Response {
+	name := r.PathValue("file")
+	if !cardFileRe.MatchString(name) {
+		return core.Err(http.StatusBadRequest, fmt.Errorf("invalid file name %q", name))
+	}
+	b, err := converter.ReadSource(r.Context(), s.store, r.PathValue("slug"), name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return core.Err(http.StatusNotFound, fmt.Errorf("file not found: %w", err))
+		}
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to read file: %w", err))
+	}
+	return core.Data(http.StatusOK, sourceResponse{FileName: name, Content: string(b)})
+}
+
+func (s *Service) getDrafts(r *http.Request, _ *store.User) core.Response {
+	status := enum.DraftStatusDraft
+	if v := r.URL.Query().Get("status"); v != "" {
+		var err error
+		status, err = enum.NewDraftStatus(v)
+		if err != nil {
+			return core.Err(http.StatusBadRequest, err)
+		}
+	}
+	drafts, err := s.store.GetCardDrafts(r.Context(), status)
+	if err != nil {
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to get drafts: %w", err))
+	}
+	return core.Data(http.StatusOK, drafts)
+}
+
+type createDraftRequest struct {
+	CourseSlug string `json:"course_slug"`
+	// FileName is empty for a new card.
+	FileName string `json:"file_name"`
+	// Content defaults to the current file for an existing card.
+	Content null.String `json:"content"`
+	// Remove makes a draft removing the card file.
+	Remove bool `json:"remove"`
+}
+
+func (s *Service) createDraft(r *http.Request, user *store.User) core.Response {
+	var payload createDraftRequest
+	if err := json.UnmarshalRead(r.Body, &payload); err != nil {
+		return core.Err(http.StatusBadRequest, fmt.Errorf("invalid json body: %w", err))
+	}
+	course, err := s.store.GetCourseBySlug(r.Context(), payload.CourseSlug)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return core.Err(http.StatusNotFound, fmt.Errorf("course not found: %w", err))
+		}
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to get course: %w", err))
+	}
+	if course.OwnerID.Valid {
+		return core.Err(http.StatusConflict, fmt.Errorf("course %s is a private deck, upload it instead", course.Slug))
+	}
+	files, err := converter.SourceFiles(r.Context(), s.store, course.Slug)
+	if err != nil {
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to list files: %w", err))
+	}
+	drafts, err := s.store.GetCardDrafts(r.Context(), enum.DraftStatusDraft)
+	if err != nil {
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to get drafts: %w", err))
+	}
+	content := payload.Content
+	switch {
+	case payload.FileName == "":
+		if payload.Remove || !content.Valid {
+			return core.Err(http.StatusBadRequest, fmt.Errorf("new card needs content"))
+		}
+		for _, d := range drafts {
+			if d.CourseID == course.ID {
+				files = append(files, d.FileName)
+			}
+		}
+		payload.FileName = converter.NewFileName(files)
+	case !cardFileRe.MatchString(payload.FileName):
+		return core.Err(http.StatusBadRequest, fmt.Errorf("invalid file name %q", payload.FileName))
+	case !slices.Contains(files, payload.FileName):
+		return core.Err(http.StatusNotFound, fmt.Errorf("file %s not found", payload.FileName))
+	case payload.Remove:
+		content = null.String{}
+	case !content.Valid:
+		var b []byte
+		b, err = converter.ReadSource(r.Context(), s.store, course.Slug, payload.FileName)
+		if err != nil {
+			return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to read file: %w", err))
+		}
+		content = null.NewString(string(b), true)
+	}
+	for _, d := range drafts {
+		if d.CourseID == course.ID && d.FileName == payload.FileName {
+			return core.Err(http.StatusConflict, fmt.Errorf("file %s already has draft %s", d.FileName, d.UUID))
+		}
+	}
+	uid, err := uuid.NewV7()
+	if err != nil {
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to create uuid v7: %w", err))
+	}
+	draft := &store.CardDraft{
+		UUID:       uid.String(),
+		CourseID:   course.ID,
+		CourseSlug: course.Slug,
+		CourseName: course.Name,
+		FileName:   payload.FileName,
+		Content:    content,
+		Status:     enum.DraftStatusDraft,
+		AuthorID:   user.ID,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+	err = s.store.CreateCardDraft(r.Context(), draft)
+	if err != nil {
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to create draft: %w", err))
+	}
+	return core.Data(http.StatusCreated, draft)
+}
+
+// getOpenDraft loads the draft of the request that is not published or
+// discarded yet.
+func (s *Service) getOpenDraft(r *http.Request) (*store.CardDraft, core.Response) {
+	draft, err := s.store.GetCardDraftByUUID(r.Context(), r.PathValue("uuid"))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, core.Err(http.StatusNotFound, fmt.Errorf("draft not found: %w", err))
+		}
+		return nil, core.Err(http.StatusInternalServerError, fmt.Errorf("failed to get draft: %w", err))
+	}
+	if draft.Status != enum.DraftStatusDraft {
+		return nil, core.Err(http.StatusConflict, fmt.Errorf("draft is %s", draft.Status))
+	}
+	return draft, nil
+}
+
+func (s *Service) getDraft(r *http.Request, _ *store.User) core.Response {
+	draft, err := s.store.GetCardDraftByUUID(r.Context(), r.PathValue("uuid"))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return core.Err(http.StatusNotFound, fmt.Errorf("draft not found: %w", err))
+		}
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to get draft: %w", err))
+	}
+	return core.Data(http.StatusOK, draft)
+}
+
+type updateDraftRequest struct {
+	Content null.String `json:"content"`
+}
+
+func (s *Service) updateDraft(r *http.Request, _ *store.User) core.Response {
+	var payload updateDraftRequest
+	if err := json.UnmarshalRead(r.Body, &payload); err != nil {
+		return core.Err(http.StatusBadRequest, fmt.Errorf("invalid json body: %w", err))
+	}
+	draft, res := s.getOpenDraft(r)
+	if res != nil {
+		return res
+	}
+	draft.Content = payload.Content
+	draft.UpdatedAt = time.Now()
+	err := s.store.UpdateCardDraft(r.Context(), draft)
+	if err != nil {
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to update draft: %w", err))
+	}
+	return core.Data(http.StatusOK, draft)
+}
+
+func (s *Service) discardDraft(r *http.Request, _ *store.User) core.Response {
+	draft, res := s.getOpenDraft(r)
+	if res != nil {
+		return res
+	}
+	draft.Status = enum.DraftStatusDiscarded
+	draft.UpdatedAt = time.Now()
+	err := s.store.UpdateCardDraft(r.Context(), draft)
+	if err != nil {
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to discard draft: %w", err))
+	}
+	return core.Data(http.StatusOK, draft)
+}
+
+func (s *Service) previewDraft(r *http.Request, _ *store.User) core.Response {
+	draft, res := s.getOpenDraft(r)
+	if res != nil {
+		return res
+	}
+	cards, err := converter.Preview(r.Context(), s.store, draft)
+	if err != nil {
+		return core.Err(http.StatusUnprocessableEntity, fmt.Errorf("failed to render draft: %w", err))
+	}
+	return core.Data(http.StatusOK, cards)
+}
+
+// publishDraft publishes the draft as new card versions. The draft stays
+// published on top of data/courses until exported to the repository.
+func (s *Service) publishDraft(r *http.Request, _ *store.User) core.Response {
+	draft, res := s.getOpenDraft(r)
+	if res != nil {
+		return res
+	}
+	if _, err := converter.Preview(r.Context(), s.store, draft); err != nil {
+		return core.Err(http.StatusUnprocessableEntity, fmt.Errorf("failed to render draft: %w", err))
+	}
+	result, err := converter.Publish(r.Context(), s.store, draft)
+	if err != nil {
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to publish draft: %w", err))
+	}
+	s.log.Info("Draft published", slog.String("draft", draft.UUID), slog.Int("changes", len(result.Changelog)))
+	s.wg.Go(func() { s.notifyChangelog(result.Changelog) })
+	return core.Data(http.StatusOK, draft)
+}
@@ -0,0 +1,44 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/zagvozdeen/malicious-learning/internal/api/core"
+	"github.com/zagvozdeen/malicious-learning/internal/db/null"
+	"github.com/zagvozdeen/malicious-learning/internal/exporter"
+	"github.com/zagvozdeen/malicious-learning/internal/store"
+)
+
+// exportCourse downloads the course as an Anki package, a CSV table or a
+// booklet. With forgotten=true only cards the user has forgotten are exported.
+func (s *Service) exportCourse(r *http.Request, user *store.User) core.Response {
+	slug := r.PathValue("slug")
+	format, err := exporter.ParseFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		return core.Err(http.StatusBadRequest, err)
+	}
+	_, err = s.getCourseForUser(r.Context(), slug, user)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return core.Err(http.StatusNotFound, fmt.Errorf("course not found: %w", err))
+		}
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to get course: %w", err))
+	}
+	var forgottenBy null.Int
+	if r.URL.Query().Get("forgotten") == "true" {
+		forgottenBy = null.WrapInt(user.ID)
+	}
+	buf := &bytes.Buffer{}
+	err = exporter.New(s.store).Export(r.Context(), buf, format, slug, forgottenBy)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return core.Err(http.StatusNotFound, fmt.Errorf("course not found: %w", err))
+		}
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to export course: %w", err))
+	}
+	return core.File(format.Filename(slug), format.ContentType(), buf.Bytes())
+}
@@ -0,0 +1,189 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/zagvozdeen/malicious-learning/internal/db/null"
+	"github.com/zagvozdeen/malicious-learning/internal/llm"
+	"github.com/zagvozdeen/malicious-learning/internal/store"
+	"github.com/zagvozdeen/malicious-learning/internal/store/enum"
+)
+
+const (
+	jobWorkers      = 4
+	jobMaxAttempts  = 5
+	jobPollInterval = 10 * time.Second
+	// jobLockTimeout is how long a job may run before another worker takes it
+	// over, it only happens when the server stopped in the middle of a job.
+	jobLockTimeout  = 10 * time.Minute
+	jobRetryDelay   = 30 * time.Second
+	jobMaxRetryWait = time.Hour
+)
+
+// enqueueJob adds a job to the queue, it reports false when the same job is
+// already pending or running. Call wakeJobs once the job is committed.
+func (s *Service) enqueueJob(ctx context.Context, kind enum.JobKind, key string) (*store.Job, bool, error) {
+	uid, err := uuid.NewV7()
+	if err != nil {
+		return nil, false, err
+	}
+	now := time.Now()
+	job := &store.Job{
+		UUID:        uid.String(),
+		Kind:        kind,
+		Key:         key,
+		Status:      enum.JobStatusPending,
+		MaxAttempts: jobMaxAttempts,
+		RunAt:       now,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	created, err := s.store.CreateJob(ctx, job)
+	if err != nil {
+		return nil, false, err
+	}
+	return job, created, nil
+}
+
+var (
+	// errJobLockTimeout kills a job whose last attempt ran past the lock
+	// timeout.
+	errJobLockTimeout = errors.New("job timed out on its last attempt")
+	// errJobInterrupted marks a run cancelled by shutdown.
+	errJobInterrupted = errors.New("job interrupted by shutdown")
+)
+
+// wakeJobs makes an idle worker look for due jobs without waiting for the
+// poll interval.
+func (s *Service) wakeJobs() {
+	select {
+	case s.jobs <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Service) enqueueRecommendations(ctx context.Context, testSessionID int) (*store.Job, bool, error) {
+	return s.enqueueJob(ctx, enum.JobKindRecommendations, strconv.Itoa(testSessionID))
+}
+
+func (s *Service) startJobs() {
+	wg := &sync.WaitGroup{}
+	for range jobWorkers {
+		wg.Go(s.runJobs)
+	}
+	wg.Wait()
+}
+
+func (s *Service) runJobs() {
+	ticker := time.NewTicker(jobPollInterval)
+	defer ticker.Stop()
+	for {
+		for s.runNextJob() {
+		}
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-s.jobs:
+		case <-ticker.C:
+		}
+	}
+}
+
+// runNextJob runs one due job and reports whether there was one.
+func (s *Service) runNextJob() bool {
+	if s.ctx.Err() != nil {
+		return false
+	}
+	now := time.Now()
+	job, err := s.store.ClaimJob(s.ctx, now, now.Add(-jobLockTimeout))
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) && s.ctx.Err() == nil {
+			s.log.Error("Failed to claim job", slog.Any("err", err))
+		}
+		return false
+	}
+
+	if job.Attempts > job.MaxAttempts {
+		// A stale job is claimed again after its last attempt timed out.
+		err = errJobLockTimeout
+	} else {
+		err = s.executeJob(job)
+	}
+	if s.ctx.Err() != nil && err != nil {
+		// The server is stopping, the run did not fail on its own.
+		err = fmt.Errorf("%w: %w", errJobInterrupted, err)
+	}
+	finishJob(job, err, time.Now())
+	switch {
+	case job.Status == enum.JobStatusDead:
+		s.log.Error("Job is dead", slog.String("kind", job.Kind.String()), slog.String("key", job.Key), slog.Any("err", err))
+	case err != nil:
+		s.log.Warn("Job failed, retrying", slog.String("kind", job.Kind.String()), slog.String("key", job.Key), slog.Int("attempts", job.Attempts), slog.Any("err", err))
+	}
+	// The job is saved on shutdown too, otherwise it waits for the lock timeout.
+	err = s.store.UpdateJob(context.WithoutCancel(s.ctx), job)
+	if err != nil {
+		s.log.Error("Failed to update job", slog.Any("err", err))
+	}
+	return true
+}
+
+// finishJob sets the state of the claimed job after the run ended with err.
+// Runs that could not start, because the user is busy or out of quota, and
+// runs interrupted by shutdown give the attempt back.
+func finishJob(job *store.Job, err error, now time.Time) {
+	job.LockedAt = nil
+	job.UpdatedAt = now
+	job.LastError = null.String{}
+	if err != nil {
+		job.LastError = null.WrapString(err.Error())
+	}
+	switch {
+	case err == nil:
+		job.Status = enum.JobStatusDone
+	case errors.Is(err, llm.ErrQuotaExceeded):
+		// Quotas are daily, so the job waits for the next day and never dies.
+		job.Status = enum.JobStatusPending
+		job.Attempts--
+		job.RunAt = llm.StartOfDay(now).AddDate(0, 0, 1)
+	case errors.Is(err, errRecommendationsBusy), errors.Is(err, errJobInterrupted):
+		job.Status = enum.JobStatusPending
+		job.Attempts--
+		job.RunAt = now.Add(jobRetryDelay)
+	case job.Attempts >= job.MaxAttempts:
+		job.Status = enum.JobStatusDead
+	default:
+		job.Status = enum.JobStatusPending
+		job.RunAt = now.Add(retryDelay(job.Attempts))
+	}
+}
+
+func (s *Service) executeJob(job *store.Job) error {
+	switch job.Kind {
+	case enum.JobKindRecommendations:
+		id, err := strconv.Atoi(job.Key)
+		if err != nil {
+			return fmt.Errorf("invalid test session id: %w", err)
+		}
+		return s.generateRecommendations(id)
+	default:
+		return fmt.Errorf("unknown job kind: %s", job.Kind)
+	}
+}
+
+// retryDelay doubles the delay with every failed attempt up to an hour.
+func retryDelay(attempts int) time.Duration {
+	delay := jobRetryDelay
+	for i := 1; i < attempts && delay < jobMaxRetryWait; i++ {
+		delay *= 2
+	}
+	return min(delay, jobMaxRetryWait)
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zagvozdeen/malicious-learning/internal/llm"
+	"github.com/zagvozdeen/malicious-learning/internal/store"
+	"github.com/zagvozdeen/malicious-learning/internal/store/enum"
+)
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 0, want: 30 * time.Second},
+		{attempts: 1, want: 30 * time.Second},
+		{attempts: 2, want: time.Minute},
+		{attempts: 3, want: 2 * time.Minute},
+		{attempts: 5, want: 8 * time.Minute},
+		{attempts: 8, want: time.Hour},
+		{attempts: 100, want: time.Hour},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, retryDelay(tt.attempts), "attempts %d", tt.attempts)
+	}
+}
+
+func TestFinishJob(t *testing.T) {
+	now := time.Date(2026, 3, 10, 15, 4, 5, 0, time.Local)
+	lockedAt := now.Add(-time.Minute)
+	tests := []struct {
+		name      string
+		attempts  int
+		err       error
+		status    enum.JobStatus
+		wantTries int
+		runAt     time.Time
+	}{
+		{name: "done", attempts: 1, status: enum.JobStatusDone, wantTries: 1},
+		{name: "retry", attempts: 2, err: errors.New("llm failed"), status: enum.JobStatusPending, wantTries: 2, runAt: now.Add(time.Minute)},
+		{name: "last attempt", attempts: 5, err: errors.New("llm failed"), status: enum.JobStatusDead, wantTries: 5},
+		{name: "stale job", attempts: 6, err: errJobLockTimeout, status: enum.JobStatusDead, wantTries: 6},
+		{name: "busy", attempts: 5, err: fmt.Errorf("%w: user 1", errRecommendationsBusy), status: enum.JobStatusPending, wantTries: 4, runAt: now.Add(jobRetryDelay)},
+		{name: "shutdown", attempts: 5, err: fmt.Errorf("%w: %w", errJobInterrupted, context.Canceled), status: enum.JobStatusPending, wantTries: 4, runAt: now.Add(jobRetryDelay)},
+		{name: "quota", attempts: 5, err: fmt.Errorf("generate: %w", llm.ErrQuotaExceeded), status: enum.JobStatusPending, wantTries: 4, runAt: time.Date(2026, 3, 11, 0, 0, 0, 0, time.Local)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job := &store.Job{
+				Status:      enum.JobStatusRunning,
+				Attempts:    tt.attempts,
+				MaxAttempts: jobMaxAttempts,
+				LockedAt:    &lockedAt,
+			}
+			finishJob(job, tt.err, now)
+			assert.Equal(t, tt.status, job.Status)
+			assert.Equal(t, tt.wantTries, job.Attempts)
+			assert.Nil(t, job.LockedAt)
+			assert.Equal(t, now, job.UpdatedAt)
+			if !tt.runAt.IsZero() {
+				assert.Equal(t, tt.runAt, job.RunAt)
+			}
+			if tt.err == nil {
+				assert.False(t, job.LastError.Valid)
+			} else {
+				assert.Equal(t, tt.err.Error(), job.LastError.V)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/zagvozdeen/malicious-learning/internal/api/core"
+	"github.com/zagvozdeen/malicious-learning/internal/llm"
+	"github.com/zagvozdeen/malicious-learning/internal/store"
+)
+
+type getLLMUsageResponse struct {
+	From  time.Time        `json:"from"`
+	To    time.Time        `json:"to"`
+	Total store.LLMTotals  `json:"total"`
+	Usage []store.LLMUsage `json:"usage"`
+}
+
+// getLLMUsage reports usage by day, user and model for the days in
+// [from, to], by default for the last 30 days.
+func (s *Service) getLLMUsage(r *http.Request, _ *store.User) core.Response {
+	to := llm.StartOfDay(time.Now())
+	from := to.AddDate(0, 0, -29)
+	var err error
+	if v := r.URL.Query().Get("from"); v != "" {
+		from, err = time.ParseInLocation(time.DateOnly, v, time.Local)
+		if err != nil {
+			return core.Err(http.StatusBadRequest, fmt.Errorf("invalid from: %w", err))
+		}
+	}
+	if v := r.URL.Query().Get("to"); v != "" {
+		to, err = time.ParseInLocation(time.DateOnly, v, time.Local)
+		if err != nil {
+			return core.Err(http.StatusBadRequest, fmt.Errorf("invalid to: %w", err))
+		}
+	}
+	if to.Before(from) {
+		return core.Err(http.StatusBadRequest, errors.New("to is before from"))
+	}
+	usage, err := s.store.GetLLMUsage(r.Context(), from, to.AddDate(0, 0, 1))
+	if err != nil {
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to get llm usage: %w", err))
+	}
+	res := getLLMUsageResponse{From: from, To: to, Usage: usage}
+	for _, u := range usage {
+		res.Total.Requests += u.Requests
+		res.Total.TotalTokens += u.TotalTokens
+		res.Total.Cost += u.Cost
+	}
+	return core.Data(http.StatusOK, res)
+}
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"context"
+	"encoding/json/v2"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/zagvozdeen/malicious-learning/internal/api/core"
+	"github.com/zagvozdeen/malicious-learning/internal/db/null"
+	"github.com/zagvozdeen/malicious-learning/internal/store"
+	"github.com/zagvozdeen/malicious-learning/internal/store/enum"
+)
+
+type locale struct {
+	Slug  enum.Locale `json:"slug"`
+	Title string      `json:"title"`
+}
+
+type getLocalesResponse struct {
+	Locales []locale    `json:"locales"`
+	Current enum.Locale `json:"current"`
+	// Chosen is the locale set in the profile, null follows Telegram.
+	Chosen null.String `json:"chosen"`
+}
+
+func (s *Service) getLocales(_ *http.Request, user *store.User) core.Response {
+	res := getLocalesResponse{
+		Locales: make([]locale, 0, len(enum.Locales)),
+		Current: user.PreferredLocale(),
+		Chosen:  user.Locale,
+	}
+	for _, l := range enum.Locales {
+		res.Locales = append(res.Locales, locale{Slug: l, Title: l.Title()})
+	}
+	return core.Data(http.StatusOK, res)
+}
+
+type updateLocaleRequest struct {
+	Locale null.String `json:"locale"`
+}
+
+func (s *Service) updateLocale(r *http.Request, user *store.User) core.Response {
+	var payload updateLocaleRequest
+	if err := json.UnmarshalRead(r.Body, &payload); err != nil {
+		return core.Err(http.StatusBadRequest, fmt.Errorf("invalid json body: %w", err))
+	}
+	if payload.Locale.Valid {
+		if _, err := enum.NewLocale(payload.Locale.V); err != nil {
+			return core.Err(http.StatusBadRequest, err)
+		}
+	}
+	user.Locale = payload.Locale
+	user.UpdatedAt = time.Now()
+	err := s.store.UpdateUserLocale(r.Context(), user)
+	if err != nil {
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to update locale: %w", err))
+	}
+	return s.getLocales(r, user)
+}
+
+// syncLanguageCode stores the language of the user's Telegram client when it
+// changes.
+func (s *Service) syncLanguageCode(ctx context.Context, user *store.User, code string) error {
+	lc := null.WrapString(code)
+	if lc == user.LanguageCode {
+		return nil
+	}
+	user.LanguageCode = lc
+	user.UpdatedAt = time.Now()
+	return s.store.UpdateUserLanguageCode(ctx, user)
+}
+
+// localizeCards replaces card content with its translation to the locale.
+// Cards without one keep the default locale.
+func (s *Service) localizeCards(ctx context.Context, locale enum.Locale, cards []store.Card) error {
+	if locale.IsDefault() || len(cards) == 0 {
+		return nil
+	}
+	ids := make([]int, 0, len(cards))
+	for _, card := range cards {
+		ids = append(ids, card.ID)
+	}
+	translations, err := s.store.GetCardTranslations(ctx, ids, locale)
+	if err != nil {
+		return err
+	}
+	for i := range cards {
+		if t, ok := translations[cards[i].ID]; ok {
+			cards[i].Question, cards[i].QuestionBody, cards[i].Answer = t.Question, t.QuestionBody, t.Answer
+		}
+	}
+	return nil
+}
+
+func (s *Service) localizeUserAnswers(ctx context.Context, locale enum.Locale, answers []store.FullUserAnswer) error {
+	if locale.IsDefault() || len(answers) == 0 {
+		return nil
+	}
+	ids := make([]int, 0, len(answers))
+	for _, answer := range answers {
+		ids = append(ids, answer.CardID)
+	}
+	translations, err := s.store.GetCardTranslations(ctx, ids, locale)
+	if err != nil {
+		return err
+	}
+	for i := range answers {
+		if t, ok := translations[answers[i].CardID]; ok {
+			answers[i].Question, answers[i].QuestionBody, answers[i].Answer = t.Question, t.QuestionBody, t.Answer
+		}
+	}
+	return nil
+}
+
+func (s *Service) localizeCourses(ctx context.Context, locale enum.Locale, courses []store.Course) error {
+	if locale.IsDefault() || len(courses) == 0 {
+		return nil
+	}
+	names, err := s.store.GetCourseNames(ctx, locale)
+	if err != nil {
+		return err
+	}
+	for i := range courses {
+		if name, ok := names[courses[i].ID]; ok {
+			courses[i].Name = name
+		}
+	}
+	return nil
+}
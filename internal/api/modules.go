@@ -1,18 +1,27 @@
 package api
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/zagvozdeen/malicious-learning/internal/api/core"
 	"github.com/zagvozdeen/malicious-learning/internal/store"
 )
 
-func (s *Service) getModules(r *http.Request, _ *store.User) core.Response {
+func (s *Service) getModules(r *http.Request, user *store.User) core.Response {
 	slug := r.URL.Query().Get("course_slug")
 	if slug == "" {
 		return core.Err(http.StatusBadRequest, fmt.Errorf("missing course_slug"))
 	}
+	_, err := s.getCourseForUser(r.Context(), slug, user)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return core.Err(http.StatusNotFound, fmt.Errorf("course not found: %w", err))
+		}
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to get course: %w", err))
+	}
 
 	modules, err := s.store.GetModulesByCourseSlug(r.Context(), slug)
 	if err != nil {
@@ -1,33 +1,31 @@
 package api
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"log/slog"
+	"html"
 	"slices"
 	"strings"
 	"time"
 
-	"github.com/google/uuid"
-	"github.com/openai/openai-go/v3"
-	"github.com/openai/openai-go/v3/option"
+	"github.com/jackc/pgx/v5"
 	"github.com/zagvozdeen/malicious-learning/internal/db/null"
+	"github.com/zagvozdeen/malicious-learning/internal/llm"
+	"github.com/zagvozdeen/malicious-learning/internal/prompts"
 	"github.com/zagvozdeen/malicious-learning/internal/store"
 	"github.com/zagvozdeen/malicious-learning/internal/store/enum"
 )
 
 const channelSize = 100
 
-func (s *Service) getUserRecommendationsByTestSessionID(user *store.User, id int) error {
-	if _, ok := s.processingTS.Load(user.ID); ok {
-		return fmt.Errorf("processing ts %d already exists", id)
-	}
-	ch := make(chan []byte, channelSize)
-	defer close(ch)
-	s.processingTS.Store(user.ID, ch)
-	defer s.processingTS.Delete(user.ID)
+// errRecommendationsBusy is returned while recommendations of another test
+// session of the user are streamed, the user has one stream at a time.
+var errRecommendationsBusy = errors.New("recommendations of the user are already processing")
 
-	ch <- []byte("<start>")
+// generateRecommendations asks the model for recommendations on the finished
+// test session and streams them to the user while they are written.
+func (s *Service) generateRecommendations(id int) error {
 	ctx, err := s.store.Begin(s.ctx)
 	if err != nil {
 		return err
@@ -38,8 +36,17 @@ func (s *Service) getUserRecommendationsByTestSessionID(user *store.User, id int
 		return err
 	}
 	if ts.Recommendations.Valid {
-		return errors.New("recommendations exist")
+		return nil
+	}
+	if _, ok := s.processingTS.Load(ts.UserID); ok {
+		return fmt.Errorf("%w: user %d", errRecommendationsBusy, ts.UserID)
 	}
+	ch := make(chan []byte, channelSize)
+	defer close(ch)
+	s.processingTS.Store(ts.UserID, ch)
+	defer s.processingTS.Delete(ts.UserID)
+
+	ch <- []byte("<start>")
 	ua, err := s.store.GetUserAnswersByTestSessionID(ctx, ts.ID)
 	if err != nil {
 		return err
@@ -47,94 +54,54 @@ func (s *Service) getUserRecommendationsByTestSessionID(user *store.User, id int
 	if len(ua) == 0 {
 		return errors.New("empty user answers")
 	}
-	var msgs = []string{
-		strings.Join([]string{
-			"Пользователь готовится к экзамену по машинному обучению.",
-			"У него есть карточки, на которые он отвечает «Вспомнил» или «Забыл».",
-			"Ниже будет вопрос с карточки и ответ пользователя.",
-			"Твоя задача ― дать персонализированные рекомендации исходя из ответов: с чем сложности, что подучить, что повторить.",
-			"Пиши кратко и просто, ответ должен уместиться в 2-3 параграфа текста.",
-			"Итак, ниже будет номер вопроса, ответ пользователя и сам вопрос в формате HTML.",
-		}, ""),
-		"```",
+	course, err := s.store.GetCourseByID(ctx, ts.CourseID)
+	if err != nil {
+		return fmt.Errorf("failed to get course: %w", err)
+	}
+	tmpl, err := s.coursePrompt(ctx, course.ID, prompts.Recommendations)
+	if err != nil {
+		return err
 	}
+	data := prompts.RecommendationsData{Course: course.Name}
 	slices.SortFunc(ua, func(a, b store.FullUserAnswer) int {
 		return a.UID - b.UID
 	})
 	for _, answer := range ua {
 		if answer.Status == enum.UserAnswerStatusForgot || answer.Status == enum.UserAnswerStatusRemember {
-			msgs = append(msgs, fmt.Sprintf(
-				"%d. %s. %s.",
-				answer.UID, answer.Status.Condition(), strings.TrimSpace(answer.Question),
-			))
+			data.Answers = append(data.Answers, prompts.Answer{
+				UID:       answer.UID,
+				Condition: answer.Status.Condition(),
+				Question:  strings.TrimSpace(html.UnescapeString(answer.Question)),
+				Body:      strings.TrimSpace(answer.QuestionBody),
+			})
 		}
 	}
-	msgs = append(msgs, "```")
-	options := []option.RequestOption{
-		option.WithBaseURL(s.cfg.NeuroAPI),
-		option.WithAPIKey(s.cfg.NeuroToken),
-	}
-	if s.cfg.NeuroDebug {
-		options = append(options, option.WithDebugLog(slog.NewLogLogger(s.log.Handler(), slog.LevelDebug)))
+	prompt, err := tmpl.Execute(data)
+	if err != nil {
+		return err
 	}
-	client := openai.NewClient(options...)
-	stream := client.Chat.Completions.NewStreaming(ctx, openai.ChatCompletionNewParams{
-		Messages: []openai.ChatCompletionMessageParamUnion{
-			openai.UserMessage(strings.Join(msgs, "\n")),
-		},
-		Model: openai.ChatModelGPT5Mini,
-		StreamOptions: openai.ChatCompletionStreamOptionsParam{
-			IncludeUsage: openai.Bool(true),
-		},
-	})
-
-	acc := openai.ChatCompletionAccumulator{}
 	var content strings.Builder
 	var counter int
-	for stream.Next() {
-		chunk := stream.Current()
-		if !acc.AddChunk(chunk) {
-			return errors.New("failed to accumulate chat model stream")
-		}
-		if len(chunk.Choices) == 0 {
-			continue
-		}
-		delta := chunk.Choices[0].Delta.Content
-		if delta == "" {
-			continue
-		}
+	res, err := s.llm.Chat(ctx, &store.ChatCompletions{
+		UserID:        null.WrapInt(ts.UserID),
+		OwnerType:     enum.ChatOwnerTypeTestSession,
+		OwnerID:       null.WrapInt(ts.ID),
+		Feature:       tmpl.Name,
+		PromptVersion: null.WrapString(tmpl.Version),
+	}, llm.Request{
+		Model:    s.cfg.LLMRecommendationsModel,
+		Messages: []llm.Message{{Role: llm.RoleUser, Content: prompt}},
+	}, func(delta string) {
 		content.WriteString(delta)
 		if counter < channelSize-3 {
 			ch <- []byte(strings.ReplaceAll(content.String(), "\n", "<br>"))
 			counter++
 		}
-	}
-	err = stream.Err()
-	if err != nil {
-		return fmt.Errorf("failed to stream chat model: %w", err)
-	}
-	uid, err := uuid.NewV7()
-	if err != nil {
-		return err
-	}
-	err = s.store.CreateChatCompletions(ctx, &store.ChatCompletions{
-		UUID:             uid.String(),
-		TestSessionID:    id,
-		Model:            acc.Model,
-		CompletionTokens: acc.Usage.CompletionTokens,
-		PromptTokens:     acc.Usage.PromptTokens,
-		TotalTokens:      acc.Usage.TotalTokens,
-		Date:             acc.Created,
-		CreatedAt:        time.Now(),
-		UpdatedAt:        time.Now(),
 	})
 	if err != nil {
-		return fmt.Errorf("failed to create chat model: %w", err)
-	}
-	if len(acc.Choices) == 0 {
-		return errors.New("empty chat model choices")
+		return err
 	}
-	finalRecommendations := strings.ReplaceAll(content.String(), "\n", "<br>")
+	finalRecommendations := strings.ReplaceAll(res.Content, "\n", "<br>")
 	ch <- []byte(finalRecommendations)
 	ts.Recommendations = null.WrapString(finalRecommendations)
 	ts.UpdatedAt = time.Now()
@@ -147,3 +114,16 @@ func (s *Service) getUserRecommendationsByTestSessionID(user *store.User, id int
 	s.metrics.AppGeneratedRecommendationsCountInc()
 	return nil
 }
+
+// coursePrompt returns the template of the prompt overridden by the course or
+// the latest embedded one.
+func (s *Service) coursePrompt(ctx context.Context, courseID int, name string) (*prompts.Template, error) {
+	p, err := s.store.GetCoursePrompt(ctx, courseID, name)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return prompts.Latest(name)
+		}
+		return nil, fmt.Errorf("failed to get course prompt: %w", err)
+	}
+	return prompts.Parse(p.Name, p.Version, p.Template)
+}
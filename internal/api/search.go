@@ -0,0 +1,25 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/zagvozdeen/malicious-learning/internal/api/core"
+	"github.com/zagvozdeen/malicious-learning/internal/store"
+)
+
+const searchLimit = 20
+
+func (s *Service) searchCards(r *http.Request, user *store.User) core.Response {
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	if utf8.RuneCountInString(q) < 2 {
+		return core.Err(http.StatusBadRequest, fmt.Errorf("query must be at least 2 characters"))
+	}
+	hits, err := s.store.SearchCards(r.Context(), q, strings.TrimSpace(r.URL.Query().Get("course_slug")), user.ID, searchLimit)
+	if err != nil {
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to search cards: %w", err))
+	}
+	return core.Data(http.StatusOK, hits)
+}
@@ -11,6 +11,8 @@ import (
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
 	"github.com/zagvozdeen/malicious-learning/internal/analytics"
+	"github.com/zagvozdeen/malicious-learning/internal/db/null"
+	"github.com/zagvozdeen/malicious-learning/internal/llm"
 )
 
 func (s *Service) startSendingMetrics() error {
@@ -54,6 +56,7 @@ func (s *Service) sendMetrics(new analytics.Snapshot, old analytics.Snapshot) {
 		"", "*Статистика по страницам\\:*\n",
 	}
 	lines = append(lines, getAppResponsesTotalDiff(new, old)...)
+	lines = append(lines, s.getLLMSpendLines()...)
 	_, err := s.bot.SendMessage(s.ctx, &bot.SendMessageParams{
 		ChatID:    s.cfg.TelegramBotGroup,
 		Text:      strings.Join(lines, "\n"),
@@ -64,6 +67,27 @@ func (s *Service) sendMetrics(new analytics.Snapshot, old analytics.Snapshot) {
 	}
 }
 
+// getLLMSpendLines summarizes tokens and cost of the language model for the
+// last hour and today, the account runs on prepaid credit.
+func (s *Service) getLLMSpendLines() []string {
+	now := time.Now()
+	hour, err := s.store.GetLLMTotals(s.ctx, now.Add(-time.Hour), null.Int{})
+	if err != nil {
+		s.log.Error("Failed to get llm spend", slog.Any("err", err))
+		return nil
+	}
+	today, err := s.store.GetLLMTotals(s.ctx, llm.StartOfDay(now), null.Int{})
+	if err != nil {
+		s.log.Error("Failed to get llm spend", slog.Any("err", err))
+		return nil
+	}
+	return []string{
+		"", "*Расходы на нейросеть\\:*\n",
+		fmt.Sprintf("– *За час\\:* %s \\(%d токенов\\)", bot.EscapeMarkdown(fmt.Sprintf("%.2f", hour.Cost)), hour.TotalTokens),
+		fmt.Sprintf("– *За сегодня\\:* %s \\(%d токенов\\)", bot.EscapeMarkdown(fmt.Sprintf("%.2f", today.Cost)), today.TotalTokens),
+	}
+}
+
 func compare(n int64, o int64) string {
 	if diff := n - o; diff != 0 {
 		return fmt.Sprintf("%d \\(\\+%d\\)", n, diff)
@@ -0,0 +1,408 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json/v2"
+	"errors"
+	"fmt"
+	"html"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/zagvozdeen/malicious-learning/internal/api/core"
+	"github.com/zagvozdeen/malicious-learning/internal/converter"
+	"github.com/zagvozdeen/malicious-learning/internal/db/null"
+	"github.com/zagvozdeen/malicious-learning/internal/patch"
+	"github.com/zagvozdeen/malicious-learning/internal/store"
+	"github.com/zagvozdeen/malicious-learning/internal/store/enum"
+)
+
+const (
+	// suggestionCallbackPrefix starts callback data of the review buttons
+	// posted to the bot group, the action and the uuid follow it.
+	suggestionCallbackPrefix = "suggestion:"
+	// suggestionDiffLimit is the number of diff characters in a notification,
+	// a message holds 4096 characters at most.
+	suggestionDiffLimit = 3000
+	// maxSuggestionComment is the number of characters in a comment, it is
+	// posted to the bot group with the diff.
+	maxSuggestionComment = 500
+)
+
+var errSuggestionReviewed = errors.New("suggestion is already reviewed")
+
+type cardSourceResponse struct {
+	FileName string `json:"file_name"`
+	Content  string `json:"content"`
+}
+
+// getCardSource returns the markdown file of the card in data/courses, the
+// file suggestions are made on.
+func (s *Service) getCardSource(r *http.Request, user *store.User) core.Response {
+	card, course, res := s.getSuggestedCard(r, user)
+	if res != nil {
+		return res
+	}
+	name, b, err := converter.RepoFile(course.Slug, card.UID)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return core.Err(http.StatusConflict, fmt.Errorf("card is not in the repository: %w", err))
+		}
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to read card file: %w", err))
+	}
+	return core.Data(http.StatusOK, cardSourceResponse{FileName: name, Content: string(b)})
+}
+
+// getSuggestedCard loads the card of the request, suggestions are made on the
+// active cards of courses in data/courses only.
+func (s *Service) getSuggestedCard(r *http.Request, user *store.User) (*store.Card, *store.Course, core.Response) {
+	card, err := s.store.GetCardByUUID(r.Context(), r.PathValue("uuid"))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil, core.Err(http.StatusNotFound, fmt.Errorf("card not found: %w", err))
+		}
+		return nil, nil, core.Err(http.StatusInternalServerError, fmt.Errorf("failed to get card: %w", err))
+	}
+	course, err := s.store.GetCourseByID(r.Context(), card.CourseID)
+	if err != nil {
+		return nil, nil, core.Err(http.StatusInternalServerError, fmt.Errorf("failed to get course: %w", err))
+	}
+	ok, err := s.store.HasCourseAccess(r.Context(), course.ID, user.ID)
+	if err != nil {
+		return nil, nil, core.Err(http.StatusInternalServerError, fmt.Errorf("failed to check course access: %w", err))
+	}
+	if !ok {
+		return nil, nil, core.Err(http.StatusNotFound, fmt.Errorf("card %s is in a private deck", card.UUID))
+	}
+	if course.OwnerID.Valid {
+		return nil, nil, core.Err(http.StatusConflict, fmt.Errorf("course %s is a private deck, upload it instead", course.Slug))
+	}
+	if !card.IsActive {
+		return nil, nil, core.Err(http.StatusConflict, fmt.Errorf("card %s has a newer version", card.UUID))
+	}
+	return card, course, nil
+}
+
+type createSuggestionRequest struct {
+	// Content is the whole edited markdown file.
+	Content string      `json:"content"`
+	Comment null.String `json:"comment"`
+}
+
+// createSuggestion proposes an edit of the card file. It is checked the same
+// way as a draft and posted to the bot group for review.
+func (s *Service) createSuggestion(r *http.Request, user *store.User) core.Response {
+	var payload createSuggestionRequest
+	if err := json.UnmarshalRead(r.Body, &payload); err != nil {
+		return core.Err(http.StatusBadRequest, fmt.Errorf("invalid json body: %w", err))
+	}
+	if payload.Comment.Valid {
+		payload.Comment = null.WrapString(strings.TrimSpace(payload.Comment.V))
+		if utf8.RuneCountInString(payload.Comment.V) > maxSuggestionComment {
+			return core.Err(http.StatusBadRequest, fmt.Errorf("comment must have at most %d characters", maxSuggestionComment))
+		}
+	}
+	card, course, res := s.getSuggestedCard(r, user)
+	if res != nil {
+		return res
+	}
+	name, base, err := converter.RepoFile(course.Slug, card.UID)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return core.Err(http.StatusConflict, fmt.Errorf("card is not in the repository: %w", err))
+		}
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to read card file: %w", err))
+	}
+	content := strings.ReplaceAll(payload.Content, "\r\n", "\n")
+	if bytes.HasSuffix(base, []byte("\n")) && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	if content == string(base) {
+		return core.Err(http.StatusBadRequest, fmt.Errorf("suggestion changes nothing"))
+	}
+	_, err = converter.Preview(r.Context(), s.store, &store.CardDraft{
+		CourseSlug: course.Slug,
+		CourseName: course.Name,
+		FileName:   name,
+		Content:    null.WrapString(content),
+	})
+	if err != nil {
+		return core.Err(http.StatusUnprocessableEntity, fmt.Errorf("failed to render suggestion: %w", err))
+	}
+	uid, err := uuid.NewV7()
+	if err != nil {
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to create uuid v7: %w", err))
+	}
+	sg := &store.CardSuggestion{
+		UUID:       uid.String(),
+		CardID:     card.ID,
+		CourseID:   course.ID,
+		CourseSlug: course.Slug,
+		Question:   card.Question,
+		FileName:   name,
+		Base:       string(base),
+		Content:    content,
+		Comment:    payload.Comment,
+		Status:     enum.SuggestionStatusPending,
+		AuthorID:   user.ID,
+		AuthorName: strings.TrimSpace(user.FirstName + " " + user.LastName.V),
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+	err = s.store.CreateCardSuggestion(r.Context(), sg)
+	if err != nil {
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to create suggestion: %w", err))
+	}
+	s.wg.Go(func() { s.notifySuggestion(sg) })
+	return core.Data(http.StatusCreated, sg)
+}
+
+func (s *Service) getSuggestions(r *http.Request, _ *store.User) core.Response {
+	status := enum.SuggestionStatusPending
+	if q := r.URL.Query().Get("status"); q != "" {
+		var err error
+		status, err = enum.NewSuggestionStatus(q)
+		if err != nil {
+			return core.Err(http.StatusBadRequest, err)
+		}
+	}
+	suggestions, err := s.store.GetCardSuggestions(r.Context(), status)
+	if err != nil {
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to get suggestions: %w", err))
+	}
+	return core.Data(http.StatusOK, suggestions)
+}
+
+type suggestionResponse struct {
+	Suggestion *store.CardSuggestion `json:"suggestion"`
+	Diff       string                `json:"diff"`
+}
+
+func (s *Service) getSuggestion(r *http.Request, _ *store.User) core.Response {
+	sg, res := s.getSuggestionByRequest(r)
+	if res != nil {
+		return res
+	}
+	return core.Data(http.StatusOK, suggestionResponse{Suggestion: sg, Diff: patch.Diff(suggestionPath(sg), sg.Base, sg.Content)})
+}
+
+func (s *Service) getSuggestionByRequest(r *http.Request) (*store.CardSuggestion, core.Response) {
+	sg, err := s.store.GetCardSuggestionByUUID(r.Context(), r.PathValue("uuid"))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, core.Err(http.StatusNotFound, fmt.Errorf("suggestion not found: %w", err))
+		}
+		return nil, core.Err(http.StatusInternalServerError, fmt.Errorf("failed to get suggestion: %w", err))
+	}
+	return sg, nil
+}
+
+func (s *Service) acceptSuggestion(r *http.Request, user *store.User) core.Response {
+	return s.reviewSuggestionByRequest(r, user, enum.SuggestionStatusAccepted)
+}
+
+func (s *Service) rejectSuggestion(r *http.Request, user *store.User) core.Response {
+	return s.reviewSuggestionByRequest(r, user, enum.SuggestionStatusRejected)
+}
+
+func (s *Service) reviewSuggestionByRequest(r *http.Request, user *store.User, status enum.SuggestionStatus) core.Response {
+	sg, res := s.getSuggestionByRequest(r)
+	if res != nil {
+		return res
+	}
+	err := s.reviewSuggestion(r.Context(), sg, user, status)
+	if err != nil {
+		if errors.Is(err, errSuggestionReviewed) {
+			return core.Err(http.StatusConflict, err)
+		}
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to review suggestion: %w", err))
+	}
+	return core.Data(http.StatusOK, sg)
+}
+
+func (s *Service) reviewSuggestion(ctx context.Context, sg *store.CardSuggestion, reviewer *store.User, status enum.SuggestionStatus) error {
+	now := time.Now()
+	sg.Status = status
+	sg.ReviewerID = null.WrapInt(reviewer.ID)
+	sg.ReviewedAt = &now
+	sg.UpdatedAt = now
+	ok, err := s.store.ReviewCardSuggestion(ctx, sg)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errSuggestionReviewed
+	}
+	return nil
+}
+
+// getSuggestionPatch downloads the suggestion as a patch for git am.
+func (s *Service) getSuggestionPatch(r *http.Request, _ *store.User) core.Response {
+	sg, res := s.getSuggestionByRequest(r)
+	if res != nil {
+		return res
+	}
+	return s.suggestionsMbox(fmt.Sprintf("%s.patch", sg.UUID), []store.CardSuggestion{*sg})
+}
+
+// getAcceptedPatches downloads accepted suggestions that are not in
+// data/courses yet as one mbox for git am. Suggestions of the same file may
+// conflict, git am stops on them.
+func (s *Service) getAcceptedPatches(r *http.Request, _ *store.User) core.Response {
+	suggestions, err := s.store.GetCardSuggestions(r.Context(), enum.SuggestionStatusAccepted)
+	if err != nil {
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to get suggestions: %w", err))
+	}
+	pending := suggestions[:0]
+	for _, sg := range suggestions {
+		if !converter.InRepo(sg.CourseSlug, sg.FileName, sg.Content) {
+			pending = append(pending, sg)
+		}
+	}
+	return s.suggestionsMbox("suggestions.mbox", pending)
+}
+
+func (s *Service) suggestionsMbox(name string, suggestions []store.CardSuggestion) core.Response {
+	patches := make([]patch.Patch, 0, len(suggestions))
+	for _, sg := range suggestions {
+		body := fmt.Sprintf("Suggested in the app as %s.", sg.UUID)
+		if sg.Comment.Valid && sg.Comment.V != "" {
+			body = patch.Quote(sg.Comment.V) + "\n\n" + body
+		}
+		patches = append(patches, patch.Patch{
+			AuthorName:  sg.AuthorName,
+			AuthorEmail: suggestionAuthorEmail(&sg),
+			Date:        sg.CreatedAt,
+			Subject:     fmt.Sprintf("Edit %s/%s: %s", sg.CourseSlug, sg.FileName, html.UnescapeString(sg.Question)),
+			Body:        body,
+			Path:        suggestionPath(&sg),
+			Old:         sg.Base,
+			New:         sg.Content,
+		})
+	}
+	buf := &bytes.Buffer{}
+	err := patch.WriteMbox(buf, patches)
+	if err != nil {
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to write patches: %w", err))
+	}
+	return core.File(name, "application/mbox", buf.Bytes())
+}
+
+// suggestionPath is the path of the suggested file from the repository root.
+func suggestionPath(sg *store.CardSuggestion) string {
+	return path.Join("data/courses", sg.CourseSlug, sg.FileName)
+}
+
+// suggestionAuthorEmail is the email of the commit author, Telegram users
+// have no email and get a placeholder.
+func suggestionAuthorEmail(sg *store.CardSuggestion) string {
+	switch {
+	case sg.AuthorEmail.Valid && sg.AuthorEmail.V != "":
+		return sg.AuthorEmail.V
+	case sg.AuthorUsername.Valid && sg.AuthorUsername.V != "":
+		return sg.AuthorUsername.V + "@telegram.invalid"
+	default:
+		return fmt.Sprintf("user-%d@telegram.invalid", sg.AuthorID)
+	}
+}
+
+// notifySuggestion posts the suggestion with review buttons to the bot group.
+func (s *Service) notifySuggestion(sg *store.CardSuggestion) {
+	if !s.cfg.TelegramBotEnabled || s.cfg.TelegramBotGroup == 0 {
+		return
+	}
+	select {
+	case <-s.ctx.Done():
+		return
+	case <-s.botReady:
+	}
+	diff := patch.Diff(suggestionPath(sg), sg.Base, sg.Content)
+	if utf8.RuneCountInString(diff) > suggestionDiffLimit {
+		diff = string([]rune(diff)[:suggestionDiffLimit]) + "\n…\n"
+	}
+	lines := []string{fmt.Sprintf(
+		"*Предложена правка карточки «%s»* от %s",
+		bot.EscapeMarkdown(html.UnescapeString(sg.Question)),
+		bot.EscapeMarkdown(sg.AuthorName),
+	)}
+	if sg.Comment.Valid && sg.Comment.V != "" {
+		lines = append(lines, "", bot.EscapeMarkdown(sg.Comment.V))
+	}
+	lines = append(lines, "", "```diff\n"+strings.NewReplacer("\\", "\\\\", "`", "\\`").Replace(diff)+"```")
+	_, err := s.bot.SendMessage(s.ctx, &bot.SendMessageParams{
+		ChatID:    s.cfg.TelegramBotGroup,
+		Text:      strings.Join(lines, "\n"),
+		ParseMode: models.ParseModeMarkdown,
+		ReplyMarkup: &models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{{
+			{Text: "Принять", CallbackData: suggestionCallbackPrefix + "accept:" + sg.UUID},
+			{Text: "Отклонить", CallbackData: suggestionCallbackPrefix + "reject:" + sg.UUID},
+		}}},
+	})
+	if err != nil {
+		s.log.Warn("Failed to send suggestion", slog.Any("err", err), slog.String("uuid", sg.UUID))
+	}
+}
+
+// suggestionHandler reviews a suggestion by a button of the bot group, only
+// editors may press them.
+func (s *Service) suggestionHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if err := s.storeTelegramUpdate(ctx, update); err != nil {
+		s.log.Warn("Failed to store telegram update", slog.Any("err", err))
+	}
+	query := update.CallbackQuery
+	reply := func(text string) {
+		_, err := b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{CallbackQueryID: query.ID, Text: text})
+		if err != nil {
+			s.log.Warn("Failed to answer callback query", slog.Any("err", err))
+		}
+	}
+	user, err := s.ensureTelegramUser(ctx, &query.From)
+	if err != nil {
+		s.log.Warn("Failed to ensure telegram user", slog.Any("err", err))
+		reply("Что-то пошло не так")
+		return
+	}
+	if !user.Role.CanEdit() {
+		reply("Проверять правки могут только редакторы")
+		return
+	}
+	action, id, _ := strings.Cut(strings.TrimPrefix(query.Data, suggestionCallbackPrefix), ":")
+	status := enum.SuggestionStatusRejected
+	if action == "accept" {
+		status = enum.SuggestionStatusAccepted
+	}
+	sg, err := s.store.GetCardSuggestionByUUID(ctx, id)
+	if err == nil {
+		err = s.reviewSuggestion(ctx, sg, user, status)
+	}
+	switch {
+	case errors.Is(err, errSuggestionReviewed):
+		reply("Правка уже проверена")
+	case err != nil:
+		s.log.Warn("Failed to review suggestion", slog.Any("err", err), slog.String("uuid", id))
+		reply("Что-то пошло не так")
+		return
+	default:
+		reply(status.Title())
+	}
+	if msg := query.Message.Message; msg != nil {
+		_, err = b.EditMessageReplyMarkup(ctx, &bot.EditMessageReplyMarkupParams{
+			ChatID:      msg.Chat.ID,
+			MessageID:   msg.ID,
+			ReplyMarkup: &models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{}},
+		})
+		if err != nil {
+			s.log.Warn("Failed to remove review buttons", slog.Any("err", err))
+		}
+	}
+}
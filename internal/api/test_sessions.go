@@ -13,6 +13,8 @@ import (
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/zagvozdeen/malicious-learning/internal/api/core"
+	"github.com/zagvozdeen/malicious-learning/internal/db/null"
+	"github.com/zagvozdeen/malicious-learning/internal/llm"
 	"github.com/zagvozdeen/malicious-learning/internal/store"
 	"github.com/zagvozdeen/malicious-learning/internal/store/enum"
 )
@@ -34,7 +36,7 @@ func (s *Service) createTestSession(r *http.Request, user *store.User) core.Resp
 		return core.Err(http.StatusBadRequest, fmt.Errorf("missing course_slug"))
 	}
 
-	course, err := s.store.GetCourseBySlug(r.Context(), payload.CourseSlug)
+	course, err := s.getCourseForUser(r.Context(), payload.CourseSlug, user)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return core.Err(http.StatusNotFound, fmt.Errorf("course not found: %w", err))
@@ -124,6 +126,10 @@ func (s *Service) getTestSession(r *http.Request, user *store.User) core.Respons
 	if err != nil {
 		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to load user answers: %w", err))
 	}
+	err = s.localizeUserAnswers(r.Context(), user.PreferredLocale(), answers)
+	if err != nil {
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to localize user answers: %w", err))
+	}
 
 	return core.Data(http.StatusOK, getTestSessionResponse{
 		TestSession: ts,
@@ -143,3 +149,51 @@ func (s *Service) getTestSessions(r *http.Request, user *store.User) core.Respon
 
 	return core.Data(http.StatusOK, getTestSessionsResponse{Data: sessions})
 }
+
+// regenerateRecommendations queues recommendations again for a finished test
+// session that has none, e.g. after all attempts of the job failed.
+func (s *Service) regenerateRecommendations(r *http.Request, user *store.User) core.Response {
+	groupUUID := r.PathValue("uuid")
+	if groupUUID == "" {
+		return core.Err(http.StatusBadRequest, fmt.Errorf("missing uuid"))
+	}
+	if err := uuid.Validate(groupUUID); err != nil {
+		return core.Err(http.StatusBadRequest, fmt.Errorf("invalid uuid: %w", err))
+	}
+
+	ts, err := s.store.GetTestSessionByUUID(r.Context(), groupUUID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return core.Err(http.StatusNotFound, fmt.Errorf("test session not found: %w", err))
+		}
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to get test session: %w", err))
+	}
+	if ts.UserID != user.ID {
+		return core.Err(http.StatusForbidden, errors.New("you can not get test session"))
+	}
+	if ts.IsActive {
+		return core.Err(http.StatusConflict, errors.New("test session is still active"))
+	}
+	if ts.Recommendations.Valid {
+		return core.Err(http.StatusConflict, errors.New("recommendations exist"))
+	}
+
+	err = s.llm.CheckQuota(r.Context(), null.WrapInt(user.ID))
+	if err != nil {
+		if errors.Is(err, llm.ErrQuotaExceeded) {
+			return core.Err(http.StatusTooManyRequests, llm.ErrQuotaExceeded)
+		}
+		return core.Err(http.StatusInternalServerError, err)
+	}
+
+	job, created, err := s.enqueueRecommendations(r.Context(), ts.ID)
+	if err != nil {
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to enqueue recommendations: %w", err))
+	}
+	if !created {
+		return core.Err(http.StatusConflict, errors.New("recommendations are already queued"))
+	}
+	s.wakeJobs()
+
+	return core.Data(http.StatusAccepted, job)
+}
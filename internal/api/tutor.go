@@ -0,0 +1,279 @@
+package api
+
+import (
+	"context"
+	"encoding/json/v2"
+	"errors"
+	"fmt"
+	"html"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/zagvozdeen/malicious-learning/internal/api/core"
+	"github.com/zagvozdeen/malicious-learning/internal/db/null"
+	"github.com/zagvozdeen/malicious-learning/internal/llm"
+	"github.com/zagvozdeen/malicious-learning/internal/prompts"
+	"github.com/zagvozdeen/malicious-learning/internal/store"
+	"github.com/zagvozdeen/malicious-learning/internal/store/enum"
+)
+
+const (
+	maxTutorMessage = 2000
+	// tutorHistory is the number of latest messages sent to the model.
+	tutorHistory = 20
+)
+
+type tutorChatResponse struct {
+	Chat     *store.TutorChat     `json:"chat"`
+	Messages []store.TutorMessage `json:"messages"`
+}
+
+// createTutorChat returns the chat of the user about the card, within the
+// test session of the test_session query parameter when it is set.
+func (s *Service) createTutorChat(r *http.Request, user *store.User) core.Response {
+	card, err := s.store.GetCardByUUID(r.Context(), r.PathValue("uuid"))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return core.Err(http.StatusNotFound, fmt.Errorf("card not found: %w", err))
+		}
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to get card: %w", err))
+	}
+	ok, err := s.store.HasCourseAccess(r.Context(), card.CourseID, user.ID)
+	if err != nil {
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to check course access: %w", err))
+	}
+	if !ok {
+		return core.Err(http.StatusNotFound, fmt.Errorf("card %s is in a private deck", card.UUID))
+	}
+	uid, err := uuid.NewV7()
+	if err != nil {
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to generate uuid: %w", err))
+	}
+	chat := &store.TutorChat{
+		UUID:      uid.String(),
+		UserID:    user.ID,
+		CardID:    card.ID,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if v := r.URL.Query().Get("test_session"); v != "" {
+		ts, err := s.store.GetTestSessionByUUID(r.Context(), v)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return core.Err(http.StatusNotFound, fmt.Errorf("test session not found: %w", err))
+			}
+			return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to get test session: %w", err))
+		}
+		if ts.UserID != user.ID {
+			return core.Err(http.StatusForbidden, errors.New("you can not get test session"))
+		}
+		if ts.CourseID != card.CourseID {
+			return core.Err(http.StatusBadRequest, errors.New("card is not in the test session course"))
+		}
+		chat.TestSessionID = null.WrapInt(ts.ID)
+	}
+	err = s.store.GetOrCreateTutorChat(r.Context(), chat)
+	if err != nil {
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to create tutor chat: %w", err))
+	}
+	messages, err := s.store.GetTutorMessages(r.Context(), chat.ID)
+	if err != nil {
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to get tutor messages: %w", err))
+	}
+	return core.Data(http.StatusOK, tutorChatResponse{Chat: chat, Messages: messages})
+}
+
+func (s *Service) getTutorChat(r *http.Request, user *store.User) core.Response {
+	chat, res := s.getOwnTutorChat(r, user)
+	if res != nil {
+		return res
+	}
+	messages, err := s.store.GetTutorMessages(r.Context(), chat.ID)
+	if err != nil {
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to get tutor messages: %w", err))
+	}
+	return core.Data(http.StatusOK, tutorChatResponse{Chat: chat, Messages: messages})
+}
+
+func (s *Service) getOwnTutorChat(r *http.Request, user *store.User) (*store.TutorChat, core.Response) {
+	chat, err := s.store.GetTutorChatByUUID(r.Context(), r.PathValue("uuid"))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, core.Err(http.StatusNotFound, fmt.Errorf("tutor chat not found: %w", err))
+		}
+		return nil, core.Err(http.StatusInternalServerError, fmt.Errorf("failed to get tutor chat: %w", err))
+	}
+	if chat.UserID != user.ID {
+		return nil, core.Err(http.StatusNotFound, fmt.Errorf("tutor chat %s belongs to another user", chat.UUID))
+	}
+	// The deck of the card may have been made private or the user removed
+	// from its members since the chat was created.
+	card, err := s.store.GetCardByID(r.Context(), chat.CardID)
+	if err != nil {
+		return nil, core.Err(http.StatusInternalServerError, fmt.Errorf("failed to get card: %w", err))
+	}
+	ok, err := s.store.HasCourseAccess(r.Context(), card.CourseID, user.ID)
+	if err != nil {
+		return nil, core.Err(http.StatusInternalServerError, fmt.Errorf("failed to check course access: %w", err))
+	}
+	if !ok {
+		return nil, core.Err(http.StatusNotFound, fmt.Errorf("card %s is in a private deck", card.UUID))
+	}
+	return chat, nil
+}
+
+type sendTutorMessageRequest struct {
+	Content string `json:"content"`
+}
+
+// sendTutorMessage stores the question of the user and streams the reply of
+// the model piece by piece. The reply is stored when it is complete, even if
+// the user has gone, so the chat may be refetched after an interrupted stream.
+// When the model fails the question is deleted, so the refetched chat ends
+// with a reply of the model or the previous message.
+func (s *Service) sendTutorMessage(r *http.Request, user *store.User) core.Response {
+	var payload sendTutorMessageRequest
+	if err := json.UnmarshalRead(r.Body, &payload); err != nil {
+		return core.Err(http.StatusBadRequest, fmt.Errorf("invalid json body: %w", err))
+	}
+	content := strings.TrimSpace(payload.Content)
+	if content == "" || utf8.RuneCountInString(content) > maxTutorMessage {
+		return core.Err(http.StatusBadRequest, fmt.Errorf("message must have from 1 to %d characters", maxTutorMessage))
+	}
+	chat, res := s.getOwnTutorChat(r, user)
+	if res != nil {
+		return res
+	}
+	if _, busy := s.tutoring.LoadOrStore(chat.ID, struct{}{}); busy {
+		return core.Err(http.StatusConflict, errors.New("tutor is still replying"))
+	}
+	req, tmpl, err := s.tutorRequest(r.Context(), user, chat, content)
+	if err != nil {
+		s.tutoring.Delete(chat.ID)
+		if errors.Is(err, llm.ErrQuotaExceeded) {
+			return core.Err(http.StatusTooManyRequests, llm.ErrQuotaExceeded)
+		}
+		return core.Err(http.StatusInternalServerError, err)
+	}
+	question := &store.TutorMessage{
+		ChatID:    chat.ID,
+		Role:      enum.MessageRoleUser,
+		Content:   content,
+		CreatedAt: time.Now(),
+	}
+	err = s.store.CreateTutorMessage(r.Context(), question)
+	if err != nil {
+		s.tutoring.Delete(chat.ID)
+		return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to create tutor message: %w", err))
+	}
+
+	ch := make(chan []byte, channelSize)
+	go func() {
+		defer s.tutoring.Delete(chat.ID)
+		defer close(ch)
+		res, err := s.llm.Chat(s.ctx, &store.ChatCompletions{
+			UserID:        null.WrapInt(user.ID),
+			OwnerType:     enum.ChatOwnerTypeTutorChat,
+			OwnerID:       null.WrapInt(chat.ID),
+			Feature:       tmpl.Name,
+			PromptVersion: null.WrapString(tmpl.Version),
+		}, req, func(delta string) {
+			select {
+			case ch <- []byte(delta):
+			case <-r.Context().Done():
+			}
+		})
+		if err != nil {
+			s.log.Error("Failed to reply in tutor chat", slog.Int("chat_id", chat.ID), slog.Any("err", err))
+			// A question without a reply would be sent to the model again as
+			// history, the client sees it is gone and restores the input.
+			err = s.store.DeleteTutorMessage(context.WithoutCancel(s.ctx), question.ID)
+			if err != nil {
+				s.log.Error("Failed to delete tutor message", slog.Int("chat_id", chat.ID), slog.Any("err", err))
+			}
+			return
+		}
+		err = s.store.CreateTutorMessage(s.ctx, &store.TutorMessage{
+			ChatID:    chat.ID,
+			Role:      enum.MessageRoleAssistant,
+			Content:   res.Content,
+			CreatedAt: time.Now(),
+		})
+		if err != nil {
+			s.log.Error("Failed to create tutor message", slog.Int("chat_id", chat.ID), slog.Any("err", err))
+		}
+	}()
+
+	return core.Flush(r.Context(), ch)
+}
+
+// tutorRequest builds the request of the reply to the content: the system
+// prompt about the card, the latest messages of the chat and the content.
+func (s *Service) tutorRequest(ctx context.Context, user *store.User, chat *store.TutorChat, content string) (llm.Request, *prompts.Template, error) {
+	err := s.llm.CheckQuota(ctx, null.WrapInt(user.ID))
+	if err != nil {
+		return llm.Request{}, nil, err
+	}
+	card, err := s.store.GetCardByID(ctx, chat.CardID)
+	if err != nil {
+		return llm.Request{}, nil, fmt.Errorf("failed to get card: %w", err)
+	}
+	cards := []store.Card{*card}
+	err = s.localizeCards(ctx, user.PreferredLocale(), cards)
+	if err != nil {
+		return llm.Request{}, nil, fmt.Errorf("failed to localize card: %w", err)
+	}
+	course, err := s.store.GetCourseByID(ctx, card.CourseID)
+	if err != nil {
+		return llm.Request{}, nil, fmt.Errorf("failed to get course: %w", err)
+	}
+	data := prompts.TutorData{
+		Course:       course.Name,
+		Question:     strings.TrimSpace(html.UnescapeString(cards[0].Question)),
+		QuestionBody: strings.TrimSpace(cards[0].QuestionBody),
+		Answer:       strings.TrimSpace(cards[0].Answer),
+	}
+	if chat.TestSessionID.Valid {
+		answers, err := s.store.GetUserAnswersByTestSessionID(ctx, chat.TestSessionID.V)
+		if err != nil {
+			return llm.Request{}, nil, fmt.Errorf("failed to get user answers: %w", err)
+		}
+		for _, answer := range answers {
+			if answer.CardID == card.ID && answer.Status != enum.UserAnswerStatusNull {
+				data.Condition = answer.Status.Condition()
+			}
+		}
+	}
+	tmpl, err := s.coursePrompt(ctx, course.ID, prompts.Tutor)
+	if err != nil {
+		return llm.Request{}, nil, err
+	}
+	system, err := tmpl.Execute(data)
+	if err != nil {
+		return llm.Request{}, nil, err
+	}
+	history, err := s.store.GetTutorMessages(ctx, chat.ID)
+	if err != nil {
+		return llm.Request{}, nil, fmt.Errorf("failed to get tutor messages: %w", err)
+	}
+	history = history[max(0, len(history)-tutorHistory):]
+	req := llm.Request{
+		Model:    s.cfg.LLMTutorModel,
+		Messages: make([]llm.Message, 0, len(history)+2),
+	}
+	req.Messages = append(req.Messages, llm.Message{Role: llm.RoleSystem, Content: system})
+	for _, m := range history {
+		role := llm.RoleUser
+		if m.Role == enum.MessageRoleAssistant {
+			role = llm.RoleAssistant
+		}
+		req.Messages = append(req.Messages, llm.Message{Role: role, Content: m.Content})
+	}
+	req.Messages = append(req.Messages, llm.Message{Role: llm.RoleUser, Content: content})
+	return req, tmpl, nil
+}
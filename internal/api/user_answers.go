@@ -4,7 +4,6 @@ import (
 	"encoding/json/v2"
 	"errors"
 	"fmt"
-	"log/slog"
 	"net/http"
 	"strings"
 	"time"
@@ -86,16 +85,15 @@ func (s *Service) updateUserAnswer(r *http.Request, user *store.User) core.Respo
 		if err != nil {
 			return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to update test session: %w", err))
 		}
+		_, _, err = s.enqueueRecommendations(ctx, ts.ID)
+		if err != nil {
+			return core.Err(http.StatusInternalServerError, fmt.Errorf("failed to enqueue recommendations: %w", err))
+		}
 	}
 	s.store.Commit(ctx)
 	s.metrics.AppUpdatedUserAnswersCountInc()
-
 	if still-1 == 0 {
-		go func() {
-			if err = s.getUserRecommendationsByTestSessionID(user, ts.ID); err != nil {
-				s.log.Error("Failed to create recommendations", slog.Any("err", err))
-			}
-		}()
+		s.wakeJobs()
 	}
 
 	return core.Data(http.StatusOK, updateUserAnswerResponse{
@@ -8,6 +8,7 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/zagvozdeen/malicious-learning/internal/db/null"
 	"github.com/zagvozdeen/malicious-learning/internal/store"
+	"github.com/zagvozdeen/malicious-learning/internal/store/enum"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -21,7 +22,12 @@ func (s *Service) createRootUser() error {
 	if err != nil {
 		return err
 	}
-	_, err = s.store.GetUserByUsername(s.ctx, s.cfg.RootUserName)
+	user, err := s.store.GetUserByUsername(s.ctx, s.cfg.RootUserName)
+	if err == nil && user.Role != enum.UserRoleAdmin {
+		user.Role = enum.UserRoleAdmin
+		user.UpdatedAt = time.Now()
+		return s.store.UpdateUserRole(s.ctx, user)
+	}
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			u := &store.User{
@@ -29,6 +35,7 @@ func (s *Service) createRootUser() error {
 				FirstName: s.cfg.RootUserName,
 				Username:  null.WrapString(s.cfg.RootUserName),
 				Password:  null.WrapString(string(password)),
+				Role:      enum.UserRoleAdmin,
 				CreatedAt: time.Now(),
 				UpdatedAt: time.Now(),
 			}
@@ -22,11 +22,26 @@ type Config struct {
 	TelegramBotToken   string
 	TelegramBotEnabled bool
 	TelegramBotGroup   int
+	ChangelogNotify    bool
 	RootUserName       string
 	RootUserPassword   string
 	NeuroAPI           string
 	NeuroToken         string
 	NeuroDebug         bool
+	// LLMProvider is "openai" for an OpenAI-compatible NEURO_API or "fake"
+	// for canned answers without network.
+	LLMProvider             string
+	LLMRecommendationsModel string
+	LLMRenamerModel         string
+	LLMTutorModel           string
+	LLMDraftModel           string
+	// LLMPrices are prices of a million prompt and completion tokens by
+	// model, e.g. "gpt-5-mini=0.25/2".
+	LLMPrices string
+	// LLMDailyTokens and LLMUserDailyTokens limit tokens spent in a day by
+	// everyone and by one user, zero is no limit.
+	LLMDailyTokens     int
+	LLMUserDailyTokens int
 }
 
 func New() *Config {
@@ -34,24 +49,33 @@ func New() *Config {
 		slog.Warn("Failed to load .env file", slog.Any("err", err))
 	}
 	return &Config{
-		AppSecret:          os.Getenv("APP_SECRET"),
-		IsProduction:       os.Getenv("IS_PRODUCTION") == "true",
-		DBHost:             os.Getenv("DB_HOST"),
-		DBPort:             os.Getenv("DB_PORT"),
-		DBDatabase:         os.Getenv("DB_DATABASE"),
-		DBUsername:         os.Getenv("DB_USERNAME"),
-		DBPassword:         os.Getenv("DB_PASSWORD"),
-		DBDownMigrations:   false,
-		APIHost:            "127.0.0.1",
-		APIPort:            "8081",
-		TelegramBotToken:   os.Getenv("TG_BOT_TOKEN"),
-		TelegramBotEnabled: os.Getenv("TG_BOT_ENABLED") == "true",
-		TelegramBotGroup:   parseInt("TG_BOT_GROUP", 0),
-		RootUserName:       os.Getenv("ROOT_USER_NAME"),
-		RootUserPassword:   os.Getenv("ROOT_USER_PASSWORD"),
-		NeuroAPI:           os.Getenv("NEURO_API"),
-		NeuroToken:         os.Getenv("NEURO_TOKEN"),
-		NeuroDebug:         false,
+		AppSecret:               os.Getenv("APP_SECRET"),
+		IsProduction:            os.Getenv("IS_PRODUCTION") == "true",
+		DBHost:                  os.Getenv("DB_HOST"),
+		DBPort:                  os.Getenv("DB_PORT"),
+		DBDatabase:              os.Getenv("DB_DATABASE"),
+		DBUsername:              os.Getenv("DB_USERNAME"),
+		DBPassword:              os.Getenv("DB_PASSWORD"),
+		DBDownMigrations:        false,
+		APIHost:                 "127.0.0.1",
+		APIPort:                 "8081",
+		TelegramBotToken:        os.Getenv("TG_BOT_TOKEN"),
+		TelegramBotEnabled:      os.Getenv("TG_BOT_ENABLED") == "true",
+		TelegramBotGroup:        parseInt("TG_BOT_GROUP", 0),
+		ChangelogNotify:         os.Getenv("CHANGELOG_NOTIFY") == "true",
+		RootUserName:            os.Getenv("ROOT_USER_NAME"),
+		RootUserPassword:        os.Getenv("ROOT_USER_PASSWORD"),
+		NeuroAPI:                os.Getenv("NEURO_API"),
+		NeuroToken:              os.Getenv("NEURO_TOKEN"),
+		NeuroDebug:              false,
+		LLMProvider:             getEnv("LLM_PROVIDER", "openai"),
+		LLMRecommendationsModel: getEnv("LLM_MODEL_RECOMMENDATIONS", "gpt-5-mini"),
+		LLMRenamerModel:         getEnv("LLM_MODEL_RENAMER", "gpt-5-mini"),
+		LLMTutorModel:           getEnv("LLM_MODEL_TUTOR", "gpt-5-mini"),
+		LLMDraftModel:           getEnv("LLM_MODEL_DRAFT", "gpt-5-mini"),
+		LLMPrices:               os.Getenv("LLM_PRICES"),
+		LLMDailyTokens:          parseInt("LLM_DAILY_TOKENS", 0),
+		LLMUserDailyTokens:      parseInt("LLM_USER_DAILY_TOKENS", 0),
 	}
 }
 
@@ -63,3 +87,10 @@ func parseInt(key string, fallback int) int {
 	}
 	return fallback
 }
+
+func getEnv(key, fallback string) string {
+	if v, ok := os.LookupEnv(key); ok && v != "" {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,112 @@
+package converter
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/zagvozdeen/malicious-learning/internal/store"
+)
+
+// assetsDir is the only directory allowed inside a course directory.
+const assetsDir = "assets"
+
+// loadAssets reads every file in the assets directory of the course, stores it
+// and returns a map from the file path (relative to the course dir) to the asset.
+// Files with the same content share an asset and are stored once.
+func loadAssets(ctx context.Context, storage store.Storage, src fs.FS, dirName string) (map[string]*store.Asset, error) {
+	assets, err := readAssets(src, dirName)
+	if err != nil {
+		return nil, err
+	}
+	created := make(map[string]bool, len(assets))
+	for name, asset := range assets {
+		if created[asset.Hash] {
+			continue
+		}
+		created[asset.Hash] = true
+		err = storage.CreateAsset(ctx, asset)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create asset %s: %w", name, err)
+		}
+	}
+	return assets, nil
+}
+
+// readAssets reads assets of the course without storing them.
+func readAssets(src fs.FS, dirName string) (map[string]*store.Asset, error) {
+	assets := make(map[string]*store.Asset)
+	root := path.Join(dirName, assetsDir)
+	if _, err := fs.Stat(src, root); err != nil {
+		return assets, nil
+	}
+	err := fs.WalkDir(src, root, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		b, err := fs.ReadFile(src, name)
+		if err != nil {
+			return fmt.Errorf("failed to read asset %s: %w", name, err)
+		}
+		sum := sha256.Sum256(b)
+		asset := &store.Asset{
+			Hash:      hex.EncodeToString(sum[:]),
+			Name:      path.Base(name),
+			MimeType:  detectMimeType(name, b),
+			Content:   b,
+			CreatedAt: time.Now(),
+		}
+		assets[strings.TrimPrefix(name, dirName+"/")] = asset
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return assets, nil
+}
+
+func detectMimeType(name string, b []byte) string {
+	if t := mime.TypeByExtension(path.Ext(name)); t != "" {
+		return t
+	}
+	return http.DetectContentType(b)
+}
+
+// rewriteAssetLinks replaces relative image destinations with content-hashed
+// asset URLs. It fails if an image references a file missing in assets.
+func rewriteAssetLinks(doc ast.Node, assets map[string]*store.Asset) error {
+	var err error
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		img, ok := node.(*ast.Image)
+		if !ok || !entering || !isRelativeLink(string(img.Destination)) {
+			return ast.GoToNext
+		}
+		name := path.Clean(string(img.Destination))
+		asset, ok := assets[name]
+		if !ok {
+			err = fmt.Errorf("missing asset %q", string(img.Destination))
+			return ast.Terminate
+		}
+		img.Destination = []byte("/api/assets/" + asset.Hash)
+		return ast.GoToNext
+	})
+	return err
+}
+
+func isRelativeLink(dest string) bool {
+	if dest == "" || strings.HasPrefix(dest, "/") || strings.HasPrefix(dest, "#") {
+		return false
+	}
+	return !strings.Contains(dest, ":")
+}
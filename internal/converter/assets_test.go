@@ -0,0 +1,74 @@
+package converter
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zagvozdeen/malicious-learning/internal/store"
+)
+
+// assetStorage keeps created assets by hash like the assets table does.
+type assetStorage struct {
+	store.Storage
+	assets  map[string]*store.Asset
+	creates int
+}
+
+func (s *assetStorage) CreateAsset(_ context.Context, asset *store.Asset) error {
+	s.creates++
+	if _, ok := s.assets[asset.Hash]; !ok {
+		s.assets[asset.Hash] = asset
+	}
+	return nil
+}
+
+func TestLoadAssets(t *testing.T) {
+	src := fstest.MapFS{
+		"courses/go/0_index.yaml":          {Data: []byte("name: Go\n")},
+		"courses/go/assets/gopher.png":     {Data: []byte("gopher")},
+		"courses/go/assets/old/gopher.png": {Data: []byte("gopher")},
+		"courses/go/assets/map.svg":        {Data: []byte("<svg></svg>")},
+	}
+	storage := &assetStorage{assets: make(map[string]*store.Asset)}
+	assets, err := loadAssets(context.Background(), storage, src, "courses/go")
+	require.NoError(t, err)
+	require.Len(t, assets, 3)
+
+	gopher := assets["assets/gopher.png"]
+	require.NotNil(t, gopher)
+	assert.Equal(t, "gopher.png", gopher.Name)
+	assert.Equal(t, "image/png", gopher.MimeType)
+	assert.Len(t, gopher.Hash, 64)
+	assert.Equal(t, gopher.Hash, assets["assets/old/gopher.png"].Hash)
+	assert.NotEqual(t, gopher.Hash, assets["assets/map.svg"].Hash)
+	assert.Len(t, storage.assets, 2)
+	assert.Equal(t, 2, storage.creates)
+
+	assets, err = loadAssets(context.Background(), storage, src, "courses/sql")
+	require.NoError(t, err)
+	assert.Empty(t, assets)
+}
+
+func TestRewriteAssetLinks(t *testing.T) {
+	assets := map[string]*store.Asset{
+		"assets/gopher.png": {Hash: "abc"},
+	}
+	doc := newParser().Parse([]byte("![a](assets/gopher.png) ![b](./assets/../assets/gopher.png) ![c](https://go.dev/gopher.png) ![d](/api/assets/def)"))
+	require.NoError(t, rewriteAssetLinks(doc, assets))
+	var dests []string
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		if img, ok := node.(*ast.Image); ok && entering {
+			dests = append(dests, string(img.Destination))
+		}
+		return ast.GoToNext
+	})
+	assert.Equal(t, []string{"/api/assets/abc", "/api/assets/abc", "https://go.dev/gopher.png", "/api/assets/def"}, dests)
+
+	err := rewriteAssetLinks(newParser().Parse([]byte("![a](assets/missing.png)")), assets)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `missing asset "assets/missing.png"`)
+}
@@ -0,0 +1,121 @@
+package converter
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io"
+	"slices"
+	"strconv"
+
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/gomarkdown/markdown/parser"
+)
+
+const (
+	// clozeUIDBase moves derived cloze cards out of the range of file numbers,
+	// so card N with group G always gets uid clozeUIDBase + N*clozeMaxGroups + G.
+	clozeUIDBase   = 1_000_000
+	clozeMaxGroups = 100
+)
+
+// Cloze is a fill-in-the-blank deletion written as {{c1::text}} or
+// {{c1::text::hint}}, the text can not contain ::.
+type Cloze struct {
+	ast.Container
+
+	Group int
+	Hint  string
+}
+
+func registerCloze(p *parser.Parser) {
+	prev := p.RegisterInline('{', nil)
+	p.RegisterInline('{', clozeInline(prev))
+}
+
+func clozeInline(prev parser.InlineParser) parser.InlineParser {
+	return func(p *parser.Parser, original []byte, offset int) (int, ast.Node) {
+		data := original[offset:]
+		if !bytes.HasPrefix(data, []byte("{{c")) {
+			if prev != nil {
+				return prev(p, original, offset)
+			}
+			return 0, nil
+		}
+
+		i := 3
+		for i < len(data) && data[i] >= '0' && data[i] <= '9' {
+			i++
+		}
+		group, err := strconv.Atoi(string(data[3:i]))
+		if err != nil || group < 1 || group >= clozeMaxGroups || !bytes.HasPrefix(data[i:], []byte("::")) {
+			return 0, nil
+		}
+		start := i + 2
+		end := bytes.Index(data[start:], []byte("}}"))
+		if end <= 0 || bytes.IndexByte(data[start:start+end], '\n') >= 0 {
+			return 0, nil
+		}
+		text := data[start : start+end]
+		cloze := &Cloze{Group: group}
+		// Like Anki, the hint starts after the first ::, so the hint and not
+		// the answer may contain ::.
+		if k := bytes.Index(text, []byte("::")); k >= 0 {
+			cloze.Hint = string(bytes.TrimSpace(text[k+2:]))
+			text = text[:k]
+		}
+		p.Inline(cloze, text)
+		return start + end + 2, cloze
+	}
+}
+
+// clozeGroups returns the sorted distinct cloze groups used in the document.
+func clozeGroups(doc ast.Node) []int {
+	var groups []int
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		if cloze, ok := node.(*Cloze); ok && entering && !slices.Contains(groups, cloze.Group) {
+			groups = append(groups, cloze.Group)
+		}
+		return ast.GoToNext
+	})
+	slices.Sort(groups)
+	return groups
+}
+
+// clozeUID derives a stable uid of the card hiding the group of card id.
+func clozeUID(id, group int) (int, error) {
+	if id >= clozeUIDBase/clozeMaxGroups {
+		return 0, fmt.Errorf("card id %d is too big for cloze deletions", id)
+	}
+	return clozeUIDBase + id*clozeMaxGroups + group, nil
+}
+
+// fileID is the id of the card file the card uid was derived from.
+func fileID(uid int) int {
+	if uid >= clozeUIDBase {
+		return (uid - clozeUIDBase) / clozeMaxGroups
+	}
+	return uid
+}
+
+func (r *renderer) renderCloze(w io.Writer, cloze *Cloze, entering bool) ast.WalkStatus {
+	if cloze.Group != r.cloze {
+		return ast.GoToNext
+	}
+	if r.reveal {
+		if entering {
+			_, _ = io.WriteString(w, `<span class="cloze cloze-answer">`)
+		} else {
+			_, _ = io.WriteString(w, `</span>`)
+		}
+		return ast.GoToNext
+	}
+	if entering {
+		hint := "…"
+		if cloze.Hint != "" {
+			hint = cloze.Hint
+		}
+		_, _ = io.WriteString(w, `<span class="cloze">[`+html.EscapeString(hint)+`]</span>`)
+	}
+	return ast.SkipChildren
+}
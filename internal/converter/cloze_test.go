@@ -0,0 +1,73 @@
+package converter
+
+import (
+	"testing"
+
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderVariants(t *testing.T) {
+	r := &renderer{hlighter: newHighlighter()}
+
+	doc := newParser().Parse([]byte("Go {{c1::map}} is a {{c2::hash table::структура}}, see {{c1::runtime/map.go}}."))
+	variants, err := renderVariants(r, nil, doc, 10)
+	require.NoError(t, err)
+	require.Len(t, variants, 2)
+
+	assert.Equal(t, clozeUIDBase+10*clozeMaxGroups+1, variants[0].uid)
+	assert.Contains(t, variants[0].questionBody, `Go <span class="cloze">[…]</span> is a hash table`)
+	assert.Contains(t, variants[0].answer, `Go <span class="cloze cloze-answer">map</span> is a hash table`)
+
+	assert.Equal(t, clozeUIDBase+10*clozeMaxGroups+2, variants[1].uid)
+	assert.Contains(t, variants[1].questionBody, `Go map is a <span class="cloze">[структура]</span>`)
+	assert.Contains(t, variants[1].answer, `<span class="cloze cloze-answer">hash table</span>`)
+
+	doc = newParser().Parse([]byte("No cloze {{here}}."))
+	variants, err = renderVariants(r, nil, doc, 11)
+	require.NoError(t, err)
+	require.Len(t, variants, 1)
+	assert.Equal(t, 11, variants[0].uid)
+	assert.Empty(t, variants[0].questionBody)
+
+	doc = newParser().Parse([]byte("Is {{c1::this}} a question?"))
+	variants, err = renderVariants(r, newParser().Parse([]byte("Fill the gap:")), doc, 12)
+	require.NoError(t, err)
+	require.Len(t, variants, 1)
+	assert.Equal(t, "<p>Fill the gap:</p>\n<p>Is <span class=\"cloze\">[…]</span> a question?</p>\n", variants[0].questionBody)
+}
+
+func TestClozeHint(t *testing.T) {
+	tests := []struct {
+		markdown string
+		text     string
+		hint     string
+	}{
+		{markdown: "{{c1::vector}}", text: "vector"},
+		{markdown: "{{c1::vector::container}}", text: "vector", hint: "container"},
+		{markdown: "{{c1::vector::std::vector}}", text: "vector", hint: "std::vector"},
+		{markdown: "{{c1::vector:: }}", text: "vector"},
+	}
+	for _, tt := range tests {
+		doc := newParser().Parse([]byte(tt.markdown))
+		var cloze *Cloze
+		ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+			if c, ok := node.(*Cloze); ok && entering {
+				cloze = c
+			}
+			return ast.GoToNext
+		})
+		require.NotNil(t, cloze, tt.markdown)
+		assert.Equal(t, tt.hint, cloze.Hint, tt.markdown)
+		require.Len(t, cloze.Children, 1, tt.markdown)
+		assert.Equal(t, tt.text, string(cloze.Children[0].AsLeaf().Literal), tt.markdown)
+	}
+}
+
+func TestFileID(t *testing.T) {
+	uid, err := clozeUID(10, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 10, fileID(uid))
+	assert.Equal(t, 11, fileID(11))
+}
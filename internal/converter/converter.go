@@ -3,186 +3,314 @@ package converter
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
-	"io"
 	"io/fs"
+	"maps"
 	"path"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/adrg/frontmatter"
-	"github.com/gomarkdown/markdown"
 	"github.com/gomarkdown/markdown/ast"
-	"github.com/gomarkdown/markdown/html"
-	"github.com/gomarkdown/markdown/parser"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
-	"github.com/zagvozdeen/malicious-learning/data"
+	"github.com/zagvozdeen/malicious-learning/internal/db/null"
+	"github.com/zagvozdeen/malicious-learning/internal/prompts"
 	"github.com/zagvozdeen/malicious-learning/internal/store"
+	"github.com/zagvozdeen/malicious-learning/internal/store/enum"
 	"gopkg.in/yaml.v3"
 )
 
 type CourseDescription struct {
 	Name string `yaml:"name"`
+	// Prompts override prompt templates of the language model by name.
+	Prompts map[string]string `yaml:"prompts,omitempty"`
 }
 
 type CardDescription struct {
 	Name   string   `yaml:"name"`
 	Module string   `yaml:"module"`
 	Tags   []string `yaml:"tags"`
+	// Draft marks an answer written by cmd/draft and not yet reviewed, the
+	// converter skips such cards.
+	Draft bool `yaml:"draft,omitempty"`
 }
 
-func Run(ctx context.Context, storage store.Storage) error {
-	entries, err := data.Courses.ReadDir("courses")
+// cardVariant is a card rendered from a markdown file. A file with cloze
+// deletions produces one variant per cloze group.
+type cardVariant struct {
+	uid int
+	// question is the escaped name of the card.
+	question     string
+	questionBody string
+	answer       string
+	translations []store.CardTranslation
+}
+
+type pendingLink struct {
+	course    int
+	uid       int
+	target    string
+	targetUID int
+}
+
+// Result is the outcome of a conversion.
+type Result struct {
+	// Changelog lists cards added, changed and removed by the conversion.
+	Changelog []store.ChangelogEntry
+	// Duplicates are likely duplicate questions across courses.
+	Duplicates []Duplicate
+}
+
+// Run converts course files to cards and indexes them for links and similar
+// cards.
+func Run(ctx context.Context, storage store.Storage) (*Result, error) {
+	return convert(ctx, storage, nil)
+}
+
+// Publish publishes the draft and converts course files with it in the same
+// transaction, so a draft breaking the conversion is never published.
+func Publish(ctx context.Context, storage store.Storage, d *store.CardDraft) (*Result, error) {
+	return convert(ctx, storage, d)
+}
+
+func convert(ctx context.Context, storage store.Storage, draft *store.CardDraft) (*Result, error) {
+	ctx, err := storage.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin tx: %w", err)
+	}
+	defer storage.Rollback(ctx)
+	err = storage.LockContent(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock content: %w", err)
+	}
+	start := time.Now()
+	if draft != nil {
+		now := time.Now()
+		err = storage.SupersedeCardDrafts(ctx, draft, now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to supersede drafts: %w", err)
+		}
+		draft.Status = enum.DraftStatusPublished
+		draft.PublishedAt = &now
+		draft.UpdatedAt = now
+		err = storage.UpdateCardDraft(ctx, draft)
+		if err != nil {
+			return nil, fmt.Errorf("failed to publish draft: %w", err)
+		}
+	}
+	src, err := newSource(ctx, storage)
 	if err != nil {
-		return fmt.Errorf("failed to read courses dir: %w", err)
+		return nil, fmt.Errorf("failed to load published drafts: %w", err)
 	}
-	head := &strings.Builder{}
-	var hlighter *highlighter
-	hlighter, err = newHighlighter(head)
+	entries, err := fs.ReadDir(src, "courses")
 	if err != nil {
-		return fmt.Errorf("failed to create highlighter: %w", err)
+		return nil, fmt.Errorf("failed to read courses dir: %w", err)
 	}
-	ctx, err = storage.Begin(ctx)
+	c, err := newConversion(storage, src)
 	if err != nil {
-		return fmt.Errorf("failed to begin tx: %w", err)
+		return nil, err
 	}
-	defer storage.Rollback(ctx)
 	for _, entry := range entries {
 		if !entry.IsDir() {
-			return fmt.Errorf("found file in courses dir: %s", entry.Name())
+			return nil, fmt.Errorf("found file in courses dir: %s", entry.Name())
 		}
 		var course *store.Course
 		course, err = storage.GetCourseBySlug(ctx, entry.Name())
 		if err != nil {
 			if !errors.Is(err, pgx.ErrNoRows) {
-				return fmt.Errorf("failed to get course by slug: %w", err)
-			}
-			var b []byte
-			b, err = data.Courses.ReadFile(fmt.Sprintf("courses/%s/0_index.yaml", entry.Name()))
-			if err != nil {
-				return fmt.Errorf("failed to read 0_index.yaml file in %s: %w", entry.Name(), err)
-			}
-			cd := &CourseDescription{}
-			err = yaml.Unmarshal(b, cd)
-			if err != nil {
-				return fmt.Errorf("failed to unmarshal yaml to struct: %w", err)
+				return nil, fmt.Errorf("failed to get course by slug: %w", err)
 			}
 			var uid uuid.UUID
 			uid, err = uuid.NewV7()
 			if err != nil {
-				return fmt.Errorf("failed to generate course uuid: %w", err)
+				return nil, fmt.Errorf("failed to generate course uuid: %w", err)
 			}
 			course = &store.Course{
 				UUID:      uid.String(),
 				Slug:      entry.Name(),
-				Name:      cd.Name,
 				CreatedAt: time.Now(),
 				UpdatedAt: time.Now(),
 			}
+			course.Name, err = readCourseName(src, entry.Name())
+			if err != nil {
+				return nil, err
+			}
 			err = storage.CreateCourse(ctx, course)
 			if err != nil {
-				return fmt.Errorf("failed to create course: %w", err)
+				return nil, fmt.Errorf("failed to create course: %w", err)
 			}
 		}
-		var cardEntries []fs.DirEntry
-		dirName := fmt.Sprintf("courses/%s", entry.Name())
-		cardEntries, err = data.Courses.ReadDir(dirName)
+		if course.OwnerID.Valid {
+			return nil, fmt.Errorf("course %s is a private deck", course.Slug)
+		}
+		err = c.convertCourse(ctx, course)
 		if err != nil {
-			return fmt.Errorf("failed to read dir %q: %w", dirName, err)
+			return nil, err
 		}
-		for _, cardEntry := range cardEntries {
-			if cardEntry.IsDir() {
-				return fmt.Errorf("dir %s haves a dir %q", dirName, cardEntry.Name())
-			}
-			if cardEntry.Name() == "0_index.yaml" {
-				continue
-			}
-			if path.Ext(cardEntry.Name()) != ".md" {
-				return fmt.Errorf("dir %s haves not markdown file %s", dirName, cardEntry.Name())
-			}
-			parts := strings.SplitN(cardEntry.Name(), "_", 2)
-			if len(parts) != 2 {
-				return fmt.Errorf("failed to split card %q", cardEntry.Name())
+	}
+	res, err := c.finish(ctx, start)
+	if err != nil {
+		return nil, err
+	}
+	storage.Commit(ctx)
+	return res, nil
+}
+
+// conversion converts courses of the source in one transaction.
+type conversion struct {
+	storage   store.Storage
+	src       fs.FS
+	rndr      *renderer
+	revision  *store.ContentRevision
+	changelog []store.ChangelogEntry
+	// Links are stored after every course is converted, a link may point to
+	// a course that is created later.
+	links     []pendingLink
+	courseIDs map[string]int
+	indexed   []indexedCard
+}
+
+func newConversion(storage store.Storage, src fs.FS) (*conversion, error) {
+	cards, err := indexCards(src)
+	if err != nil {
+		return nil, fmt.Errorf("failed to index cards: %w", err)
+	}
+	c := &conversion{
+		storage:   storage,
+		src:       src,
+		rndr:      &renderer{hlighter: newHighlighter(), cards: cards},
+		revision:  &store.ContentRevision{},
+		courseIDs: make(map[string]int),
+	}
+	c.revision.Hash, err = contentHash(src)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash content: %w", err)
+	}
+	return c, nil
+}
+
+// readCourseName reads the course name from 0_index.yaml of the course dir.
+func readCourseName(src fs.FS, slug string) (string, error) {
+	b, err := fs.ReadFile(src, fmt.Sprintf("courses/%s/0_index.yaml", slug))
+	if err != nil {
+		return "", fmt.Errorf("failed to read 0_index.yaml file in %s: %w", slug, err)
+	}
+	cd := &CourseDescription{}
+	err = yaml.Unmarshal(b, cd)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal yaml to struct: %w", err)
+	}
+	return cd.Name, nil
+}
+
+// readCoursePrompts reads prompt overrides from 0_index.yaml of the course
+// dir, every override must be a valid template of a known prompt.
+func readCoursePrompts(src fs.FS, slug string) ([]store.CoursePrompt, error) {
+	b, err := fs.ReadFile(src, fmt.Sprintf("courses/%s/0_index.yaml", slug))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read 0_index.yaml file in %s: %w", slug, err)
+	}
+	cd := &CourseDescription{}
+	err = yaml.Unmarshal(b, cd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal yaml to struct: %w", err)
+	}
+	var overrides []store.CoursePrompt
+	for _, name := range slices.Sorted(maps.Keys(cd.Prompts)) {
+		t, err := prompts.Override(name, slug, cd.Prompts[name])
+		if err != nil {
+			return nil, fmt.Errorf("invalid prompt in %s: %w", slug, err)
+		}
+		overrides = append(overrides, store.CoursePrompt{Name: name, Version: t.Version, Template: cd.Prompts[name]})
+	}
+	return overrides, nil
+}
+
+// convertCourse converts the files of the course dir into cards of the
+// course and deactivates cards of removed files.
+func (c *conversion) convertCourse(ctx context.Context, course *store.Course) error {
+	storage, src, rndr := c.storage, c.src, c.rndr
+	c.courseIDs[course.Slug] = course.ID
+	rndr.course = course.Slug
+	dirName := fmt.Sprintf("courses/%s", course.Slug)
+	courseTranslations, err := readCourseTranslations(src, dirName)
+	if err != nil {
+		return err
+	}
+	err = storage.ReplaceCourseTranslations(ctx, course.ID, courseTranslations)
+	if err != nil {
+		return fmt.Errorf("failed to store course translations: %w", err)
+	}
+	coursePrompts, err := readCoursePrompts(src, course.Slug)
+	if err != nil {
+		return err
+	}
+	err = storage.ReplaceCoursePrompts(ctx, course.ID, coursePrompts)
+	if err != nil {
+		return fmt.Errorf("failed to store course prompts: %w", err)
+	}
+	names, err := cardFiles(src, dirName)
+	if err != nil {
+		return err
+	}
+	// Modules of a private deck belong to it, public courses share modules.
+	var moduleCourse null.Int
+	if course.OwnerID.Valid {
+		moduleCourse = null.WrapInt(course.ID)
+	}
+	assets, err := loadAssets(ctx, storage, src, dirName)
+	if err != nil {
+		return fmt.Errorf("failed to load assets in %s: %w", dirName, err)
+	}
+	var uids []int
+	for _, name := range names {
+		var id int
+		id, err = cardID(name)
+		if err != nil {
+			return err
+		}
+		var cd *CardDescription
+		var variants []cardVariant
+		rndr.links = make(map[cardRef]cardTarget)
+		cd, variants, err = convertFile(rndr, src, dirName, name, id, assets)
+		if err != nil {
+			return err
+		}
+		if cd.Draft {
+			continue
+		}
+		var module *store.Module
+		module, err = storage.GetModuleByName(ctx, cd.Module, moduleCourse)
+		if err != nil {
+			if !errors.Is(err, pgx.ErrNoRows) {
+				return fmt.Errorf("failed to get module by name: %w", err)
 			}
-			var id int
-			id, err = strconv.Atoi(parts[0])
+			var uid uuid.UUID
+			uid, err = uuid.NewV7()
 			if err != nil {
-				return fmt.Errorf("failed to parse card id: %w", err)
+				return fmt.Errorf("failed to generate module uuid: %w", err)
 			}
-			var b []byte
-			fileName := fmt.Sprintf("courses/%s/%s", entry.Name(), cardEntry.Name())
-			b, err = data.Courses.ReadFile(fileName)
-			if err != nil {
-				return fmt.Errorf("failed to read file %s: %w", fileName, err)
+			module = &store.Module{
+				UUID:      uid.String(),
+				Name:      cd.Module,
+				CourseID:  moduleCourse,
+				CreatedAt: time.Now(),
+				UpdatedAt: time.Now(),
 			}
-			cd := &CardDescription{}
-			b, err = frontmatter.Parse(bytes.NewReader(b), cd, frontmatter.NewFormat("---", "---", yaml.Unmarshal))
-			if err != nil {
-				return fmt.Errorf("failed to parse front-matter: %w", err)
-			}
-			cd.Name = strings.TrimSpace(cd.Name)
-			cd.Module = strings.TrimSpace(cd.Module)
-			if cd.Module == "" || cd.Name == "" {
-				return fmt.Errorf("failed to parse card description %q: module or name is empty", cardEntry.Name())
-			}
-			extensions := parser.CommonExtensions | parser.AutoHeadingIDs | parser.NoEmptyLineBeforeBlock
-			p := parser.NewWithExtensions(extensions)
-			registerSpoiler(p)
-			doc := p.Parse(b)
-			renderer := html.NewRenderer(html.RendererOptions{
-				Flags: html.CommonFlags | html.HrefTargetBlank,
-				RenderNodeHook: func(w io.Writer, node ast.Node, entering bool) (ast.WalkStatus, bool) {
-					if _, ok := node.(*ast.Table); ok {
-						if entering {
-							_, _ = io.WriteString(w, `<div class="table-wrapper"><table>`)
-						} else {
-							_, _ = io.WriteString(w, `</table></div>`)
-						}
-						return ast.GoToNext, true
-					}
-					if _, ok := node.(*Spoiler); ok {
-						if entering {
-							_, _ = io.WriteString(w, `<span class="spoiler">`)
-						} else {
-							_, _ = io.WriteString(w, `</span>`)
-						}
-						return ast.GoToNext, true
-					}
-					if code, ok := node.(*ast.CodeBlock); ok {
-						err = hlighter.highlight(w, string(code.Literal), string(code.Info))
-						if err != nil {
-							return ast.GoToNext, false
-						}
-						return ast.GoToNext, true
-					}
-					return ast.GoToNext, false
-				},
-			})
-			xml := markdown.Render(doc, renderer)
-			var module *store.Module
-			module, err = storage.GetModuleByName(ctx, cd.Module)
+			err = storage.CreateModule(ctx, module)
 			if err != nil {
-				if !errors.Is(err, pgx.ErrNoRows) {
-					return fmt.Errorf("failed to get module by name: %w", err)
-				}
-				var uid uuid.UUID
-				uid, err = uuid.NewV7()
-				if err != nil {
-					return fmt.Errorf("failed to generate module uuid: %w", err)
-				}
-				module = &store.Module{
-					UUID:      uid.String(),
-					Name:      cd.Module,
-					CreatedAt: time.Now(),
-					UpdatedAt: time.Now(),
-				}
-				err = storage.CreateModule(ctx, module)
-				if err != nil {
-					return fmt.Errorf("failed to create module: %w", err)
-				}
+				return fmt.Errorf("failed to create module: %w", err)
+			}
+		}
+		for _, variant := range variants {
+			for ref, target := range rndr.links {
+				c.links = append(c.links, pendingLink{course: course.ID, uid: variant.uid, target: ref.course, targetUID: target.uid})
 			}
 			var uid uuid.UUID
 			uid, err = uuid.NewV7()
@@ -190,36 +318,316 @@ func Run(ctx context.Context, storage store.Storage) error {
 				return fmt.Errorf("failed to generate card uuid: %w", err)
 			}
 			card := &store.Card{
-				UID:       id,
-				UUID:      uid.String(),
-				Question:  cd.Name,
-				Answer:    string(xml) + head.String(),
-				Tags:      cd.Tags,
-				ModuleID:  module.ID,
-				CourseID:  course.ID,
-				IsActive:  true,
-				CreatedAt: time.Now(),
-				UpdatedAt: time.Now(),
+				UID:          variant.uid,
+				UUID:         uid.String(),
+				Question:     variant.question,
+				QuestionBody: variant.questionBody,
+				Answer:       variant.answer,
+				Tags:         cd.Tags,
+				Translations: variant.translations,
+				ModuleID:     module.ID,
+				CourseID:     course.ID,
+				IsActive:     true,
+				CreatedAt:    time.Now(),
+				UpdatedAt:    time.Now(),
 			}
+			card.PlainAnswer = plainAnswer(variant)
 			card.Hash = card.GetHash()
+			c.indexed = append(c.indexed, newIndexedCard(card, course.Slug, id))
+			c.revision.CardsTotal++
+			uids = append(uids, card.UID)
 			var exists bool
-			exists, err = storage.IsExistsCardByUIDAndHash(ctx, card.UID, card.Hash)
+			exists, err = storage.IsExistsCardByUIDAndHash(ctx, card.CourseID, card.UID, card.Hash)
 			if err != nil {
 				return fmt.Errorf("failed to check existing card: %w", err)
 			}
 			if exists {
 				continue
 			}
-			err = storage.DeactivateCard(ctx, card)
+			var deactivated int64
+			deactivated, err = storage.DeactivateCard(ctx, card)
 			if err != nil {
 				return fmt.Errorf("failed to deactivate card: %w", err)
 			}
+			c.revision.CardsDeactivated += int(deactivated)
 			err = storage.CreateCard(ctx, card)
 			if err != nil {
 				return fmt.Errorf("failed to create card: %w", err)
 			}
+			c.revision.CardsCreated++
+			kind := enum.ChangeKindAdded
+			if deactivated > 0 {
+				kind = enum.ChangeKindChanged
+			}
+			c.changelog = append(c.changelog, newChangelogEntry(card, kind))
 		}
 	}
-	storage.Commit(ctx)
+	removed, err := storage.DeactivateCardsExcept(ctx, course.ID, uids, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to deactivate removed cards: %w", err)
+	}
+	c.revision.CardsDeactivated += len(removed)
+	for i := range removed {
+		c.changelog = append(c.changelog, newChangelogEntry(&removed[i], enum.ChangeKindRemoved))
+	}
 	return nil
 }
+
+// cardFiles lists card files of the course dir checking that it has only the
+// index, card files, their translations and assets.
+func cardFiles(src fs.FS, dirName string) ([]string, error) {
+	entries, err := fs.ReadDir(src, dirName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dir %q: %w", dirName, err)
+	}
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			if entry.Name() == assetsDir {
+				continue
+			}
+			return nil, fmt.Errorf("dir %s haves a dir %q", dirName, entry.Name())
+		}
+		if entry.Name() == "0_index.yaml" {
+			continue
+		}
+		if base, _, ok := localeOf(entry.Name()); ok {
+			found, err := exists(src, path.Join(dirName, base))
+			if err != nil {
+				return nil, err
+			}
+			if !found {
+				return nil, fmt.Errorf("translation %s/%s has no %s", dirName, entry.Name(), base)
+			}
+			continue
+		}
+		if path.Ext(entry.Name()) != ".md" {
+			return nil, fmt.Errorf("dir %s haves not markdown file %s", dirName, entry.Name())
+		}
+		names = append(names, entry.Name())
+	}
+	return names, nil
+}
+
+// finish stores links and similarities of the converted courses, the content
+// revision and the changelog.
+func (c *conversion) finish(ctx context.Context, start time.Time) (*Result, error) {
+	courseIDs := make([]int, 0, len(c.courseIDs))
+	for _, id := range c.courseIDs {
+		courseIDs = append(courseIDs, id)
+	}
+	cardLinks := make([]store.CardLink, 0, len(c.links))
+	for _, link := range c.links {
+		cardLinks = append(cardLinks, store.CardLink{
+			CourseID:       link.course,
+			UID:            link.uid,
+			TargetCourseID: c.courseIDs[link.target],
+			TargetUID:      link.targetUID,
+		})
+	}
+	err := c.storage.ReplaceCardLinks(ctx, courseIDs, cardLinks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store card links: %w", err)
+	}
+	similarities, duplicates := buildSimilarities(c.indexed)
+	err = c.storage.ReplaceCardSimilarities(ctx, courseIDs, similarities)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store card similarities: %w", err)
+	}
+	c.revision.DurationMs = time.Since(start).Milliseconds()
+	c.revision.CreatedAt = time.Now()
+	err = c.storage.CreateContentRevision(ctx, c.revision)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create content revision: %w", err)
+	}
+	for i := range c.changelog {
+		c.changelog[i].ContentRevisionID = c.revision.ID
+		c.changelog[i].CreatedAt = c.revision.CreatedAt
+	}
+	err = c.storage.CreateChangelogEntries(ctx, c.changelog)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create changelog: %w", err)
+	}
+	return &Result{Changelog: c.changelog, Duplicates: duplicates}, nil
+}
+
+func newChangelogEntry(card *store.Card, kind enum.ChangeKind) store.ChangelogEntry {
+	return store.ChangelogEntry{
+		CourseID: card.CourseID,
+		ModuleID: card.ModuleID,
+		CardUID:  card.UID,
+		Question: card.Question,
+		Kind:     kind,
+	}
+}
+
+// convertFile renders the card file and its translations to card variants.
+// A draft has no variants.
+func convertFile(r *renderer, src fs.FS, dirName, name string, id int, assets map[string]*store.Asset) (*CardDescription, []cardVariant, error) {
+	fileName := path.Join(dirName, name)
+	cd, questionDoc, doc, err := parseCard(src, fileName, assets)
+	if err != nil {
+		return nil, nil, err
+	}
+	if cd.Draft {
+		return cd, nil, nil
+	}
+	if cd.Module == "" {
+		return nil, nil, fmt.Errorf("failed to parse card description %q: module is empty", name)
+	}
+	variants, err := renderVariants(r, questionDoc, doc, id)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to render %s: %w", fileName, err)
+	}
+	for i := range variants {
+		variants[i].question = escapeQuestion(cd.Name)
+	}
+	err = translateVariants(r, src, variants, dirName, name, id, assets)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cd, variants, nil
+}
+
+// cardID parses the number a card file name starts with.
+func cardID(name string) (int, error) {
+	parts := strings.SplitN(name, "_", 2)
+	if len(parts) != 2 {
+		return 0, fmt.Errorf("failed to split card %q", name)
+	}
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse card id: %w", err)
+	}
+	return id, nil
+}
+
+// readCard reads the card front-matter, the optional question section and the
+// answer of the card file. Only the front-matter of a draft is read, drafts
+// may be incomplete and callers skip them.
+func readCard(src fs.FS, fileName string) (cd *CardDescription, question, answer []byte, err error) {
+	b, err := fs.ReadFile(src, fileName)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to read file %s: %w", fileName, err)
+	}
+	cd = &CardDescription{}
+	b, err = frontmatter.Parse(bytes.NewReader(b), cd, frontmatter.NewFormat("---", "---", yaml.Unmarshal))
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to parse front-matter: %w", err)
+	}
+	cd.Name = strings.TrimSpace(cd.Name)
+	cd.Module = strings.TrimSpace(cd.Module)
+	if cd.Draft {
+		return cd, nil, nil, nil
+	}
+	if cd.Name == "" {
+		return nil, nil, nil, fmt.Errorf("failed to parse card description %q: name is empty", path.Base(fileName))
+	}
+	question, answer, err = splitQuestion(b)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to split question in %s: %w", fileName, err)
+	}
+	return cd, question, answer, nil
+}
+
+// plainAnswer is the text of the variant answer followed by its translations.
+func plainAnswer(variant cardVariant) string {
+	parts := []string{PlainText([]byte(variant.answer))}
+	for _, t := range variant.translations {
+		parts = append(parts, PlainText([]byte(t.Question)), PlainText([]byte(t.Answer)))
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// parseCard reads the card file and parses its question and answer with asset
+// links rewritten. The question is nil when the card has no question section.
+func parseCard(src fs.FS, fileName string, assets map[string]*store.Asset) (cd *CardDescription, question, answer ast.Node, err error) {
+	cd, qb, b, err := readCard(src, fileName)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if cd.Draft {
+		return cd, nil, nil, nil
+	}
+	if qb != nil {
+		question = newParser().Parse(qb)
+		err = rewriteAssetLinks(question, assets)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to rewrite asset links in %s: %w", fileName, err)
+		}
+	}
+	answer = newParser().Parse(b)
+	err = rewriteAssetLinks(answer, assets)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to rewrite asset links in %s: %w", fileName, err)
+	}
+	return cd, question, answer, nil
+}
+
+// contentHash hashes paths and contents of all course files.
+func contentHash(src fs.FS) (string, error) {
+	hasher := sha256.New()
+	err := fs.WalkDir(src, "courses", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		b, err := fs.ReadFile(src, name)
+		if err != nil {
+			return err
+		}
+		hasher.Write([]byte(name))
+		hasher.Write([]byte{0})
+		hasher.Write(b)
+		hasher.Write([]byte{0})
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+func renderVariants(r *renderer, questionDoc, doc ast.Node, id int) ([]cardVariant, error) {
+	var question []byte
+	if questionDoc != nil {
+		var err error
+		r.cloze = 0
+		question, err = r.render(questionDoc)
+		if err != nil {
+			return nil, err
+		}
+	}
+	groups := clozeGroups(doc)
+	if len(groups) == 0 {
+		r.cloze = 0
+		answer, err := r.render(doc)
+		if err != nil {
+			return nil, err
+		}
+		return []cardVariant{{uid: id, questionBody: string(question), answer: string(answer)}}, nil
+	}
+	variants := make([]cardVariant, 0, len(groups))
+	for _, group := range groups {
+		uid, err := clozeUID(id, group)
+		if err != nil {
+			return nil, err
+		}
+		r.cloze, r.reveal = group, false
+		hidden, err := r.render(doc)
+		if err != nil {
+			return nil, err
+		}
+		r.reveal = true
+		answer, err := r.render(doc)
+		if err != nil {
+			return nil, err
+		}
+		variants = append(variants, cardVariant{
+			uid:          uid,
+			questionBody: string(question) + string(hidden),
+			answer:       string(answer),
+		})
+	}
+	r.cloze, r.reveal = 0, false
+	return variants, nil
+}
@@ -0,0 +1,210 @@
+package converter
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/zagvozdeen/malicious-learning/internal/store"
+)
+
+// ErrCourseExists is returned by Import when a course with the slug of a new
+// deck was created after the caller looked it up.
+var ErrCourseExists = errors.New("course already exists")
+
+// Limits of an uploaded deck, the size is of unpacked files.
+const (
+	maxDeckFiles = 1000
+	maxDeckSize  = 50 << 20
+)
+
+// deckAssetTypes are the types of assets a deck may have. Assets are served
+// from the origin of the app, so anything a browser may run, like HTML or SVG,
+// is rejected.
+var deckAssetTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// emptyFS has no files, decks are read on top of it.
+type emptyFS struct{}
+
+func (emptyFS) Open(name string) (fs.File, error) {
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+// ReadDeck reads a zip in the data/courses/<slug> layout as the course files
+// of the course slug. Files may be at the root of the zip or in a single dir
+// with 0_index.yaml, hidden files and macOS metadata are skipped.
+func ReadDeck(r io.ReaderAt, size int64, slug string) (fs.FS, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open zip: %w", err)
+	}
+	if len(zr.File) > maxDeckFiles {
+		return nil, fmt.Errorf("zip has more than %d files", maxDeckFiles)
+	}
+	root := ""
+	found := false
+	for _, f := range zr.File {
+		if path.Base(f.Name) != "0_index.yaml" || strings.Count(f.Name, "/") > 1 {
+			continue
+		}
+		if found {
+			return nil, fmt.Errorf("zip has more than one 0_index.yaml")
+		}
+		root, _ = strings.CutSuffix(f.Name, "0_index.yaml")
+		found = true
+	}
+	if !found {
+		return nil, fmt.Errorf("zip has no 0_index.yaml")
+	}
+	deck := newOverlay(emptyFS{})
+	var total int64
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() || strings.HasPrefix(f.Name, "__MACOSX/") || strings.HasPrefix(path.Base(f.Name), ".") {
+			continue
+		}
+		rel, ok := strings.CutPrefix(f.Name, root)
+		if !ok || !fs.ValidPath(rel) {
+			return nil, fmt.Errorf("file %s is outside of the deck dir", f.Name)
+		}
+		var b []byte
+		b, err = readZipFile(f, maxDeckSize-total)
+		if err != nil {
+			return nil, err
+		}
+		total += int64(len(b))
+		deck.files[path.Join("courses", slug, rel)] = b
+	}
+	return deck, nil
+}
+
+// readZipFile reads the file failing when it is larger than limit, the size
+// in the zip header is not trusted.
+func readZipFile(f *zip.File, limit int64) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", f.Name, err)
+	}
+	defer rc.Close()
+	b, err := io.ReadAll(io.LimitReader(rc, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", f.Name, err)
+	}
+	if int64(len(b)) > limit {
+		return nil, fmt.Errorf("zip is larger than %d MiB unpacked", maxDeckSize>>20)
+	}
+	return b, nil
+}
+
+// ValidateDeck renders every card of the deck the same way Import does,
+// without storing anything, and returns the number of cards.
+func ValidateDeck(deck fs.FS, slug string) (int, error) {
+	dirName := path.Join("courses", slug)
+	cards, err := indexCards(deck)
+	if err != nil {
+		return 0, fmt.Errorf("failed to index cards: %w", err)
+	}
+	name, err := readCourseName(deck, slug)
+	if err != nil {
+		return 0, err
+	}
+	if name == "" {
+		return 0, fmt.Errorf("0_index.yaml has no name")
+	}
+	_, err = readCoursePrompts(deck, slug)
+	if err != nil {
+		return 0, err
+	}
+	names, err := cardFiles(deck, dirName)
+	if err != nil {
+		return 0, err
+	}
+	assets, err := readAssets(deck, dirName)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read assets: %w", err)
+	}
+	for name, asset := range assets {
+		// The content is sniffed too, the type of the asset comes from the
+		// extension.
+		if !deckAssetTypes[asset.MimeType] || http.DetectContentType(asset.Content) != asset.MimeType {
+			return 0, fmt.Errorf("asset %s is not a png, jpeg, gif or webp image", name)
+		}
+	}
+	rndr := &renderer{hlighter: newHighlighter(), cards: cards, course: slug}
+	total := 0
+	for _, file := range names {
+		id, err := cardID(file)
+		if err != nil {
+			return 0, err
+		}
+		rndr.links = make(map[cardRef]cardTarget)
+		_, variants, err := convertFile(rndr, deck, dirName, file, id, assets)
+		if err != nil {
+			return 0, err
+		}
+		total += len(variants)
+	}
+	return total, nil
+}
+
+// Import converts the deck checked by ValidateDeck into the private course,
+// the course is created when it has no id yet. Cards keep their uids across
+// uploads, so progress on unchanged cards is kept.
+func Import(ctx context.Context, storage store.Storage, course *store.Course, deck fs.FS) (*Result, error) {
+	ctx, err := storage.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin tx: %w", err)
+	}
+	defer storage.Rollback(ctx)
+	err = storage.LockContent(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock content: %w", err)
+	}
+	start := time.Now()
+	c, err := newConversion(storage, deck)
+	if err != nil {
+		return nil, err
+	}
+	course.Name, err = readCourseName(deck, course.Slug)
+	if err != nil {
+		return nil, err
+	}
+	course.UpdatedAt = time.Now()
+	if course.ID == 0 {
+		course.CreatedAt = course.UpdatedAt
+		err = storage.CreateCourse(ctx, course)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%w: %s", ErrCourseExists, course.Slug)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to create course: %w", err)
+		}
+	} else {
+		err = storage.UpdateCourseName(ctx, course)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update course: %w", err)
+		}
+	}
+	err = c.convertCourse(ctx, course)
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.finish(ctx, start)
+	if err != nil {
+		return nil, err
+	}
+	storage.Commit(ctx)
+	return res, nil
+}
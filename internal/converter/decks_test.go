@@ -0,0 +1,124 @@
+package converter
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/fs"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func zipDeck(t *testing.T, files map[string]string) *bytes.Reader {
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return bytes.NewReader(buf.Bytes())
+}
+
+func TestReadDeck(t *testing.T) {
+	r := zipDeck(t, map[string]string{
+		"biology/0_index.yaml":    "name: Biology\n",
+		"biology/1_cell.md":       "---\nname: Cell\nmodule: Biology\n---\nCells, see [[2]].",
+		"biology/2_dna.md":        "---\nname: DNA\nmodule: Biology\n---\nDNA.",
+		"biology/3_rna.md":        "---\nname: RNA\nmodule: Biology\ndraft: true\n---\nRNA, see [[9]].",
+		"biology/.DS_Store":       "junk",
+		"__MACOSX/biology/._1.md": "junk",
+		"biology/2_dna.en.md":     "---\nname: DNA\nmodule: Biology\n---\nDNA.",
+		"biology/assets/cell.png": "\x89PNG\r\n\x1a\ncell",
+		"biology/0_index.en.yaml": "name: Biology\n",
+	})
+	deck, err := ReadDeck(r, r.Size(), "u1-bio")
+	require.NoError(t, err)
+
+	names, err := cardFiles(deck, "courses/u1-bio")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1_cell.md", "2_dna.md", "3_rna.md"}, names)
+	_, err = fs.Stat(deck, "courses/u1-bio/.DS_Store")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	cards, err := ValidateDeck(deck, "u1-bio")
+	require.NoError(t, err)
+	assert.Equal(t, 2, cards)
+}
+
+func TestReadDeckAtRoot(t *testing.T) {
+	r := zipDeck(t, map[string]string{
+		"0_index.yaml": "name: Biology\n",
+		"1_cell.md":    "---\nname: Cell\nmodule: Biology\n---\nCells.",
+	})
+	deck, err := ReadDeck(r, r.Size(), "u1-bio")
+	require.NoError(t, err)
+	b, err := fs.ReadFile(deck, "courses/u1-bio/1_cell.md")
+	require.NoError(t, err)
+	assert.Contains(t, string(b), "Cells.")
+}
+
+func TestReadDeckErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+	}{
+		{"no index", map[string]string{"bio/1_cell.md": "Cells."}},
+		{"two indexes", map[string]string{"a/0_index.yaml": "name: A\n", "b/0_index.yaml": "name: B\n"}},
+		{"file outside of deck", map[string]string{"bio/0_index.yaml": "name: Bio\n", "other/1_cell.md": "Cells."}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := zipDeck(t, tt.files)
+			_, err := ReadDeck(r, r.Size(), "u1-bio")
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestValidateDeckErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+	}{
+		{"unknown link", map[string]string{
+			"0_index.yaml": "name: Bio\n",
+			"1_cell.md":    "---\nname: Cell\nmodule: Bio\n---\nSee [[7]].",
+		}},
+		{"not markdown", map[string]string{
+			"0_index.yaml": "name: Bio\n",
+			"notes.txt":    "notes",
+		}},
+		{"html asset", map[string]string{
+			"0_index.yaml":     "name: Bio\n",
+			"1_cell.md":        "---\nname: Cell\nmodule: Bio\n---\nCells.",
+			"assets/cell.html": "<script>alert(1)</script>",
+		}},
+		{"svg asset", map[string]string{
+			"0_index.yaml":    "name: Bio\n",
+			"1_cell.md":       "---\nname: Cell\nmodule: Bio\n---\n![cell](assets/cell.svg)",
+			"assets/cell.svg": `<svg xmlns="http://www.w3.org/2000/svg" onload="alert(1)"/>`,
+		}},
+		{"html in png", map[string]string{
+			"0_index.yaml":    "name: Bio\n",
+			"1_cell.md":       "---\nname: Cell\nmodule: Bio\n---\n![cell](assets/cell.png)",
+			"assets/cell.png": "<html><script>alert(1)</script></html>",
+		}},
+		{"no name", map[string]string{
+			"0_index.yaml": "title: Bio\n",
+			"1_cell.md":    "---\nname: Cell\nmodule: Bio\n---\nCells.",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := zipDeck(t, tt.files)
+			deck, err := ReadDeck(r, r.Size(), "u1-bio")
+			require.NoError(t, err)
+			_, err = ValidateDeck(deck, "u1-bio")
+			assert.Error(t, err)
+		})
+	}
+}
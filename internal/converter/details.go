@@ -0,0 +1,99 @@
+package converter
+
+import (
+	"bytes"
+	"regexp"
+
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/gomarkdown/markdown/parser"
+)
+
+// detailsTitle is the summary of a details block written without a title.
+const detailsTitle = "Подробнее"
+
+var (
+	detailsOpenRe  = regexp.MustCompile(`^:{3,}[ \t]*details(?:[ \t]+(.*?))?[ \t]*$`)
+	detailsCloseRe = regexp.MustCompile(`^:{3,}[ \t]*$`)
+	codeFenceRe    = regexp.MustCompile("^[ \t]*(```|~~~)")
+)
+
+// Details is a collapsible block:
+//
+//	:::details Full solution
+//	any markdown, including nested details blocks
+//	:::
+type Details struct {
+	ast.Container
+
+	// Unclosed is set when the block runs to the end of the card without a
+	// closing ":::" line.
+	Unclosed bool
+}
+
+// DetailsSummary is the first child of Details holding its title.
+type DetailsSummary struct {
+	ast.Container
+}
+
+func (d *Details) CanContain(node ast.Node) bool {
+	_, ok := node.(*ast.ListItem)
+	return !ok
+}
+
+func registerDetails(p *parser.Parser) {
+	p.Opts.ParserHook = detailsBlock(p, p.Opts.ParserHook)
+}
+
+func detailsBlock(p *parser.Parser, prev parser.BlockFunc) parser.BlockFunc {
+	return func(data []byte) (ast.Node, []byte, int) {
+		line, _, _ := bytes.Cut(data, []byte("\n"))
+		m := detailsOpenRe.FindSubmatch(line)
+		if m == nil {
+			if prev != nil {
+				return prev(data)
+			}
+			return nil, nil, 0
+		}
+
+		details := &Details{Unclosed: true}
+		title := m[1]
+		if len(title) == 0 {
+			title = []byte(detailsTitle)
+		}
+		summary := &DetailsSummary{}
+		p.Inline(summary, title)
+		ast.AppendChild(details, summary)
+
+		// Nested blocks are matched by depth, lines inside code fences are
+		// never treated as container markers.
+		start := min(len(line)+1, len(data))
+		depth := 1
+		var fence []byte
+		for pos := start; pos < len(data); {
+			end := len(data)
+			if i := bytes.IndexByte(data[pos:], '\n'); i >= 0 {
+				end = pos + i
+			}
+			line = data[pos:end]
+			next := min(end+1, len(data))
+			if f := codeFenceRe.FindSubmatch(line); f != nil {
+				switch {
+				case fence == nil:
+					fence = f[1]
+				case bytes.Equal(fence, f[1]):
+					fence = nil
+				}
+			} else if fence == nil && detailsOpenRe.Match(line) {
+				depth++
+			} else if fence == nil && detailsCloseRe.Match(line) {
+				depth--
+				if depth == 0 {
+					details.Unclosed = false
+					return details, data[start:pos], next
+				}
+			}
+			pos = next
+		}
+		return details, data[start:], len(data)
+	}
+}
@@ -0,0 +1,23 @@
+package converter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderDetails(t *testing.T) {
+	r := &renderer{hlighter: newHighlighter()}
+
+	src := "Intro\n\n:::details Full `code`\nSome **bold**\n\n```\n:::\n```\n\n:::details\nnested\n:::\n:::\n\nAfter\n"
+	b, err := r.render(newParser().Parse([]byte(src)))
+	require.NoError(t, err)
+	html := string(b)
+	assert.Contains(t, html, "<p>Intro</p>")
+	assert.Contains(t, html, "<details><summary>Full <code>code</code></summary><p>Some <strong>bold</strong></p>")
+	assert.Contains(t, html, "<details><summary>Подробнее</summary><p>nested</p>\n</details></details><p>After</p>")
+
+	_, err = r.render(newParser().Parse([]byte(":::details\nnot closed\n")))
+	assert.Error(t, err)
+}
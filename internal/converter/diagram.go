@@ -0,0 +1,289 @@
+package converter
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// diagramLangs are code fence languages rendered as diagrams instead of code.
+var diagramLangs = map[string]bool{"mermaid": true, "diagram": true}
+
+const (
+	diagramCharWidth  = 8
+	diagramNodeHeight = 40
+	diagramPadding    = 16
+	diagramMinWidth   = 60
+	diagramGapMain    = 60
+	diagramGapCross   = 30
+	diagramMargin     = 10
+)
+
+var (
+	diagramHeaderRe = regexp.MustCompile(`^(?:graph|flowchart)\s+(TD|TB|BT|LR|RL)$`)
+	diagramArrowRe  = regexp.MustCompile(`\s*(-->|---|-\.->|==>)\s*(?:\|([^|]*)\|)?\s*`)
+	diagramNodeRe   = regexp.MustCompile(`^([\p{L}\p{N}_]+)\s*(?:\[(.*)\]|\(\((.*)\)\)|\((.*)\)|\{(.*)\})?$`)
+)
+
+type diagramShape int
+
+const (
+	diagramShapeRect diagramShape = iota
+	diagramShapeRound
+	diagramShapeDiamond
+)
+
+type diagramNode struct {
+	id    string
+	label string
+	shape diagramShape
+	rank  int
+	order int
+	x, y  int
+	w, h  int
+}
+
+type diagramEdge struct {
+	from, to *diagramNode
+	arrow    string
+	label    string
+}
+
+type diagram struct {
+	horizontal bool
+	// reversed lays ranks out from the bottom or the right for BT and RL.
+	reversed bool
+	nodes    []*diagramNode
+	byID     map[string]*diagramNode
+	edges    []diagramEdge
+}
+
+// renderDiagram renders a mermaid flowchart subset to inline SVG.
+//
+// Supported: "graph TD|TB|BT|LR|RL" header, nodes A[rect], A(round), A{diamond},
+// edges -->, ---, -.->, ==> with optional |label|, chains and %% comments.
+func renderDiagram(w io.Writer, source string) error {
+	d, err := parseDiagram(source)
+	if err != nil {
+		return err
+	}
+	d.layout()
+	_, err = io.WriteString(w, d.svg())
+	return err
+}
+
+func parseDiagram(source string) (*diagram, error) {
+	d := &diagram{byID: make(map[string]*diagramNode)}
+	header := false
+	for n, line := range strings.Split(source, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "%%") {
+			continue
+		}
+		if !header {
+			m := diagramHeaderRe.FindStringSubmatch(line)
+			if m == nil {
+				return nil, fmt.Errorf("line %d: expected \"graph TD\" or \"graph LR\" header", n+1)
+			}
+			d.horizontal = m[1] == "LR" || m[1] == "RL"
+			d.reversed = m[1] == "BT" || m[1] == "RL"
+			header = true
+			continue
+		}
+		line = strings.TrimSuffix(line, ";")
+		arrows := diagramArrowRe.FindAllStringSubmatchIndex(line, -1)
+		var prev *diagramNode
+		start := 0
+		for i := 0; i <= len(arrows); i++ {
+			end := len(line)
+			if i < len(arrows) {
+				end = arrows[i][0]
+			}
+			node, err := d.node(line[start:end])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", n+1, err)
+			}
+			if prev != nil {
+				a := arrows[i-1]
+				edge := diagramEdge{from: prev, to: node, arrow: line[a[2]:a[3]]}
+				if a[4] >= 0 {
+					edge.label = strings.TrimSpace(line[a[4]:a[5]])
+				}
+				d.edges = append(d.edges, edge)
+			}
+			if i < len(arrows) {
+				start = arrows[i][1]
+			}
+			prev = node
+		}
+	}
+	if !header {
+		return nil, fmt.Errorf("empty diagram")
+	}
+	return d, nil
+}
+
+func (d *diagram) node(spec string) (*diagramNode, error) {
+	m := diagramNodeRe.FindStringSubmatch(strings.TrimSpace(spec))
+	if m == nil {
+		return nil, fmt.Errorf("invalid node %q", spec)
+	}
+	node, ok := d.byID[m[1]]
+	if !ok {
+		node = &diagramNode{id: m[1], label: m[1]}
+		d.byID[node.id] = node
+		d.nodes = append(d.nodes, node)
+	}
+	for i, shape := range []diagramShape{diagramShapeRect, diagramShapeRound, diagramShapeRound, diagramShapeDiamond} {
+		if label := strings.TrimSpace(m[i+2]); label != "" {
+			node.label = strings.Trim(label, `"`)
+			node.shape = shape
+		}
+	}
+	return node, nil
+}
+
+// layout ranks nodes by the longest path from the roots ignoring back edges,
+// then places every rank on its own row (or column for LR diagrams), the last
+// rank first for BT and RL diagrams.
+func (d *diagram) layout() {
+	back := d.backEdges()
+	for range d.nodes {
+		for i, e := range d.edges {
+			if !back[i] && e.to.rank < e.from.rank+1 {
+				e.to.rank = e.from.rank + 1
+			}
+		}
+	}
+	var ranks [][]*diagramNode
+	for _, node := range d.nodes {
+		for len(ranks) <= node.rank {
+			ranks = append(ranks, nil)
+		}
+		node.order = len(ranks[node.rank])
+		ranks[node.rank] = append(ranks[node.rank], node)
+		node.w = max(diagramMinWidth, utf8.RuneCountInString(node.label)*diagramCharWidth+2*diagramPadding)
+		node.h = diagramNodeHeight
+		if node.shape == diagramShapeDiamond {
+			node.w += node.w / 2
+			node.h += node.h / 2
+		}
+	}
+	if d.reversed {
+		slices.Reverse(ranks)
+	}
+	mainPos := diagramMargin
+	for _, rank := range ranks {
+		mainSize := 0
+		for _, node := range rank {
+			if d.horizontal {
+				mainSize = max(mainSize, node.w)
+			} else {
+				mainSize = max(mainSize, node.h)
+			}
+		}
+		crossPos := diagramMargin
+		for _, node := range rank {
+			if d.horizontal {
+				node.x, node.y = mainPos+(mainSize-node.w)/2, crossPos
+				crossPos += node.h + diagramGapCross
+			} else {
+				node.x, node.y = crossPos, mainPos+(mainSize-node.h)/2
+				crossPos += node.w + diagramGapCross
+			}
+		}
+		mainPos += mainSize + diagramGapMain
+	}
+}
+
+func (d *diagram) backEdges() map[int]bool {
+	back := make(map[int]bool)
+	state := make(map[*diagramNode]int)
+	var visit func(n *diagramNode)
+	visit = func(n *diagramNode) {
+		state[n] = 1
+		for i, e := range d.edges {
+			if e.from != n {
+				continue
+			}
+			switch state[e.to] {
+			case 0:
+				visit(e.to)
+			case 1:
+				back[i] = true
+			}
+		}
+		state[n] = 2
+	}
+	for _, n := range d.nodes {
+		if state[n] == 0 {
+			visit(n)
+		}
+	}
+	return back
+}
+
+func (d *diagram) svg() string {
+	width, height := 0, 0
+	for _, n := range d.nodes {
+		width = max(width, n.x+n.w+diagramMargin)
+		height = max(height, n.y+n.h+diagramMargin)
+	}
+	b := &strings.Builder{}
+	_, _ = fmt.Fprintf(b, `<svg class="diagram" xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" width="%d" height="%d" font-size="14" fill="none" stroke="currentColor">`, width, height, width, height)
+	b.WriteString(`<defs><marker id="diagram-arrow" viewBox="0 0 10 10" refX="10" refY="5" markerWidth="8" markerHeight="8" orient="auto-start-reverse"><path d="M0,0L10,5L0,10z" fill="currentColor" stroke="none"/></marker></defs>`)
+	for _, e := range d.edges {
+		x1, y1, x2, y2 := d.edgePoints(e)
+		attrs := ""
+		switch e.arrow {
+		case "-->":
+			attrs = ` marker-end="url(#diagram-arrow)"`
+		case "-.->":
+			attrs = ` marker-end="url(#diagram-arrow)" stroke-dasharray="5,4"`
+		case "==>":
+			attrs = ` marker-end="url(#diagram-arrow)" stroke-width="3"`
+		}
+		_, _ = fmt.Fprintf(b, `<line x1="%d" y1="%d" x2="%d" y2="%d"%s/>`, x1, y1, x2, y2, attrs)
+		if e.label != "" {
+			_, _ = fmt.Fprintf(b, `<text x="%d" y="%d" text-anchor="middle" fill="currentColor" stroke="none">%s</text>`, (x1+x2)/2, (y1+y2)/2-4, html.EscapeString(e.label))
+		}
+	}
+	for _, n := range d.nodes {
+		switch n.shape {
+		case diagramShapeRect:
+			_, _ = fmt.Fprintf(b, `<rect x="%d" y="%d" width="%d" height="%d"/>`, n.x, n.y, n.w, n.h)
+		case diagramShapeRound:
+			_, _ = fmt.Fprintf(b, `<rect x="%d" y="%d" width="%d" height="%d" rx="%d"/>`, n.x, n.y, n.w, n.h, n.h/2)
+		case diagramShapeDiamond:
+			_, _ = fmt.Fprintf(b, `<polygon points="%d,%d %d,%d %d,%d %d,%d"/>`, n.x+n.w/2, n.y, n.x+n.w, n.y+n.h/2, n.x+n.w/2, n.y+n.h, n.x, n.y+n.h/2)
+		}
+		_, _ = fmt.Fprintf(b, `<text x="%d" y="%d" text-anchor="middle" dominant-baseline="central" fill="currentColor" stroke="none">%s</text>`, n.x+n.w/2, n.y+n.h/2, html.EscapeString(n.label))
+	}
+	b.WriteString(`</svg>`)
+	return b.String()
+}
+
+// edgePoints connects the facing sides of two nodes.
+func (d *diagram) edgePoints(e diagramEdge) (x1, y1, x2, y2 int) {
+	from, to := e.from, e.to
+	// backward is an edge pointing to the left or up.
+	backward := (to.rank <= from.rank) != d.reversed
+	if d.horizontal {
+		x1, y1 = from.x+from.w, from.y+from.h/2
+		x2, y2 = to.x, to.y+to.h/2
+		if backward {
+			x1, x2 = from.x, to.x+to.w
+		}
+		return
+	}
+	x1, y1 = from.x+from.w/2, from.y+from.h
+	x2, y2 = to.x+to.w/2, to.y
+	if backward {
+		y1, y2 = from.y, to.y+to.h
+	}
+	return
+}
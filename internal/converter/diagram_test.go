@@ -0,0 +1,53 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderDiagram(t *testing.T) {
+	b := &strings.Builder{}
+	err := renderDiagram(b, `graph TD
+    %% a comment
+    Client[Клиент] --> LB{Балансировщик}
+    LB -->|HTTP| S1(Сервер 1)
+    LB -.-> S2(Сервер 2) --- DB[(db)]
+`)
+	require.NoError(t, err)
+	s := b.String()
+	assert.True(t, strings.HasPrefix(s, `<svg class="diagram"`))
+	assert.Contains(t, s, `>Клиент</text>`)
+	assert.Contains(t, s, `<polygon points=`)
+	assert.Contains(t, s, `>HTTP</text>`)
+	assert.Contains(t, s, `stroke-dasharray="5,4"`)
+	assert.Contains(t, s, `>(db)</text>`)
+
+	d, err := parseDiagram("graph LR\nA --> B --> C --> A")
+	require.NoError(t, err)
+	d.layout()
+	assert.Equal(t, []int{0, 1, 2}, []int{d.byID["A"].rank, d.byID["B"].rank, d.byID["C"].rank})
+	assert.Len(t, d.edges, 3)
+
+	d, err = parseDiagram("graph BT\nA --> B")
+	require.NoError(t, err)
+	d.layout()
+	assert.Greater(t, d.byID["A"].y, d.byID["B"].y, "BT starts at the bottom")
+	x1, y1, x2, y2 := d.edgePoints(d.edges[0])
+	assert.Equal(t, []int{d.byID["A"].y, d.byID["B"].y + d.byID["B"].h}, []int{y1, y2})
+	assert.Equal(t, x1, x2)
+
+	d, err = parseDiagram("graph RL\nA --> B")
+	require.NoError(t, err)
+	d.layout()
+	assert.Greater(t, d.byID["A"].x, d.byID["B"].x, "RL starts at the right")
+	x1, _, x2, _ = d.edgePoints(d.edges[0])
+	assert.Equal(t, []int{d.byID["A"].x, d.byID["B"].x + d.byID["B"].w}, []int{x1, x2})
+
+	err = renderDiagram(b, "sequenceDiagram\nA->>B: hi")
+	assert.Error(t, err)
+	err = renderDiagram(b, "graph TD\nA[ok] --> ???")
+	assert.Error(t, err)
+}
@@ -0,0 +1,78 @@
+package converter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/zagvozdeen/malicious-learning/internal/store"
+	"github.com/zagvozdeen/malicious-learning/internal/store/enum"
+	"gopkg.in/yaml.v3"
+)
+
+// ExportDrafts writes published drafts and course names changed in the app to
+// the courses directory on disk, usually data/courses, and returns the paths
+// it changed. Once the files are deployed the drafts land.
+func ExportDrafts(ctx context.Context, storage store.Storage, dir string) ([]string, error) {
+	drafts, err := storage.GetCardDrafts(ctx, enum.DraftStatusPublished)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get published drafts: %w", err)
+	}
+	var changed []string
+	for _, d := range drafts {
+		name := filepath.Join(dir, d.CourseSlug, d.FileName)
+		if !d.Content.Valid {
+			err = os.Remove(name)
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return nil, err
+			}
+			changed = append(changed, name)
+			continue
+		}
+		err = os.MkdirAll(filepath.Dir(name), 0o755)
+		if err != nil {
+			return nil, err
+		}
+		err = os.WriteFile(name, []byte(d.Content.V), 0o644)
+		if err != nil {
+			return nil, err
+		}
+		changed = append(changed, name)
+	}
+	courses, err := storage.GetCourses(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get courses: %w", err)
+	}
+	for _, course := range courses {
+		courseDir := filepath.Join(dir, course.Slug)
+		if _, err = os.Stat(courseDir); err != nil {
+			continue
+		}
+		name := filepath.Join(courseDir, "0_index.yaml")
+		cd := &CourseDescription{}
+		b, err := os.ReadFile(name)
+		if err == nil {
+			err = yaml.Unmarshal(b, cd)
+		}
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		if cd.Name == course.Name {
+			continue
+		}
+		cd.Name = course.Name
+		b, err = yaml.Marshal(cd)
+		if err != nil {
+			return nil, err
+		}
+		err = os.WriteFile(name, b, 0o644)
+		if err != nil {
+			return nil, err
+		}
+		changed = append(changed, name)
+	}
+	return changed, nil
+}
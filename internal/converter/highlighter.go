@@ -10,40 +10,62 @@ import (
 	"github.com/alecthomas/chroma/styles"
 )
 
-// styleName is the name of the style to use for highlighting.
+// Theme is a chroma style the code in answers can be shown with.
 //
 // See all styles: https://github.com/alecthomas/chroma/tree/master/styles.
-const styleName = "dracula"
+type Theme struct {
+	Name string `json:"name"`
+	Dark bool   `json:"dark"`
+}
+
+const (
+	// DarkTheme and LightTheme follow the Telegram color scheme when the user
+	// has not picked a theme.
+	DarkTheme  = "dracula"
+	LightTheme = "github"
+)
+
+var Themes = []Theme{
+	{Name: DarkTheme, Dark: true},
+	{Name: "monokai", Dark: true},
+	{Name: "nord", Dark: true},
+	{Name: "solarized-dark", Dark: true},
+	{Name: LightTheme, Dark: false},
+	{Name: "monokailight", Dark: false},
+	{Name: "solarized-light", Dark: false},
+	{Name: "xcode", Dark: false},
+}
 
 type highlighter struct {
 	formatter *html.Formatter
 	style     *chroma.Style
 }
 
-func newHighlighter(w io.Writer) (*highlighter, error) {
-	htmlFormatter := html.New(
+func newFormatter() *html.Formatter {
+	return html.New(
 		html.WithClasses(true),
 		html.WithAllClasses(true),
 		html.WithLineNumbers(true),
 		html.TabWidth(2),
 	)
-	highlightStyle := styles.Get(styleName)
-	_, err := fmt.Fprint(w, "<style>")
-	if err != nil {
-		return nil, err
-	}
-	err = htmlFormatter.WriteCSS(w, highlightStyle)
-	if err != nil {
-		return nil, err
+}
+
+func newHighlighter() *highlighter {
+	return &highlighter{
+		formatter: newFormatter(),
+		style:     styles.Get(DarkTheme),
 	}
-	_, err = fmt.Fprint(w, "</style>")
-	if err != nil {
-		return nil, err
+}
+
+// WriteThemeCSS writes the stylesheet of the theme. Highlighted code only has
+// chroma classes, so any theme applies to every stored answer.
+func WriteThemeCSS(w io.Writer, name string) error {
+	for _, theme := range Themes {
+		if theme.Name == name {
+			return newFormatter().WriteCSS(w, styles.Get(name))
+		}
 	}
-	return &highlighter{
-		formatter: htmlFormatter,
-		style:     highlightStyle,
-	}, nil
+	return fmt.Errorf("unknown theme %q", name)
 }
 
 func (h *highlighter) highlight(w io.Writer, source, lang string) error {
@@ -0,0 +1,194 @@
+package converter
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"io/fs"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/gomarkdown/markdown/parser"
+	"github.com/zagvozdeen/malicious-learning/internal/store/enum"
+)
+
+var wikiLinkRe = regexp.MustCompile(`^\[\[(?:([a-z0-9_-]+):)?([0-9]+)]]`)
+
+// WikiLink is a link to another card written as [[uid]] or [[course:uid]],
+// where uid is the number the card file name starts with.
+type WikiLink struct {
+	ast.Leaf
+
+	Course string
+	ID     int
+}
+
+// cardRef identifies a card file by its course and number.
+type cardRef struct {
+	course string
+	id     int
+}
+
+func (r cardRef) String() string {
+	return r.course + ":" + strconv.Itoa(r.id)
+}
+
+// cardTarget is what a link to the card file resolves to.
+type cardTarget struct {
+	name string
+	// uid is the uid of the card or, for cloze files, of the first
+	// cloze card.
+	uid int
+	// names are the localized names of the card.
+	names map[enum.Locale]string
+}
+
+func registerWikiLinks(p *parser.Parser) {
+	prev := p.RegisterInline('[', nil)
+	p.RegisterInline('[', wikiLinkInline(prev))
+}
+
+func wikiLinkInline(prev parser.InlineParser) parser.InlineParser {
+	return func(p *parser.Parser, original []byte, offset int) (int, ast.Node) {
+		m := wikiLinkRe.FindSubmatch(original[offset:])
+		if m == nil {
+			if prev != nil {
+				return prev(p, original, offset)
+			}
+			return 0, nil
+		}
+		id, err := strconv.Atoi(string(m[2]))
+		if err != nil {
+			return 0, nil
+		}
+		return len(m[0]), &WikiLink{Course: string(m[1]), ID: id}
+	}
+}
+
+// indexCards resolves every card file of every course to its link target, so
+// cards can link to cards converted later.
+func indexCards(src fs.FS) (map[cardRef]cardTarget, error) {
+	courses, err := fs.ReadDir(src, "courses")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read courses dir: %w", err)
+	}
+	index := make(map[cardRef]cardTarget)
+	names := make(map[cardRef]map[enum.Locale]string)
+	for _, course := range courses {
+		if !course.IsDir() {
+			continue
+		}
+		dirName := path.Join("courses", course.Name())
+		entries, err := fs.ReadDir(src, dirName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read dir %q: %w", dirName, err)
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || path.Ext(entry.Name()) != ".md" {
+				continue
+			}
+			id, err := cardID(entry.Name())
+			if err != nil {
+				return nil, err
+			}
+			cd, _, answer, err := readCard(src, path.Join(dirName, entry.Name()))
+			if err != nil {
+				return nil, err
+			}
+			if cd.Draft {
+				continue
+			}
+			ref := cardRef{course: course.Name(), id: id}
+			if _, locale, ok := localeOf(entry.Name()); ok {
+				if names[ref] == nil {
+					names[ref] = make(map[enum.Locale]string)
+				}
+				names[ref][locale] = cd.Name
+				continue
+			}
+			target := cardTarget{name: cd.Name, uid: id}
+			if groups := clozeGroups(newParser().Parse(answer)); len(groups) > 0 {
+				target.uid, err = clozeUID(id, groups[0])
+				if err != nil {
+					return nil, err
+				}
+			}
+			index[ref] = target
+		}
+	}
+	for ref, localized := range names {
+		if target, ok := index[ref]; ok {
+			target.names = localized
+			index[ref] = target
+		}
+	}
+	return index, nil
+}
+
+// cardLinkHref is the deep link of a card on the cards page. Uids repeat
+// across courses, the page shows only the cards of the course query.
+func cardLinkHref(course string, uid int) string {
+	return "/cards?course=" + course + "#u" + strconv.Itoa(uid)
+}
+
+func (r *renderer) renderWikiLink(w io.Writer, link *WikiLink) ast.WalkStatus {
+	ref := cardRef{course: link.Course, id: link.ID}
+	if ref.course == "" {
+		ref.course = r.course
+	}
+	target, ok := r.cards[ref]
+	if !ok {
+		r.err = fmt.Errorf("dangling link [[%s]]", ref)
+		return ast.Terminate
+	}
+	if r.links != nil {
+		r.links[ref] = target
+	}
+	name := target.name
+	if localized, ok := target.names[r.locale]; ok {
+		name = localized
+	}
+	_, _ = fmt.Fprintf(w, `<a class="card-link" href="%s">%s</a>`, cardLinkHref(ref.course, target.uid), html.EscapeString(name))
+	return ast.GoToNext
+}
+
+// checkLinks fails on the first wiki link to a card missing from the index,
+// it is how removing a card is checked without converting everything.
+func checkLinks(src fs.FS, cards map[cardRef]cardTarget) error {
+	return fs.WalkDir(src, "courses", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || path.Ext(name) != ".md" {
+			return err
+		}
+		course := strings.Split(name, "/")[1]
+		cd, question, answer, err := readCard(src, name)
+		if err != nil {
+			return err
+		}
+		if cd.Draft {
+			return nil
+		}
+		for _, b := range [][]byte{question, answer} {
+			if b == nil {
+				continue
+			}
+			ast.WalkFunc(newParser().Parse(b), func(node ast.Node, entering bool) ast.WalkStatus {
+				link, ok := node.(*WikiLink)
+				if !ok || !entering || err != nil {
+					return ast.GoToNext
+				}
+				ref := cardRef{course: link.Course, id: link.ID}
+				if ref.course == "" {
+					ref.course = course
+				}
+				if _, ok = cards[ref]; !ok {
+					err = fmt.Errorf("dangling link [[%s]] in %s", ref, name)
+				}
+				return ast.GoToNext
+			})
+		}
+		return err
+	})
+}
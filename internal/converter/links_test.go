@@ -0,0 +1,33 @@
+package converter
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderWikiLinks(t *testing.T) {
+	r := &renderer{
+		hlighter: newHighlighter(),
+		course:   "golang",
+		cards: map[cardRef]cardTarget{
+			{course: "golang", id: 3}: {name: "Что такое map?", uid: 3},
+			{course: "sql", id: 7}:    {name: "Что такое индекс?", uid: 7},
+		},
+		links: make(map[cardRef]cardTarget),
+	}
+
+	html, err := r.render(newParser().Parse([]byte("См. [[3]] и [[sql:7]], но не [[sql]].")))
+	require.NoError(t, err)
+	assert.Contains(t, string(html), `<a class="card-link" href="/cards?course=golang#u3">Что такое map?</a>`)
+	assert.Contains(t, string(html), `<a class="card-link" href="/cards?course=sql#u7">Что такое индекс?</a>`)
+	assert.Contains(t, string(html), `[[sql]]`)
+	assert.Len(t, r.links, 2)
+
+	_, err = r.render(newParser().Parse([]byte("См. [[golang:42]].")))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "dangling link [[golang:42]]")
+	assert.False(t, bytes.Contains(html, []byte("[[3]]")))
+}
@@ -0,0 +1,127 @@
+package converter
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/zagvozdeen/malicious-learning/internal/store"
+	"github.com/zagvozdeen/malicious-learning/internal/store/enum"
+	"gopkg.in/yaml.v3"
+)
+
+// localeOf splits the locale off a localized course file name, for example
+// 10_how_hash_table_works.en.md or 0_index.en.yaml. Files without a locale
+// are written in the default locale.
+func localeOf(name string) (base string, locale enum.Locale, ok bool) {
+	ext := path.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	suffix := path.Ext(stem)
+	if suffix == "" {
+		return name, enum.DefaultLocale, false
+	}
+	locale, err := enum.NewLocale(suffix[1:])
+	if err != nil || locale.IsDefault() {
+		return name, enum.DefaultLocale, false
+	}
+	return strings.TrimSuffix(stem, suffix) + ext, locale, true
+}
+
+func localizedName(name string, locale enum.Locale) string {
+	ext := path.Ext(name)
+	return strings.TrimSuffix(name, ext) + "." + locale.String() + ext
+}
+
+func exists(src fs.FS, name string) (bool, error) {
+	_, err := fs.Stat(src, name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// readCourseTranslations reads localized 0_index.yaml files of the course.
+func readCourseTranslations(src fs.FS, dirName string) ([]store.CourseTranslation, error) {
+	var translations []store.CourseTranslation
+	for _, locale := range enum.Locales {
+		if locale.IsDefault() {
+			continue
+		}
+		fileName := path.Join(dirName, localizedName("0_index.yaml", locale))
+		ok, err := exists(src, fileName)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		b, err := fs.ReadFile(src, fileName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", fileName, err)
+		}
+		cd := &CourseDescription{}
+		err = yaml.Unmarshal(b, cd)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal %s: %w", fileName, err)
+		}
+		if cd.Name == "" {
+			return nil, fmt.Errorf("course name is empty in %s", fileName)
+		}
+		translations = append(translations, store.CourseTranslation{Locale: locale, Name: cd.Name})
+	}
+	return translations, nil
+}
+
+// translateVariants renders localized files of the card and attaches them to
+// the variants rendered from the default file. A translation must have the
+// same cloze groups as the default file.
+func translateVariants(r *renderer, src fs.FS, variants []cardVariant, dirName, name string, id int, assets map[string]*store.Asset) error {
+	defer func() { r.locale = enum.DefaultLocale }()
+	for _, locale := range enum.Locales {
+		if locale.IsDefault() {
+			continue
+		}
+		fileName := path.Join(dirName, localizedName(name, locale))
+		ok, err := exists(src, fileName)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		cd, questionDoc, doc, err := parseCard(src, fileName, assets)
+		if err != nil {
+			return err
+		}
+		if cd.Draft {
+			// The card is published without the translation until it is
+			// reviewed.
+			continue
+		}
+		r.locale = locale
+		localized, err := renderVariants(r, questionDoc, doc, id)
+		if err != nil {
+			return fmt.Errorf("failed to render %s: %w", fileName, err)
+		}
+		if len(localized) != len(variants) {
+			return fmt.Errorf("translation %s has %d variants, expected %d", fileName, len(localized), len(variants))
+		}
+		for i := range variants {
+			if localized[i].uid != variants[i].uid {
+				return fmt.Errorf("translation %s has other cloze groups than %s", fileName, name)
+			}
+			variants[i].translations = append(variants[i].translations, store.CardTranslation{
+				Locale:       locale,
+				Question:     escapeQuestion(cd.Name),
+				QuestionBody: localized[i].questionBody,
+				Answer:       localized[i].answer,
+			})
+		}
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package converter
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zagvozdeen/malicious-learning/internal/store/enum"
+)
+
+func TestLocaleOf(t *testing.T) {
+	base, locale, ok := localeOf("10_how_hash_table_works.en.md")
+	assert.True(t, ok)
+	assert.Equal(t, "10_how_hash_table_works.md", base)
+	assert.Equal(t, enum.LocaleEn, locale)
+
+	base, _, ok = localeOf("0_index.en.yaml")
+	assert.True(t, ok)
+	assert.Equal(t, "0_index.yaml", base)
+
+	for _, name := range []string{"10_how_hash_table_works.md", "10_x.ru.md", "10_x.de.md", "0_index.yaml"} {
+		base, locale, ok = localeOf(name)
+		assert.False(t, ok, name)
+		assert.Equal(t, name, base)
+		assert.Equal(t, enum.DefaultLocale, locale)
+	}
+
+	assert.Equal(t, "10_how_hash_table_works.en.md", localizedName("10_how_hash_table_works.md", enum.LocaleEn))
+}
+
+func TestTranslateVariantsDraft(t *testing.T) {
+	src := fstest.MapFS{
+		"courses/go/1_maps.md":    {Data: []byte("---\nname: Мапы\nmodule: Go\n---\nХеш-таблица.")},
+		"courses/go/1_maps.en.md": {Data: []byte("---\nname: Maps\nmodule: Go\ndraft: true\n---\n")},
+	}
+	r := &renderer{hlighter: newHighlighter()}
+	_, variants, err := convertFile(r, src, "courses/go", "1_maps.md", 1, nil)
+	require.NoError(t, err)
+	require.Len(t, variants, 1)
+	assert.Empty(t, variants[0].translations)
+
+	deck := fstest.MapFS{
+		"courses/u1-go/0_index.yaml": {Data: []byte("name: Go\n")},
+		"courses/u1-go/1_maps.md":    src["courses/go/1_maps.md"],
+		"courses/u1-go/1_maps.en.md": src["courses/go/1_maps.en.md"],
+	}
+	cards, err := ValidateDeck(deck, "u1-go")
+	require.NoError(t, err)
+	assert.Equal(t, 1, cards)
+}
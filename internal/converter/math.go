@@ -0,0 +1,81 @@
+package converter
+
+import (
+	"bytes"
+
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/gomarkdown/markdown/parser"
+)
+
+// Math is an inline formula written as $...$.
+type Math struct {
+	ast.Leaf
+}
+
+// MathBlock is a display formula written as $$...$$ on its own lines.
+type MathBlock struct {
+	ast.Leaf
+}
+
+func registerMath(p *parser.Parser) {
+	prev := p.RegisterInline('$', nil)
+	p.RegisterInline('$', mathInline(prev))
+	p.Opts.ParserHook = mathBlock(p.Opts.ParserHook)
+}
+
+func mathInline(prev parser.InlineParser) parser.InlineParser {
+	return func(p *parser.Parser, original []byte, offset int) (int, ast.Node) {
+		data := original[offset:]
+		if len(data) < 3 || data[0] != '$' || data[1] == '$' || data[1] == ' ' {
+			if prev != nil {
+				return prev(p, original, offset)
+			}
+			return 0, nil
+		}
+
+		for i := 1; i < len(data); i++ {
+			if data[i] == '\n' {
+				return 0, nil
+			}
+			if data[i] == '\\' {
+				i++
+				continue
+			}
+			if data[i] == '$' {
+				if data[i-1] == ' ' {
+					return 0, nil
+				}
+				math := &Math{}
+				math.Literal = data[1:i]
+				return i + 1, math
+			}
+		}
+
+		return 0, nil
+	}
+}
+
+func mathBlock(prev parser.BlockFunc) parser.BlockFunc {
+	return func(data []byte) (ast.Node, []byte, int) {
+		if !bytes.HasPrefix(data, []byte("$$")) {
+			if prev != nil {
+				return prev(data)
+			}
+			return nil, nil, 0
+		}
+		end := bytes.Index(data[2:], []byte("$$"))
+		if end < 0 {
+			return nil, nil, 0
+		}
+		end += 2
+		consumed := end + 2
+		if i := bytes.IndexByte(data[consumed:], '\n'); i >= 0 {
+			consumed += i + 1
+		} else {
+			consumed = len(data)
+		}
+		math := &MathBlock{}
+		math.Literal = bytes.TrimSpace(data[2:end])
+		return math, nil, consumed
+	}
+}
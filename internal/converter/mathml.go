@@ -0,0 +1,455 @@
+package converter
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"unicode"
+)
+
+// texToMathML converts a subset of LaTeX math to MathML Core, so the browser
+// renders formulas natively without any JS library.
+func texToMathML(tex string, display bool) (string, error) {
+	p := &texParser{src: []rune(tex), display: display}
+	body, err := p.parseRow(func(t string) bool { return false })
+	if err != nil {
+		return "", fmt.Errorf("failed to convert %q: %w", tex, err)
+	}
+	if p.pos < len(p.src) {
+		return "", fmt.Errorf("failed to convert %q: unexpected %q", tex, string(p.src[p.pos:]))
+	}
+	if display {
+		return `<math display="block"><mrow>` + body + `</mrow></math>`, nil
+	}
+	return `<math><mrow>` + body + `</mrow></math>`, nil
+}
+
+var texGreek = map[string]string{
+	"alpha": "α", "beta": "β", "gamma": "γ", "delta": "δ", "epsilon": "ϵ", "varepsilon": "ε",
+	"zeta": "ζ", "eta": "η", "theta": "θ", "vartheta": "ϑ", "iota": "ι", "kappa": "κ",
+	"lambda": "λ", "mu": "μ", "nu": "ν", "xi": "ξ", "pi": "π", "rho": "ρ", "sigma": "σ",
+	"tau": "τ", "upsilon": "υ", "phi": "ϕ", "varphi": "φ", "chi": "χ", "psi": "ψ", "omega": "ω",
+	"Gamma": "Γ", "Delta": "Δ", "Theta": "Θ", "Lambda": "Λ", "Xi": "Ξ", "Pi": "Π",
+	"Sigma": "Σ", "Phi": "Φ", "Psi": "Ψ", "Omega": "Ω",
+	"ell": "ℓ", "infty": "∞", "partial": "∂", "nabla": "∇", "emptyset": "∅",
+}
+
+var texOperators = map[string]string{
+	"cdot": "⋅", "times": "×", "div": "÷", "pm": "±", "mp": "∓", "ast": "∗", "circ": "∘",
+	"le": "≤", "leq": "≤", "ge": "≥", "geq": "≥", "ne": "≠", "neq": "≠", "approx": "≈",
+	"sim": "∼", "equiv": "≡", "propto": "∝", "ll": "≪", "gg": "≫",
+	"in": "∈", "notin": "∉", "subset": "⊂", "subseteq": "⊆", "cup": "∪", "cap": "∩",
+	"forall": "∀", "exists": "∃", "neg": "¬", "land": "∧", "lor": "∨",
+	"to": "→", "rightarrow": "→", "leftarrow": "←", "Rightarrow": "⇒", "Leftarrow": "⇐",
+	"leftrightarrow": "↔", "Leftrightarrow": "⇔", "mapsto": "↦", "implies": "⟹",
+	"ldots": "…", "cdots": "⋯", "vdots": "⋮", "ddots": "⋱", "dots": "…",
+	"langle": "⟨", "rangle": "⟩", "lfloor": "⌊", "rfloor": "⌋", "lceil": "⌈", "rceil": "⌉",
+	"mid": "∣", "perp": "⊥", "top": "⊤",
+}
+
+// texLargeOperators have their limits placed under and over in display mode.
+var texLargeOperators = map[string]string{
+	"sum": "∑", "prod": "∏", "coprod": "∐", "int": "∫", "iint": "∬", "oint": "∮",
+	"bigcup": "⋃", "bigcap": "⋂",
+}
+
+var texFunctions = map[string]bool{
+	"sin": true, "cos": true, "tan": true, "cot": true, "arcsin": true, "arccos": true, "arctan": true,
+	"sinh": true, "cosh": true, "tanh": true, "log": true, "ln": true, "lg": true, "exp": true,
+	"max": true, "min": true, "sup": true, "inf": true, "lim": true, "det": true, "dim": true,
+	"arg": true, "argmax": true, "argmin": true, "sgn": true, "deg": true, "Pr": true,
+}
+
+var texAccents = map[string]string{
+	"hat": "^", "widehat": "^", "bar": "‾", "overline": "‾", "tilde": "~", "widetilde": "~",
+	"vec": "→", "dot": "˙", "ddot": "¨",
+}
+
+var texSpaces = map[string]string{
+	",": "0.1667em", ":": "0.2222em", ";": "0.2778em", "!": "-0.1667em",
+	"quad": "1em", "qquad": "2em", " ": "0.25em",
+}
+
+var texDoubleStruck = map[rune]rune{
+	'C': 'ℂ', 'H': 'ℍ', 'N': 'ℕ', 'P': 'ℙ', 'Q': 'ℚ', 'R': 'ℝ', 'Z': 'ℤ',
+}
+
+var texScript = map[rune]rune{
+	'B': 'ℬ', 'E': 'ℰ', 'F': 'ℱ', 'H': 'ℋ', 'I': 'ℐ', 'L': 'ℒ', 'M': 'ℳ', 'R': 'ℛ',
+}
+
+var texEnvironments = map[string][2]string{
+	"matrix":  {"", ""},
+	"pmatrix": {"(", ")"},
+	"bmatrix": {"[", "]"},
+	"vmatrix": {"|", "|"},
+	"cases":   {"{", ""},
+	"aligned": {"", ""},
+	"align":   {"", ""},
+}
+
+type texParser struct {
+	src     []rune
+	pos     int
+	display bool
+	// alphabet is the current \mathbb, \mathbf or \mathcal style of letters.
+	alphabet string
+}
+
+// next returns the next token: a command name with its backslash, or a rune.
+func (p *texParser) next() string {
+	p.skipSpaces()
+	if p.pos >= len(p.src) {
+		return ""
+	}
+	r := p.src[p.pos]
+	p.pos++
+	if r != '\\' {
+		return string(r)
+	}
+	if p.pos >= len(p.src) {
+		return "\\"
+	}
+	start := p.pos
+	if !unicode.IsLetter(p.src[p.pos]) {
+		p.pos++
+		return "\\" + string(p.src[start:p.pos])
+	}
+	for p.pos < len(p.src) && unicode.IsLetter(p.src[p.pos]) {
+		p.pos++
+	}
+	return "\\" + string(p.src[start:p.pos])
+}
+
+func (p *texParser) peek() string {
+	pos := p.pos
+	t := p.next()
+	p.pos = pos
+	return t
+}
+
+func (p *texParser) skipSpaces() {
+	for p.pos < len(p.src) && unicode.IsSpace(p.src[p.pos]) {
+		p.pos++
+	}
+}
+
+// parseRow parses atoms until stop reports true for the upcoming token.
+func (p *texParser) parseRow(stop func(t string) bool) (string, error) {
+	var b strings.Builder
+	for {
+		t := p.peek()
+		if t == "" || t == "}" || stop(t) {
+			return b.String(), nil
+		}
+		atom, err := p.parseScripts()
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(atom)
+	}
+}
+
+// parseScripts parses an atom followed by optional subscript and superscript.
+func (p *texParser) parseScripts() (string, error) {
+	t := p.peek()
+	_, large := texLargeOperators[strings.TrimPrefix(t, "\\")]
+	large = large || t == "\\lim" || t == "\\max" || t == "\\min" || t == "\\argmax" || t == "\\argmin"
+	base, err := p.parseAtom()
+	if err != nil {
+		return "", err
+	}
+	var sub, sup string
+	for {
+		switch p.peek() {
+		case "_":
+			p.next()
+			if sub, err = p.parseArg(); err != nil {
+				return "", err
+			}
+			continue
+		case "^":
+			p.next()
+			if sup, err = p.parseArg(); err != nil {
+				return "", err
+			}
+			continue
+		case "'":
+			p.next()
+			sup += "<mo>′</mo>"
+			continue
+		}
+		break
+	}
+	under, over, both := "msub", "msup", "msubsup"
+	if large && p.display {
+		under, over, both = "munder", "mover", "munderover"
+	}
+	switch {
+	case sub != "" && sup != "":
+		return fmt.Sprintf("<%s>%s<mrow>%s</mrow><mrow>%s</mrow></%s>", both, base, sub, sup, both), nil
+	case sub != "":
+		return fmt.Sprintf("<%s>%s<mrow>%s</mrow></%s>", under, base, sub, under), nil
+	case sup != "":
+		return fmt.Sprintf("<%s>%s<mrow>%s</mrow></%s>", over, base, sup, over), nil
+	}
+	return base, nil
+}
+
+// parseArg parses a command argument: a braced group or a single atom.
+func (p *texParser) parseArg() (string, error) {
+	if p.peek() != "{" {
+		return p.parseAtom()
+	}
+	p.next()
+	row, err := p.parseRow(func(string) bool { return false })
+	if err != nil {
+		return "", err
+	}
+	if p.next() != "}" {
+		return "", fmt.Errorf("missing closing brace")
+	}
+	return "<mrow>" + row + "</mrow>", nil
+}
+
+// parseRawArg returns the literal content of a braced argument.
+func (p *texParser) parseRawArg() (string, error) {
+	if p.next() != "{" {
+		return "", fmt.Errorf("expected opening brace at %d", p.pos)
+	}
+	start, depth := p.pos, 1
+	for ; p.pos < len(p.src); p.pos++ {
+		switch p.src[p.pos] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		}
+		if depth == 0 {
+			raw := string(p.src[start:p.pos])
+			p.pos++
+			return raw, nil
+		}
+	}
+	return "", fmt.Errorf("missing closing brace")
+}
+
+func (p *texParser) parseAtom() (string, error) {
+	t := p.next()
+	switch {
+	case t == "":
+		return "", fmt.Errorf("unexpected end of formula")
+	case t == "{":
+		p.pos--
+		return p.parseArg()
+	case t == "}" || t == "&":
+		return "", fmt.Errorf("unexpected %q", t)
+	case t == "_" || t == "^":
+		return "", fmt.Errorf("unexpected %q without base", t)
+	case len([]rune(t)) == 1:
+		return p.parseChar([]rune(t)[0]), nil
+	}
+	name := strings.TrimPrefix(t, "\\")
+	if s, ok := texGreek[name]; ok {
+		return mi(s), nil
+	}
+	if s, ok := texOperators[name]; ok {
+		return mo(s), nil
+	}
+	if s, ok := texLargeOperators[name]; ok {
+		return `<mo largeop="true">` + s + `</mo>`, nil
+	}
+	if texFunctions[name] {
+		return mi(name), nil
+	}
+	if w, ok := texSpaces[name]; ok {
+		return `<mspace width="` + w + `"></mspace>`, nil
+	}
+	if s, ok := texAccents[name]; ok {
+		arg, err := p.parseArg()
+		if err != nil {
+			return "", err
+		}
+		return `<mover accent="true"><mrow>` + arg + `</mrow>` + mo(s) + `</mover>`, nil
+	}
+	switch name {
+	case "{", "}", "|", "#", "%", "&", "$", "_":
+		return mo(name), nil
+	case "\\":
+		return "", fmt.Errorf("line breaks are only allowed inside environments")
+	case "frac", "dfrac", "tfrac":
+		num, err := p.parseArg()
+		if err != nil {
+			return "", err
+		}
+		den, err := p.parseArg()
+		if err != nil {
+			return "", err
+		}
+		return "<mfrac><mrow>" + num + "</mrow><mrow>" + den + "</mrow></mfrac>", nil
+	case "sqrt":
+		var index string
+		if p.peek() == "[" {
+			p.next()
+			row, err := p.parseRow(func(t string) bool { return t == "]" })
+			if err != nil {
+				return "", err
+			}
+			p.next()
+			index = row
+		}
+		arg, err := p.parseArg()
+		if err != nil {
+			return "", err
+		}
+		if index != "" {
+			return "<mroot><mrow>" + arg + "</mrow><mrow>" + index + "</mrow></mroot>", nil
+		}
+		return "<msqrt>" + arg + "</msqrt>", nil
+	case "text", "textrm", "mbox":
+		raw, err := p.parseRawArg()
+		if err != nil {
+			return "", err
+		}
+		return "<mtext>" + html.EscapeString(raw) + "</mtext>", nil
+	case "operatorname":
+		raw, err := p.parseRawArg()
+		if err != nil {
+			return "", err
+		}
+		return mi(strings.TrimSpace(raw)), nil
+	case "mathbb", "mathbf", "boldsymbol", "mathcal", "mathrm":
+		prev := p.alphabet
+		p.alphabet = name
+		arg, err := p.parseArg()
+		p.alphabet = prev
+		return arg, err
+	case "left", "right":
+		d := p.next()
+		if d == "." {
+			return "", nil
+		}
+		d = strings.TrimPrefix(d, "\\")
+		if s, ok := texOperators[d]; ok {
+			d = s
+		}
+		return `<mo fence="true" stretchy="true">` + html.EscapeString(d) + `</mo>`, nil
+	case "begin":
+		return p.parseEnvironment()
+	}
+	return "", fmt.Errorf("unknown command %q", t)
+}
+
+func (p *texParser) parseChar(r rune) string {
+	switch {
+	case unicode.IsDigit(r) || r == '.':
+		var b strings.Builder
+		b.WriteRune(p.styled(r))
+		for p.pos < len(p.src) && (unicode.IsDigit(p.src[p.pos]) || p.src[p.pos] == '.') {
+			b.WriteRune(p.styled(p.src[p.pos]))
+			p.pos++
+		}
+		return "<mn>" + b.String() + "</mn>"
+	case unicode.IsLetter(r):
+		if p.alphabet == "mathrm" {
+			return `<mi mathvariant="normal">` + string(r) + `</mi>`
+		}
+		return mi(string(p.styled(r)))
+	}
+	return mo(string(r))
+}
+
+// styled maps a letter or digit to the Unicode mathematical alphabet of the
+// current style, as MathML Core has no mathvariant other than normal.
+func (p *texParser) styled(r rune) rune {
+	switch p.alphabet {
+	case "mathbf", "boldsymbol":
+		switch {
+		case r >= 'A' && r <= 'Z':
+			return 0x1D400 + r - 'A'
+		case r >= 'a' && r <= 'z':
+			return 0x1D41A + r - 'a'
+		case r >= '0' && r <= '9':
+			return 0x1D7CE + r - '0'
+		}
+	case "mathbb":
+		if s, ok := texDoubleStruck[r]; ok {
+			return s
+		}
+		switch {
+		case r >= 'A' && r <= 'Z':
+			return 0x1D538 + r - 'A'
+		case r >= '0' && r <= '9':
+			return 0x1D7D8 + r - '0'
+		}
+	case "mathcal":
+		if s, ok := texScript[r]; ok {
+			return s
+		}
+		if r >= 'A' && r <= 'Z' {
+			return 0x1D49C + r - 'A'
+		}
+	}
+	return r
+}
+
+func (p *texParser) parseEnvironment() (string, error) {
+	name, err := p.parseRawArg()
+	if err != nil {
+		return "", err
+	}
+	fences, ok := texEnvironments[name]
+	if !ok {
+		return "", fmt.Errorf("unknown environment %q", name)
+	}
+	var rows []string
+	var cells []string
+	for {
+		cell, err := p.parseRow(func(t string) bool { return t == "&" || t == "\\\\" || t == "\\end" })
+		if err != nil {
+			return "", err
+		}
+		cells = append(cells, "<mtd>"+cell+"</mtd>")
+		switch p.next() {
+		case "&":
+			continue
+		case "\\\\":
+			rows = append(rows, "<mtr>"+strings.Join(cells, "")+"</mtr>")
+			cells = nil
+			continue
+		case "\\end":
+			end, err := p.parseRawArg()
+			if err != nil {
+				return "", err
+			}
+			if end != name {
+				return "", fmt.Errorf("environment %q closed by %q", name, end)
+			}
+			if len(cells) > 1 || cell != "" {
+				rows = append(rows, "<mtr>"+strings.Join(cells, "")+"</mtr>")
+			}
+			table := "<mtable>" + strings.Join(rows, "") + "</mtable>"
+			if fences[0] == "" && fences[1] == "" {
+				return table, nil
+			}
+			return "<mrow>" + fence(fences[0]) + table + fence(fences[1]) + "</mrow>", nil
+		default:
+			return "", fmt.Errorf("unterminated environment %q", name)
+		}
+	}
+}
+
+func fence(s string) string {
+	if s == "" {
+		return ""
+	}
+	return `<mo fence="true" stretchy="true">` + html.EscapeString(s) + `</mo>`
+}
+
+func mi(s string) string {
+	return "<mi>" + html.EscapeString(s) + "</mi>"
+}
+
+func mo(s string) string {
+	return "<mo>" + html.EscapeString(s) + "</mo>"
+}
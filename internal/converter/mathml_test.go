@@ -0,0 +1,42 @@
+package converter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTexToMathML(t *testing.T) {
+	s, err := texToMathML(`x^2`, false)
+	require.NoError(t, err)
+	assert.Equal(t, `<math><mrow><msup><mi>x</mi><mrow><mn>2</mn></mrow></msup></mrow></math>`, s)
+
+	s, err = texToMathML(`\frac{\partial L}{\partial w}`, false)
+	require.NoError(t, err)
+	assert.Equal(t, `<math><mrow><mfrac><mrow><mrow><mi>∂</mi><mi>L</mi></mrow></mrow><mrow><mrow><mi>∂</mi><mi>w</mi></mrow></mrow></mfrac></mrow></math>`, s)
+
+	s, err = texToMathML(`\sum_{i=1}^n x_i`, true)
+	require.NoError(t, err)
+	assert.Contains(t, s, `<math display="block">`)
+	assert.Contains(t, s, `<munderover><mo largeop="true">∑</mo>`)
+	assert.Contains(t, s, `<msub><mi>x</mi><mrow><mi>i</mi></mrow></msub>`)
+
+	s, err = texToMathML(`w \in \mathbb{R}^n`, false)
+	require.NoError(t, err)
+	assert.Contains(t, s, `<mi>ℝ</mi>`)
+
+	s, err = texToMathML(`\begin{pmatrix} a & b \\ c & d \end{pmatrix}`, false)
+	require.NoError(t, err)
+	assert.Contains(t, s, `<mtable><mtr><mtd><mi>a</mi></mtd><mtd><mi>b</mi></mtd></mtr><mtr><mtd><mi>c</mi></mtd><mtd><mi>d</mi></mtd></mtr></mtable>`)
+
+	s, err = texToMathML(`a < b`, false)
+	require.NoError(t, err)
+	assert.Contains(t, s, `<mo>&lt;</mo>`)
+
+	_, err = texToMathML(`\unknown{x}`, false)
+	assert.Error(t, err)
+
+	_, err = texToMathML(`\frac{1}{2`, false)
+	assert.Error(t, err)
+}
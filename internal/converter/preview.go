@@ -0,0 +1,68 @@
+package converter
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	"github.com/zagvozdeen/malicious-learning/internal/store"
+)
+
+// PreviewCard is a card as the conversion would create it from a draft.
+type PreviewCard struct {
+	UID          int      `json:"uid"`
+	Question     string   `json:"question"`
+	QuestionBody string   `json:"question_body"`
+	Answer       string   `json:"answer"`
+	Module       string   `json:"module"`
+	Tags         []string `json:"tags"`
+}
+
+// Preview renders the draft on top of course files and published drafts the
+// same way Run does, without storing anything. A draft removing the file has
+// no cards, but it still fails when other cards link to it.
+func Preview(ctx context.Context, storage store.Storage, d *store.CardDraft) ([]PreviewCard, error) {
+	src, _, err := loadOverlay(ctx, storage)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load published drafts: %w", err)
+	}
+	err = applyDraft(src, d)
+	if err != nil {
+		return nil, err
+	}
+	cards, err := indexCards(src)
+	if err != nil {
+		return nil, fmt.Errorf("failed to index cards: %w", err)
+	}
+	if !d.Content.Valid {
+		return []PreviewCard{}, checkLinks(src, cards)
+	}
+	// A translation is rendered with the file it translates.
+	name, _, _ := localeOf(d.FileName)
+	id, err := cardID(name)
+	if err != nil {
+		return nil, err
+	}
+	dirName := path.Join("courses", d.CourseSlug)
+	assets, err := readAssets(src, dirName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read assets: %w", err)
+	}
+	rndr := &renderer{hlighter: newHighlighter(), cards: cards, course: d.CourseSlug}
+	cd, variants, err := convertFile(rndr, src, dirName, name, id, assets)
+	if err != nil {
+		return nil, err
+	}
+	preview := make([]PreviewCard, 0, len(variants))
+	for _, v := range variants {
+		preview = append(preview, PreviewCard{
+			UID:          v.uid,
+			Question:     v.question,
+			QuestionBody: v.questionBody,
+			Answer:       v.answer,
+			Module:       cd.Module,
+			Tags:         cd.Tags,
+		})
+	}
+	return preview, nil
+}
@@ -0,0 +1,36 @@
+package converter
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+)
+
+var (
+	questionHeadingRe = regexp.MustCompile(`(?m)^##[ \t]+(?:Вопрос|Question)[ \t]*$`)
+	answerHeadingRe   = regexp.MustCompile(`(?m)^##[ \t]+(?:Ответ|Answer)[ \t]*$`)
+)
+
+// splitQuestion separates the optional question section from the answer. The
+// section starts with a "## Вопрос" heading at the top of the file and lasts
+// until the "## Ответ" heading:
+//
+//	## Вопрос
+//	Что выведет код?
+//	## Ответ
+//	Ничего.
+func splitQuestion(b []byte) (question []byte, answer []byte, err error) {
+	loc := questionHeadingRe.FindIndex(b)
+	if loc == nil {
+		return nil, b, nil
+	}
+	if len(bytes.TrimSpace(b[:loc[0]])) != 0 {
+		return nil, nil, errors.New("question section must be at the beginning of the card")
+	}
+	rest := b[loc[1]:]
+	end := answerHeadingRe.FindIndex(rest)
+	if end == nil {
+		return nil, nil, errors.New("question section must be followed by an answer heading")
+	}
+	return bytes.TrimSpace(rest[:end[0]]), bytes.TrimSpace(rest[end[1]:]), nil
+}
@@ -0,0 +1,129 @@
+package converter
+
+import (
+	"errors"
+	"io"
+
+	"github.com/gomarkdown/markdown"
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/gomarkdown/markdown/html"
+	"github.com/gomarkdown/markdown/parser"
+	"github.com/zagvozdeen/malicious-learning/internal/store/enum"
+)
+
+func newParser() *parser.Parser {
+	// Definition lists are off: they take ":::details" after a paragraph for
+	// a definition.
+	extensions := (parser.CommonExtensions | parser.AutoHeadingIDs | parser.NoEmptyLineBeforeBlock) &^ parser.DefinitionLists
+	p := parser.NewWithExtensions(extensions)
+	registerSpoiler(p)
+	registerMath(p)
+	registerCloze(p)
+	registerDetails(p)
+	registerWikiLinks(p)
+	return p
+}
+
+type renderer struct {
+	hlighter *highlighter
+	// course is the slug of the course of the rendered card, cards maps wiki
+	// links to cards and links collects the links of the rendered card.
+	course string
+	cards  map[cardRef]cardTarget
+	links  map[cardRef]cardTarget
+	// locale is the language of the rendered file, the zero value is the
+	// default locale.
+	locale enum.Locale
+	// cloze is the group hidden (or revealed) by the current render, 0 renders
+	// every cloze deletion as plain text.
+	cloze  int
+	reveal bool
+	err    error
+}
+
+func (r *renderer) render(doc ast.Node) ([]byte, error) {
+	r.err = nil
+	renderer := html.NewRenderer(html.RendererOptions{
+		Flags:          html.CommonFlags | html.HrefTargetBlank,
+		RenderNodeHook: r.renderNode,
+	})
+	xml := markdown.Render(doc, renderer)
+	return Sanitize(xml), r.err
+}
+
+func (r *renderer) renderNode(w io.Writer, node ast.Node, entering bool) (ast.WalkStatus, bool) {
+	if _, ok := node.(*ast.Table); ok {
+		if entering {
+			_, _ = io.WriteString(w, `<div class="table-wrapper"><table>`)
+		} else {
+			_, _ = io.WriteString(w, `</table></div>`)
+		}
+		return ast.GoToNext, true
+	}
+	if _, ok := node.(*Spoiler); ok {
+		if entering {
+			_, _ = io.WriteString(w, `<span class="spoiler">`)
+		} else {
+			_, _ = io.WriteString(w, `</span>`)
+		}
+		return ast.GoToNext, true
+	}
+	if details, ok := node.(*Details); ok {
+		if details.Unclosed {
+			r.err = errors.New("details block is not closed with \":::\"")
+			return ast.Terminate, true
+		}
+		if entering {
+			_, _ = io.WriteString(w, "<details>")
+		} else {
+			_, _ = io.WriteString(w, "</details>")
+		}
+		return ast.GoToNext, true
+	}
+	if _, ok := node.(*DetailsSummary); ok {
+		if entering {
+			_, _ = io.WriteString(w, "<summary>")
+		} else {
+			_, _ = io.WriteString(w, "</summary>")
+		}
+		return ast.GoToNext, true
+	}
+	if link, ok := node.(*WikiLink); ok {
+		return r.renderWikiLink(w, link), true
+	}
+	if cloze, ok := node.(*Cloze); ok {
+		return r.renderCloze(w, cloze, entering), true
+	}
+	if math, ok := node.(*Math); ok {
+		var mathml string
+		mathml, r.err = texToMathML(string(math.Literal), false)
+		if r.err != nil {
+			return ast.Terminate, true
+		}
+		_, _ = io.WriteString(w, mathml)
+		return ast.GoToNext, true
+	}
+	if math, ok := node.(*MathBlock); ok {
+		var mathml string
+		mathml, r.err = texToMathML(string(math.Literal), true)
+		if r.err != nil {
+			return ast.Terminate, true
+		}
+		_, _ = io.WriteString(w, mathml)
+		return ast.GoToNext, true
+	}
+	if code, ok := node.(*ast.CodeBlock); ok && diagramLangs[string(code.Info)] {
+		r.err = renderDiagram(w, string(code.Literal))
+		if r.err != nil {
+			return ast.Terminate, true
+		}
+		return ast.GoToNext, true
+	}
+	if code, ok := node.(*ast.CodeBlock); ok {
+		if err := r.hlighter.highlight(w, string(code.Literal), string(code.Info)); err != nil {
+			return ast.GoToNext, false
+		}
+		return ast.GoToNext, true
+	}
+	return ast.GoToNext, false
+}
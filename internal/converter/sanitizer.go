@@ -0,0 +1,99 @@
+package converter
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/microcosm-cc/bluemonday"
+	"golang.org/x/net/html"
+)
+
+var (
+	policy      = newPolicy()
+	plainPolicy = bluemonday.StrictPolicy()
+	// questionEscaper escapes the text of the card name, questions are shown
+	// as HTML element content, so quotes are kept as they are.
+	questionEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+)
+
+// newPolicy allows the markup the renderer produces: common markdown HTML,
+// chroma, spoiler, cloze and table wrapper classes, details blocks, MathML
+// formulas and SVG diagrams.
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	p.RequireNoFollowOnLinks(false)
+	p.AllowAttrs("target").Matching(regexp.MustCompile(`^_blank$`)).OnElements("a")
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^[a-zA-Z0-9 _-]*$`)).OnElements(
+		"a", "span", "div", "pre", "code", "svg", "details", "summary",
+	)
+	p.AllowAttrs("tabindex").Matching(regexp.MustCompile(`^-?[0-9]+$`)).OnElements("pre")
+	p.AllowNoAttrs().OnElements("details", "summary")
+
+	p.AllowNoAttrs().OnElements(
+		"math", "mi", "mn", "mo", "mrow", "mfrac", "msqrt", "mroot", "msub", "msup", "msubsup",
+		"munder", "mover", "munderover", "mtable", "mtr", "mtd", "mtext", "mspace",
+	)
+	p.AllowAttrs("display").Matching(regexp.MustCompile(`^(block|inline)$`)).OnElements("math")
+	p.AllowAttrs("xmlns").Matching(regexp.MustCompile(`^http://www\.w3\.org/(1998/Math/MathML|2000/svg)$`)).OnElements("math", "svg")
+	p.AllowAttrs("mathvariant", "stretchy", "fence", "largeop", "accent", "width").
+		Matching(regexp.MustCompile(`^[a-z0-9.-]+$`)).
+		OnElements("mi", "mo", "mover", "munder", "munderover", "mspace")
+
+	p.AllowNoAttrs().OnElements("svg", "defs", "marker", "path", "line", "rect", "polygon", "text")
+	p.AllowAttrs(
+		"viewbox", "width", "height", "font-size", "x", "y", "x1", "y1", "x2", "y2", "rx",
+		"refx", "refy", "markerwidth", "markerheight", "points", "d", "stroke-width", "stroke-dasharray",
+	).Matching(regexp.MustCompile(`^[0-9a-zA-Z ,.-]*$`)).OnElements("svg", "marker", "path", "line", "rect", "polygon", "text")
+	p.AllowAttrs("fill", "stroke", "orient", "text-anchor", "dominant-baseline").
+		Matching(regexp.MustCompile(`^[a-zA-Z-]+$`)).
+		OnElements("svg", "marker", "path", "line", "rect", "polygon", "text")
+	p.AllowAttrs("id").Matching(regexp.MustCompile(`^diagram-[a-z-]+$`)).OnElements("marker")
+	p.AllowAttrs("marker-end").Matching(regexp.MustCompile(`^url\(#diagram-[a-z-]+\)$`)).OnElements("line")
+	return p
+}
+
+// Sanitize strips everything but the allowed markup from rendered HTML. It is
+// applied to every render, so content written by users can be shown as is.
+// HTML with nothing to strip is returned unchanged: the policy writes entities
+// its own way, and a card must not get a new hash for that.
+func Sanitize(b []byte) []byte {
+	clean := policy.SanitizeBytes(b)
+	if sameHTML(b, clean) {
+		return b
+	}
+	return clean
+}
+
+// sameHTML reports whether a and b have the same tokens, they may differ only
+// in how characters are escaped.
+func sameHTML(a, b []byte) bool {
+	za, zb := html.NewTokenizer(bytes.NewReader(a)), html.NewTokenizer(bytes.NewReader(b))
+	for {
+		ta, tb := za.Next(), zb.Next()
+		if ta != tb {
+			return false
+		}
+		if ta == html.ErrorToken {
+			return za.Err() == io.EOF && zb.Err() == io.EOF
+		}
+		x, y := za.Token(), zb.Token()
+		if x.Data != y.Data || !slices.Equal(x.Attr, y.Attr) {
+			return false
+		}
+	}
+}
+
+// escapeQuestion turns the card name written in front-matter into the HTML
+// of the question, markup in names is shown as text.
+func escapeQuestion(name string) string {
+	return questionEscaper.Replace(name)
+}
+
+// PlainText strips all markup from rendered HTML and collapses whitespace, it
+// is the text cards are searched by.
+func PlainText(b []byte) string {
+	return strings.Join(strings.Fields(html.UnescapeString(plainPolicy.Sanitize(string(b)))), " ")
+}
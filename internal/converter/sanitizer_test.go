@@ -0,0 +1,89 @@
+package converter
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSanitizeMalicious(t *testing.T) {
+	r := &renderer{hlighter: newHighlighter()}
+
+	tests := []struct {
+		name string
+		src  string
+		deny []string
+	}{
+		{"script", "Hi <script>alert(1)</script>", []string{"<script", "alert(1)"}},
+		{"event handler", `<img src="x" onerror="alert(1)">`, []string{"onerror", "alert"}},
+		{"javascript link", "[click](javascript:alert(1))", []string{"javascript:"}},
+		{"raw javascript link", `<a href="javascript:alert(1)">x</a>`, []string{"javascript:"}},
+		{"iframe", `<iframe src="https://evil.example"></iframe>`, []string{"<iframe"}},
+		{"style", `<style>body{display:none}</style><p style="position:fixed">x</p>`, []string{"<style", "position:fixed"}},
+		{"svg script", `<svg><script>alert(1)</script><a href="javascript:alert(1)">x</a></svg>`, []string{"<script", "javascript:"}},
+		{"svg onload", `<svg onload="alert(1)"></svg>`, []string{"onload"}},
+		{"data image", `<img src="data:text/html;base64,PHNjcmlwdD5hbGVydCgxKTwvc2NyaXB0Pg==">`, []string{"data:"}},
+		{"class injection", `<span class="x" data-x="1" onclick="alert(1)">x</span>`, []string{"onclick", "data-x"}},
+		{"details handler", `<details ontoggle="alert(1)" open><summary>x</summary></details>`, []string{"ontoggle"}},
+		{"math href", `<math href="javascript:alert(1)"><mi>x</mi></math>`, []string{"javascript:"}},
+		{"marker url", `<svg><line marker-end="url(https://evil.example/x)"/></svg>`, []string{"evil.example"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := r.render(newParser().Parse([]byte(tt.src)))
+			require.NoError(t, err)
+			for _, deny := range tt.deny {
+				assert.NotContains(t, string(b), deny)
+			}
+		})
+	}
+}
+
+func TestSanitizeKeepsMarkup(t *testing.T) {
+	r := &renderer{hlighter: newHighlighter()}
+
+	src := "Text ||secret|| and $x^2$\n\n| a | b |\n|---|---|\n| 1 | 2 |\n\n```go\nfmt.Println(1)\n```\n\n:::details More\n[link](https://go.dev)\n:::\n\n```mermaid\ngraph TD\nA --> B\n```\n"
+	b, err := r.render(newParser().Parse([]byte(src)))
+	require.NoError(t, err)
+	html := string(b)
+	assert.Contains(t, html, `<span class="spoiler">secret</span>`)
+	assert.Contains(t, html, `<math>`)
+	assert.Contains(t, html, `<msup>`)
+	assert.Contains(t, html, `<div class="table-wrapper"><table>`)
+	assert.Contains(t, html, `<pre tabindex="0" class="chroma">`)
+	assert.Contains(t, html, `<span class="nx">fmt</span>`)
+	assert.Contains(t, html, `<details><summary>More</summary>`)
+	assert.Contains(t, html, `<a href="https://go.dev" target="_blank"`)
+	assert.Contains(t, html, `<svg class="diagram"`)
+	assert.Contains(t, html, `marker-end="url(#diagram-arrow)"`)
+	assert.Contains(t, html, `<marker id="diagram-arrow"`)
+}
+
+func TestSanitizeStable(t *testing.T) {
+	clean := []byte(`<p>&quot;Go&quot; &amp; 'C' &lt;3</p>` + "\n" + `<pre tabindex="0" class="chroma"><code><span class="s">&#34;a&#34;</span></code></pre>`)
+	assert.Equal(t, clean, Sanitize(clean), "clean HTML keeps its entities")
+
+	dirty := []byte(`<p>&quot;Go&quot;<script>alert(1)</script></p>`)
+	assert.Equal(t, `<p>&#34;Go&#34;</p>`, string(Sanitize(dirty)))
+}
+
+func TestPlainText(t *testing.T) {
+	assert.Equal(t, "Map is a hash table. a < b && c", PlainText([]byte("<p>Map is a <strong>hash</strong> table.</p>\n<pre><code>a &lt; b &amp;&amp; c</code></pre>")))
+}
+
+func TestEscapeQuestion(t *testing.T) {
+	src := fstest.MapFS{
+		"courses/go/1_xss.md":    {Data: []byte("---\nname: <img src=x onerror=alert(1)> & \"quotes\"\nmodule: Go\n---\nAnswer.")},
+		"courses/go/1_xss.en.md": {Data: []byte("---\nname: <script>alert(1)</script>\nmodule: Go\n---\nAnswer.")},
+	}
+	r := &renderer{hlighter: newHighlighter()}
+	cd, variants, err := convertFile(r, src, "courses/go", "1_xss.md", 1, nil)
+	require.NoError(t, err)
+	assert.Equal(t, `<img src=x onerror=alert(1)> & "quotes"`, cd.Name)
+	require.Len(t, variants, 1)
+	assert.Equal(t, `&lt;img src=x onerror=alert(1)&gt; &amp; "quotes"`, variants[0].question)
+	require.Len(t, variants[0].translations, 1)
+	assert.Equal(t, `&lt;script&gt;alert(1)&lt;/script&gt;`, variants[0].translations[0].Question)
+}
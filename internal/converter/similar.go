@@ -0,0 +1,150 @@
+package converter
+
+import (
+	"fmt"
+	"html"
+	"io/fs"
+	"path"
+
+	"github.com/zagvozdeen/malicious-learning/internal/similarity"
+	"github.com/zagvozdeen/malicious-learning/internal/store"
+)
+
+const (
+	// similarCards is the number of neighbours stored per card.
+	similarCards = 5
+	// minSimilarity drops neighbours sharing only a few words.
+	minSimilarity = 0.15
+	// duplicateSimilarity is the score cards of different courses are
+	// reported as likely duplicates from.
+	duplicateSimilarity = 0.7
+)
+
+// Duplicate is a pair of cards of different courses that are likely the same
+// question.
+type Duplicate struct {
+	Course        string
+	UID           int
+	Question      string
+	OtherCourse   string
+	OtherUID      int
+	OtherQuestion string
+	Score         float64
+}
+
+func (d Duplicate) String() string {
+	return fmt.Sprintf("%s:%d %q ~ %s:%d %q (%.2f)", d.Course, d.UID, d.Question, d.OtherCourse, d.OtherUID, d.OtherQuestion, d.Score)
+}
+
+// indexedCard is an active card as seen by the similarity index.
+type indexedCard struct {
+	courseID int
+	course   string
+	// file is the number of the card file, cloze cards of one file are not
+	// similar to each other but the same text.
+	file     int
+	uid      int
+	question string
+	text     string
+}
+
+func newIndexedCard(card *store.Card, course string, file int) indexedCard {
+	return indexedCard{
+		courseID: card.CourseID,
+		course:   course,
+		file:     file,
+		uid:      card.UID,
+		question: html.UnescapeString(card.Question),
+		text:     html.UnescapeString(card.Question) + "\n" + card.PlainAnswer + "\n" + fmt.Sprint(card.Tags),
+	}
+}
+
+// buildSimilarities finds the nearest neighbours of every card and the likely
+// duplicates across courses.
+func buildSimilarities(cards []indexedCard) ([]store.CardSimilarity, []Duplicate) {
+	texts := make([]string, len(cards))
+	for i := range cards {
+		texts[i] = cards[i].text
+	}
+	idx := similarity.New(texts)
+	var similarities []store.CardSimilarity
+	var duplicates []Duplicate
+	for i, card := range cards {
+		var found int
+		for _, m := range idx.Similar(i, similarCards+clozeMaxGroups, minSimilarity) {
+			other := cards[m.Index]
+			if other.courseID == card.courseID && other.file == card.file {
+				continue
+			}
+			if found < similarCards {
+				similarities = append(similarities, store.CardSimilarity{
+					CourseID:        card.courseID,
+					UID:             card.uid,
+					SimilarCourseID: other.courseID,
+					SimilarUID:      other.uid,
+					Score:           m.Score,
+				})
+				found++
+			}
+			if m.Score >= duplicateSimilarity && other.courseID != card.courseID && i < m.Index {
+				duplicates = append(duplicates, Duplicate{
+					Course:        card.course,
+					UID:           card.uid,
+					Question:      card.question,
+					OtherCourse:   other.course,
+					OtherUID:      other.uid,
+					OtherQuestion: other.question,
+					Score:         m.Score,
+				})
+			}
+		}
+	}
+	return similarities, duplicates
+}
+
+// FindDuplicates renders every course of src the way Run does, without
+// storing anything, and returns likely duplicate questions across courses.
+func FindDuplicates(src fs.FS) ([]Duplicate, error) {
+	targets, err := indexCards(src)
+	if err != nil {
+		return nil, fmt.Errorf("failed to index cards: %w", err)
+	}
+	courses, err := fs.ReadDir(src, "courses")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read courses dir: %w", err)
+	}
+	var cards []indexedCard
+	for i, course := range courses {
+		if !course.IsDir() {
+			return nil, fmt.Errorf("found file in courses dir: %s", course.Name())
+		}
+		dirName := path.Join("courses", course.Name())
+		names, err := cardFiles(src, dirName)
+		if err != nil {
+			return nil, err
+		}
+		assets, err := readAssets(src, dirName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read assets in %s: %w", dirName, err)
+		}
+		rndr := &renderer{hlighter: newHighlighter(), cards: targets, course: course.Name()}
+		for _, name := range names {
+			id, err := cardID(name)
+			if err != nil {
+				return nil, err
+			}
+			rndr.links = make(map[cardRef]cardTarget)
+			cd, variants, err := convertFile(rndr, src, dirName, name, id, assets)
+			if err != nil {
+				return nil, err
+			}
+			for _, variant := range variants {
+				card := &store.Card{UID: variant.uid, Question: variant.question, Tags: cd.Tags, CourseID: i + 1}
+				card.PlainAnswer = plainAnswer(variant)
+				cards = append(cards, newIndexedCard(card, course.Name(), id))
+			}
+		}
+	}
+	_, duplicates := buildSimilarities(cards)
+	return duplicates, nil
+}
@@ -0,0 +1,53 @@
+package converter
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildSimilarities(t *testing.T) {
+	first, err := clozeUID(2, 1)
+	require.NoError(t, err)
+	second, err := clozeUID(2, 2)
+	require.NoError(t, err)
+	cards := []indexedCard{
+		{courseID: 1, course: "algo", file: 1, uid: 1, question: "Хеш-таблица", text: "Как устроена хеш-таблица и разрешение коллизий цепочками"},
+		{courseID: 1, course: "algo", file: 2, uid: first, question: "Коллизии", text: "Разрешение коллизий в хеш-таблице открытой адресацией"},
+		{courseID: 1, course: "algo", file: 2, uid: second, question: "Коллизии", text: "Разрешение коллизий в хеш-таблице открытой адресацией"},
+		{courseID: 2, course: "go", file: 1, uid: 1, question: "Как устроена хеш-таблица?", text: "Как устроена хеш-таблица и разрешение коллизий цепочками"},
+		{courseID: 2, course: "go", file: 3, uid: 3, question: "Горутины", text: "Планировщик горутин и модель GMP"},
+	}
+	similarities, duplicates := buildSimilarities(cards)
+
+	for _, s := range similarities {
+		assert.False(t, s.CourseID == 1 && s.UID == cards[1].uid && s.SimilarUID == cards[2].uid, "cloze cards of one file are not similar")
+		assert.NotEqual(t, 3, s.UID, "unrelated card has no neighbours")
+	}
+	require.Len(t, duplicates, 1)
+	assert.Equal(t, "algo", duplicates[0].Course)
+	assert.Equal(t, "go", duplicates[0].OtherCourse)
+	assert.InDelta(t, 1, duplicates[0].Score, 0.001)
+}
+
+func TestFindDuplicates(t *testing.T) {
+	src := fstest.MapFS{
+		"courses/algo/0_index.yaml":  {Data: []byte("name: Algo\n")},
+		"courses/algo/1_hash.md":     {Data: []byte("---\nname: Хеш-таблица\nmodule: Algo\n---\nКак устроена хеш-таблица и разрешение коллизий цепочками.")},
+		"courses/algo/2_sort.md":     {Data: []byte("---\nname: Сортировка\nmodule: Algo\n---\nБыстрая сортировка выбирает опорный элемент.")},
+		"courses/go/0_index.yaml":    {Data: []byte("name: Go\n")},
+		"courses/go/1_map.md":        {Data: []byte("---\nname: Хеш-таблица\nmodule: Go\n---\nКак устроена хеш-таблица и разрешение коллизий цепочками.")},
+		"courses/go/2_goroutines.md": {Data: []byte("---\nname: Горутины\nmodule: Go\n---\nПланировщик горутин и модель GMP.")},
+		"courses/go/3_channels.md":   {Data: []byte("---\nname: Каналы\nmodule: Go\n---\nБуферизованные каналы блокируют запись при заполнении, см. [[1]].")},
+	}
+	duplicates, err := FindDuplicates(src)
+	require.NoError(t, err)
+	require.Len(t, duplicates, 1)
+	assert.Equal(t, "algo:1 \"Хеш-таблица\" ~ go:1 \"Хеш-таблица\" (1.00)", duplicates[0].String())
+
+	src["courses/go/3_channels.md"] = &fstest.MapFile{Data: []byte("---\nname: Каналы\nmodule: Go\n---\nСм. [[9]].")}
+	_, err = FindDuplicates(src)
+	assert.Error(t, err)
+}
@@ -0,0 +1,292 @@
+package converter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/zagvozdeen/malicious-learning/data"
+	"github.com/zagvozdeen/malicious-learning/internal/store"
+	"github.com/zagvozdeen/malicious-learning/internal/store/enum"
+	"gopkg.in/yaml.v3"
+)
+
+// overlay is the file system of course files with files written, replaced or
+// removed on top of it. A nil content removes the file.
+type overlay struct {
+	base  fs.FS
+	files map[string][]byte
+}
+
+func newOverlay(base fs.FS) *overlay {
+	return &overlay{base: base, files: make(map[string][]byte)}
+}
+
+func (o *overlay) Open(name string) (fs.File, error) {
+	if b, ok := o.files[name]; ok {
+		if b == nil {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+		}
+		return &memFile{Reader: bytes.NewReader(b), info: memInfo{name: path.Base(name), size: int64(len(b))}}, nil
+	}
+	return o.base.Open(name)
+}
+
+func (o *overlay) ReadFile(name string) ([]byte, error) {
+	if b, ok := o.files[name]; ok {
+		if b == nil {
+			return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrNotExist}
+		}
+		return slices.Clone(b), nil
+	}
+	return fs.ReadFile(o.base, name)
+}
+
+func (o *overlay) Stat(name string) (fs.FileInfo, error) {
+	if b, ok := o.files[name]; ok {
+		if b == nil {
+			return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+		}
+		return memInfo{name: path.Base(name), size: int64(len(b))}, nil
+	}
+	info, err := fs.Stat(o.base, name)
+	if err == nil {
+		return info, nil
+	}
+	for file, b := range o.files {
+		if b != nil && strings.HasPrefix(file, name+"/") {
+			return memInfo{name: path.Base(name), dir: true}, nil
+		}
+	}
+	return nil, err
+}
+
+func (o *overlay) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries, err := fs.ReadDir(o.base, name)
+	byName := make(map[string]fs.DirEntry, len(entries))
+	for _, entry := range entries {
+		byName[entry.Name()] = entry
+	}
+	found := err == nil
+	for file, b := range o.files {
+		rel, ok := strings.CutPrefix(file, name+"/")
+		if !ok {
+			continue
+		}
+		if b == nil {
+			if !strings.Contains(rel, "/") {
+				delete(byName, rel)
+			}
+			continue
+		}
+		found = true
+		if dir, _, nested := strings.Cut(rel, "/"); nested {
+			if _, ok = byName[dir]; !ok {
+				byName[dir] = fs.FileInfoToDirEntry(memInfo{name: dir, dir: true})
+			}
+			continue
+		}
+		byName[rel] = fs.FileInfoToDirEntry(memInfo{name: rel, size: int64(len(b))})
+	}
+	if !found {
+		return nil, err
+	}
+	entries = make([]fs.DirEntry, 0, len(byName))
+	for _, entry := range byName {
+		entries = append(entries, entry)
+	}
+	slices.SortFunc(entries, func(a, b fs.DirEntry) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+	return entries, nil
+}
+
+type memFile struct {
+	*bytes.Reader
+	info memInfo
+}
+
+func (f *memFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *memFile) Close() error               { return nil }
+
+type memInfo struct {
+	name string
+	size int64
+	dir  bool
+}
+
+func (i memInfo) Name() string       { return i.name }
+func (i memInfo) Size() int64        { return i.size }
+func (i memInfo) ModTime() time.Time { return time.Time{} }
+func (i memInfo) IsDir() bool        { return i.dir }
+func (i memInfo) Sys() any           { return nil }
+
+func (i memInfo) Mode() fs.FileMode {
+	if i.dir {
+		return fs.ModeDir | 0o555
+	}
+	return 0o444
+}
+
+// draftPath is the path of the draft file in the course files.
+func draftPath(d *store.CardDraft) string {
+	return path.Join("courses", d.CourseSlug, d.FileName)
+}
+
+// applyDraft writes the draft over the course files. A course created in the
+// app gets its 0_index.yaml from the database.
+func applyDraft(o *overlay, d *store.CardDraft) error {
+	if d.Content.Valid {
+		o.files[draftPath(d)] = []byte(d.Content.V)
+	} else {
+		o.files[draftPath(d)] = nil
+	}
+	index := path.Join("courses", d.CourseSlug, "0_index.yaml")
+	if ok, err := exists(o, index); err != nil || ok {
+		return err
+	}
+	b, err := yaml.Marshal(CourseDescription{Name: d.CourseName})
+	if err != nil {
+		return err
+	}
+	o.files[index] = b
+	return nil
+}
+
+// newSource returns the course files with published drafts applied. Drafts
+// found in data/courses as published are marked landed, from then on the
+// repository is their source.
+func newSource(ctx context.Context, storage store.Storage) (fs.FS, error) {
+	src, landed, err := loadOverlay(ctx, storage)
+	if err != nil {
+		return nil, err
+	}
+	for i := range landed {
+		landed[i].Status = enum.DraftStatusLanded
+		landed[i].UpdatedAt = time.Now()
+		err = storage.UpdateCardDraft(ctx, &landed[i])
+		if err != nil {
+			return nil, fmt.Errorf("failed to mark draft %s landed: %w", landed[i].UUID, err)
+		}
+	}
+	return src, nil
+}
+
+// loadOverlay applies published drafts that are not in data/courses yet and
+// returns the ones that are.
+func loadOverlay(ctx context.Context, storage store.Storage) (*overlay, []store.CardDraft, error) {
+	drafts, err := storage.GetCardDrafts(ctx, enum.DraftStatusPublished)
+	if err != nil {
+		return nil, nil, err
+	}
+	src := newOverlay(data.Courses)
+	var landed []store.CardDraft
+	for i := range drafts {
+		ok, err := isLanded(&drafts[i])
+		if err != nil {
+			return nil, nil, err
+		}
+		if ok {
+			landed = append(landed, drafts[i])
+			continue
+		}
+		err = applyDraft(src, &drafts[i])
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return src, landed, nil
+}
+
+func isLanded(d *store.CardDraft) (bool, error) {
+	b, err := fs.ReadFile(data.Courses, draftPath(d))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return !d.Content.Valid, nil
+		}
+		return false, err
+	}
+	return d.Content.Valid && d.Content.V == string(b), nil
+}
+
+// ReadSource returns the markdown of the course file as the next conversion
+// sees it, with published drafts applied.
+func ReadSource(ctx context.Context, storage store.Storage, courseSlug, fileName string) ([]byte, error) {
+	src, _, err := loadOverlay(ctx, storage)
+	if err != nil {
+		return nil, err
+	}
+	return fs.ReadFile(src, path.Join("courses", courseSlug, fileName))
+}
+
+// SourceFiles lists card files of the course with published drafts applied.
+func SourceFiles(ctx context.Context, storage store.Storage, courseSlug string) ([]string, error) {
+	src, _, err := loadOverlay(ctx, storage)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := fs.ReadDir(src, path.Join("courses", courseSlug))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return []string{}, nil
+		}
+		return nil, err
+	}
+	files := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() && path.Ext(entry.Name()) == ".md" {
+			files = append(files, entry.Name())
+		}
+	}
+	return files, nil
+}
+
+// NewFileName returns the name of a new card file after the given files. The
+// renamer gives it a slug later.
+func NewFileName(files []string) string {
+	next := 1
+	for _, name := range files {
+		if id, err := cardID(name); err == nil && id >= next {
+			next = id + 1
+		}
+	}
+	return fmt.Sprintf("%d_.md", next)
+}
+
+// InRepo reports whether the file in data/courses has the content.
+func InRepo(courseSlug, fileName, content string) bool {
+	b, err := fs.ReadFile(data.Courses, path.Join("courses", courseSlug, fileName))
+	return err == nil && string(b) == content
+}
+
+// RepoFile returns the name and the content of the data/courses file the card
+// with the uid is converted from, without published drafts. Edits made on it
+// apply to the repository as is.
+func RepoFile(courseSlug string, uid int) (string, []byte, error) {
+	dirName := path.Join("courses", courseSlug)
+	entries, err := fs.ReadDir(data.Courses, dirName)
+	if err != nil {
+		return "", nil, err
+	}
+	id := fileID(uid)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || path.Ext(name) != ".md" {
+			continue
+		}
+		if _, _, ok := localeOf(name); ok {
+			continue
+		}
+		if n, err := cardID(name); err == nil && n == id {
+			b, err := fs.ReadFile(data.Courses, path.Join(dirName, name))
+			return name, b, err
+		}
+	}
+	return "", nil, &fs.PathError{Op: "open", Path: fmt.Sprintf("%s/%d_*.md", dirName, id), Err: fs.ErrNotExist}
+}
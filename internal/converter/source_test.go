@@ -0,0 +1,61 @@
+package converter
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zagvozdeen/malicious-learning/internal/db/null"
+	"github.com/zagvozdeen/malicious-learning/internal/store"
+)
+
+func TestOverlay(t *testing.T) {
+	src := newOverlay(fstest.MapFS{
+		"courses/go/0_index.yaml": {Data: []byte("name: Go\n")},
+		"courses/go/1_maps.md":    {Data: []byte("---\nname: Maps\nmodule: Go\n---\nMaps, see [[2]].")},
+		"courses/go/2_slices.md":  {Data: []byte("---\nname: Slices\nmodule: Go\n---\nSlices.")},
+	})
+	require.NoError(t, applyDraft(src, &store.CardDraft{
+		CourseSlug: "go",
+		FileName:   "3_.md",
+		Content:    null.WrapString("---\nname: Channels\nmodule: Go\n---\nChannels, see [[go:1]]."),
+	}))
+	require.NoError(t, applyDraft(src, &store.CardDraft{
+		CourseSlug: "sql",
+		CourseName: "SQL",
+		FileName:   "1_.md",
+		Content:    null.WrapString("---\nname: Indexes\nmodule: SQL\n---\nIndexes, see [[go:3]]."),
+	}))
+
+	entries, err := fs.ReadDir(src, "courses")
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "sql", entries[1].Name())
+	assert.True(t, entries[1].IsDir())
+
+	b, err := fs.ReadFile(src, "courses/sql/0_index.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, "name: SQL\n", string(b))
+
+	cards, err := indexCards(src)
+	require.NoError(t, err)
+	assert.Len(t, cards, 4)
+	require.NoError(t, checkLinks(src, cards))
+
+	require.NoError(t, applyDraft(src, &store.CardDraft{CourseSlug: "go", FileName: "2_slices.md"}))
+	entries, err = fs.ReadDir(src, "courses/go")
+	require.NoError(t, err)
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	assert.Equal(t, []string{"0_index.yaml", "1_maps.md", "3_.md"}, names)
+
+	cards, err = indexCards(src)
+	require.NoError(t, err)
+	err = checkLinks(src, cards)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "dangling link [[go:2]] in courses/go/1_maps.md")
+}
@@ -0,0 +1,12 @@
+-- +goose up
+CREATE TABLE IF NOT EXISTS assets
+(
+    hash       TEXT         PRIMARY KEY,
+    name       VARCHAR(512) NOT NULL,
+    mime_type  VARCHAR(255) NOT NULL,
+    content    BYTEA        NOT NULL,
+    created_at TIMESTAMPTZ  NOT NULL
+);
+
+-- +goose down
+DROP TABLE IF EXISTS assets;
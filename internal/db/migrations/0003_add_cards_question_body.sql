@@ -0,0 +1,5 @@
+-- +goose up
+ALTER TABLE cards ADD COLUMN IF NOT EXISTS question_body TEXT NOT NULL DEFAULT '';
+
+-- +goose down
+ALTER TABLE cards DROP COLUMN IF EXISTS question_body;
@@ -0,0 +1,26 @@
+-- +goose up
+-- Answers were stored with the whole highlighter stylesheet appended, it is
+-- served by /api/code-themes now. The hash is recomputed the same way as
+-- store.Card.GetHash does, so the converter does not recreate every card.
+UPDATE cards
+SET answer = stripped.answer,
+    hash   = encode(sha256(
+        convert_to(cards.module_id::TEXT, 'UTF8') || '\x00'::BYTEA ||
+        convert_to(cards.course_id::TEXT, 'UTF8') || '\x00'::BYTEA ||
+        convert_to(cards.question, 'UTF8') || '\x00'::BYTEA ||
+        convert_to(stripped.answer, 'UTF8') || '\x00'::BYTEA ||
+        convert_to(array_to_string(cards.tags, ','), 'UTF8') ||
+        CASE
+            WHEN cards.question_body <> '' THEN '\x00'::BYTEA || convert_to(cards.question_body, 'UTF8')
+            ELSE ''::BYTEA
+        END
+    ), 'hex')
+FROM (
+    SELECT id, regexp_replace(answer, '<style>[^<]*</style>$', '') AS answer
+    FROM cards
+    WHERE answer LIKE '%</style>'
+) stripped
+WHERE stripped.id = cards.id;
+
+-- +goose down
+-- The stylesheet is not restored, the converter renders answers without it.
@@ -0,0 +1,50 @@
+-- +goose up
+-- Duplicates left by instances converting content at the same time are merged
+-- before the constraints are added.
+CREATE TEMP TABLE module_duplicates ON COMMIT DROP AS
+SELECT id, keep_id
+FROM (SELECT id, MIN(id) OVER (PARTITION BY name) AS keep_id FROM modules) m
+WHERE id <> keep_id;
+
+UPDATE cards c
+SET module_id = d.keep_id
+FROM module_duplicates d
+WHERE d.id = c.module_id;
+
+UPDATE test_sessions ts
+SET module_ids = ARRAY(
+    SELECT DISTINCT COALESCE(d.keep_id, m.id)
+    FROM unnest(ts.module_ids) AS m(id)
+    LEFT JOIN module_duplicates d ON d.id = m.id
+)
+WHERE ts.module_ids && ARRAY(SELECT id FROM module_duplicates);
+
+DELETE FROM modules m
+USING module_duplicates d
+WHERE d.id = m.id;
+
+UPDATE cards
+SET is_active = FALSE
+WHERE is_active = TRUE
+  AND id NOT IN (SELECT MAX(id) FROM cards WHERE is_active = TRUE GROUP BY course_id, uid);
+
+ALTER TABLE modules ADD CONSTRAINT modules_name_key UNIQUE (name);
+
+-- Only one version of a card is active, older versions stay for user answers.
+CREATE UNIQUE INDEX IF NOT EXISTS cards_course_id_uid_active_key ON cards (course_id, uid) WHERE is_active = TRUE;
+
+CREATE TABLE IF NOT EXISTS content_revisions
+(
+    id                SERIAL PRIMARY KEY,
+    hash              TEXT        NOT NULL,
+    duration_ms       BIGINT      NOT NULL,
+    cards_total       INTEGER     NOT NULL,
+    cards_created     INTEGER     NOT NULL,
+    cards_deactivated INTEGER     NOT NULL,
+    created_at        TIMESTAMPTZ NOT NULL
+);
+
+-- +goose down
+DROP TABLE IF EXISTS content_revisions;
+DROP INDEX IF EXISTS cards_course_id_uid_active_key;
+ALTER TABLE modules DROP CONSTRAINT IF EXISTS modules_name_key;
@@ -0,0 +1,20 @@
+-- +goose up
+CREATE TYPE change_kind AS ENUM ('added', 'changed', 'removed');
+
+CREATE TABLE IF NOT EXISTS changelog
+(
+    id                  SERIAL PRIMARY KEY,
+    content_revision_id INTEGER     NOT NULL REFERENCES content_revisions (id) ON DELETE CASCADE,
+    course_id           INTEGER     NOT NULL REFERENCES courses (id) ON DELETE CASCADE,
+    module_id           INTEGER     NOT NULL REFERENCES modules (id) ON DELETE RESTRICT,
+    card_uid            INTEGER     NOT NULL,
+    question            TEXT        NOT NULL,
+    kind                change_kind NOT NULL,
+    created_at          TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS changelog_course_id_idx ON changelog (course_id, id DESC);
+
+-- +goose down
+DROP TABLE IF EXISTS changelog;
+DROP TYPE IF EXISTS change_kind;
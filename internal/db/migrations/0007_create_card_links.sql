@@ -0,0 +1,15 @@
+-- +goose up
+-- Links point from card uids to card uids, so they survive new card versions.
+CREATE TABLE IF NOT EXISTS card_links
+(
+    course_id        INTEGER NOT NULL REFERENCES courses (id) ON DELETE CASCADE,
+    uid              INTEGER NOT NULL,
+    target_course_id INTEGER NOT NULL REFERENCES courses (id) ON DELETE CASCADE,
+    target_uid       INTEGER NOT NULL,
+    PRIMARY KEY (course_id, uid, target_course_id, target_uid)
+);
+
+CREATE INDEX IF NOT EXISTS card_links_target_idx ON card_links (target_course_id, target_uid);
+
+-- +goose down
+DROP TABLE IF EXISTS card_links;
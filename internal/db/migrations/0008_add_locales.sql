@@ -0,0 +1,34 @@
+-- +goose up
+CREATE TYPE locale AS ENUM ('ru', 'en');
+
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS language_code VARCHAR(35) NULL,
+    ADD COLUMN IF NOT EXISTS locale        locale      NULL;
+
+-- Translations belong to a card version, so answers, stats and test sessions
+-- keep referencing the same card whatever language it is shown in.
+CREATE TABLE IF NOT EXISTS card_translations
+(
+    card_id       INTEGER NOT NULL REFERENCES cards (id) ON DELETE CASCADE,
+    locale        locale  NOT NULL,
+    question      TEXT    NOT NULL,
+    question_body TEXT    NOT NULL,
+    answer        TEXT    NOT NULL,
+    PRIMARY KEY (card_id, locale)
+);
+
+CREATE TABLE IF NOT EXISTS course_translations
+(
+    course_id INTEGER      NOT NULL REFERENCES courses (id) ON DELETE CASCADE,
+    locale    locale       NOT NULL,
+    name      VARCHAR(255) NOT NULL,
+    PRIMARY KEY (course_id, locale)
+);
+
+-- +goose down
+DROP TABLE IF EXISTS course_translations;
+DROP TABLE IF EXISTS card_translations;
+ALTER TABLE users
+    DROP COLUMN IF EXISTS locale,
+    DROP COLUMN IF EXISTS language_code;
+DROP TYPE IF EXISTS locale;
@@ -0,0 +1,25 @@
+-- +goose up
+ALTER TABLE cards
+    ADD COLUMN IF NOT EXISTS plain_answer  TEXT     NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS search_vector TSVECTOR NULL;
+
+-- The converter fills both columns for new card versions, existing ones are
+-- backfilled with answers stripped of tags.
+UPDATE cards
+SET plain_answer = replace(replace(replace(replace(replace(
+        regexp_replace(answer, '<[^>]*>', ' ', 'g'),
+        '&lt;', '<'), '&gt;', '>'), '&quot;', '"'), '&#39;', ''''), '&amp;', '&');
+
+UPDATE cards
+SET search_vector =
+        setweight(to_tsvector('russian', question) || to_tsvector('english', question), 'A') ||
+        setweight(to_tsvector('simple', array_to_string(tags, ' ')), 'B') ||
+        setweight(to_tsvector('russian', plain_answer) || to_tsvector('english', plain_answer), 'C');
+
+CREATE INDEX IF NOT EXISTS cards_search_vector_idx ON cards USING GIN (search_vector) WHERE is_active;
+
+-- +goose down
+DROP INDEX IF EXISTS cards_search_vector_idx;
+ALTER TABLE cards
+    DROP COLUMN IF EXISTS search_vector,
+    DROP COLUMN IF EXISTS plain_answer;
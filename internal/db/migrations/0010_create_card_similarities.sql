@@ -0,0 +1,13 @@
+-- +goose up
+CREATE TABLE IF NOT EXISTS card_similarities
+(
+    course_id         INTEGER NOT NULL REFERENCES courses (id) ON DELETE CASCADE,
+    uid               INTEGER NOT NULL,
+    similar_course_id INTEGER NOT NULL REFERENCES courses (id) ON DELETE CASCADE,
+    similar_uid       INTEGER NOT NULL,
+    score             REAL    NOT NULL,
+    PRIMARY KEY (course_id, uid, similar_course_id, similar_uid)
+);
+
+-- +goose down
+DROP TABLE IF EXISTS card_similarities;
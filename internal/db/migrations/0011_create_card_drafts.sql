@@ -0,0 +1,33 @@
+-- +goose up
+CREATE TYPE user_role AS ENUM ('user', 'editor');
+
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS role user_role NOT NULL DEFAULT 'user';
+
+CREATE TYPE draft_status AS ENUM ('draft', 'published', 'landed', 'superseded', 'discarded');
+
+-- A draft is a whole card markdown file, content is NULL for a draft removing
+-- the file. Published drafts overlay data/courses until the same file lands
+-- in the repository.
+CREATE TABLE IF NOT EXISTS card_drafts
+(
+    id           SERIAL PRIMARY KEY,
+    uuid         UUID         NOT NULL UNIQUE,
+    course_id    INTEGER      NOT NULL REFERENCES courses (id) ON DELETE CASCADE,
+    file_name    VARCHAR(255) NOT NULL,
+    content      TEXT         NULL,
+    status       draft_status NOT NULL,
+    author_id    INTEGER      NOT NULL REFERENCES users (id) ON DELETE RESTRICT,
+    published_at TIMESTAMPTZ  NULL,
+    created_at   TIMESTAMPTZ  NOT NULL,
+    updated_at   TIMESTAMPTZ  NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS card_drafts_open_key ON card_drafts (course_id, file_name) WHERE status = 'draft';
+
+-- +goose down
+DROP TABLE IF EXISTS card_drafts;
+DROP TYPE IF EXISTS draft_status;
+ALTER TABLE users
+    DROP COLUMN IF EXISTS role;
+DROP TYPE IF EXISTS user_role;
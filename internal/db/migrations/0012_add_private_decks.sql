@@ -0,0 +1,20 @@
+-- +goose up
+-- Courses with an owner are private decks uploaded by users, they are seen by
+-- the owner and the members the deck is shared with.
+ALTER TABLE courses
+    ADD COLUMN IF NOT EXISTS owner_id INTEGER NULL REFERENCES users (id) ON DELETE RESTRICT;
+
+CREATE TABLE IF NOT EXISTS course_members
+(
+    course_id  INTEGER     NOT NULL REFERENCES courses (id) ON DELETE CASCADE,
+    user_id    INTEGER     NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (course_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS course_members_user_id_idx ON course_members (user_id);
+
+-- +goose down
+DROP TABLE IF EXISTS course_members;
+ALTER TABLE courses
+    DROP COLUMN IF EXISTS owner_id;
@@ -0,0 +1,29 @@
+-- +goose up
+CREATE TYPE suggestion_status AS ENUM ('pending', 'accepted', 'rejected');
+
+-- A suggestion is an edit of a card markdown file in data/courses proposed by
+-- a learner. Base is the file the edit was made on, accepted suggestions are
+-- exported as patches from base to content.
+CREATE TABLE IF NOT EXISTS card_suggestions
+(
+    id          SERIAL PRIMARY KEY,
+    uuid        UUID              NOT NULL UNIQUE,
+    card_id     INTEGER           NOT NULL REFERENCES cards (id) ON DELETE CASCADE,
+    course_id   INTEGER           NOT NULL REFERENCES courses (id) ON DELETE CASCADE,
+    file_name   VARCHAR(255)      NOT NULL,
+    base        TEXT              NOT NULL,
+    content     TEXT              NOT NULL,
+    comment     TEXT              NULL,
+    status      suggestion_status NOT NULL,
+    author_id   INTEGER           NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    reviewer_id INTEGER           NULL REFERENCES users (id) ON DELETE SET NULL,
+    reviewed_at TIMESTAMPTZ       NULL,
+    created_at  TIMESTAMPTZ       NOT NULL,
+    updated_at  TIMESTAMPTZ       NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS card_suggestions_status_idx ON card_suggestions (status, id);
+
+-- +goose down
+DROP TABLE IF EXISTS card_suggestions;
+DROP TYPE IF EXISTS suggestion_status;
@@ -0,0 +1,30 @@
+-- +goose up
+CREATE TYPE job_kind AS ENUM ('recommendations');
+CREATE TYPE job_status AS ENUM ('pending', 'running', 'done', 'dead');
+
+-- A job is background work retried with backoff until it is done or runs out
+-- of attempts and becomes dead. Key is the subject of the job, e.g. the test
+-- session id, one subject has at most one pending or running job per kind.
+CREATE TABLE IF NOT EXISTS jobs
+(
+    id           SERIAL PRIMARY KEY,
+    uuid         UUID         NOT NULL UNIQUE,
+    kind         job_kind     NOT NULL,
+    key          VARCHAR(255) NOT NULL,
+    status       job_status   NOT NULL,
+    attempts     INTEGER      NOT NULL DEFAULT 0,
+    max_attempts INTEGER      NOT NULL,
+    run_at       TIMESTAMPTZ  NOT NULL,
+    locked_at    TIMESTAMPTZ  NULL,
+    last_error   TEXT         NULL,
+    created_at   TIMESTAMPTZ  NOT NULL,
+    updated_at   TIMESTAMPTZ  NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS jobs_active_key ON jobs (kind, key) WHERE status IN ('pending', 'running');
+CREATE INDEX IF NOT EXISTS jobs_pending_idx ON jobs (run_at) WHERE status = 'pending';
+
+-- +goose down
+DROP TABLE IF EXISTS jobs;
+DROP TYPE IF EXISTS job_status;
+DROP TYPE IF EXISTS job_kind;
@@ -0,0 +1,19 @@
+-- +goose up
+-- Prompt templates a course overrides in its 0_index.yaml, version is derived
+-- from the template text.
+CREATE TABLE IF NOT EXISTS course_prompts
+(
+    course_id INTEGER      NOT NULL REFERENCES courses (id) ON DELETE CASCADE,
+    name      VARCHAR(255) NOT NULL,
+    version   VARCHAR(255) NOT NULL,
+    template  TEXT         NOT NULL,
+    PRIMARY KEY (course_id, name)
+);
+
+ALTER TABLE chat_completions
+    ADD COLUMN IF NOT EXISTS prompt_version VARCHAR(255) NULL;
+
+-- +goose down
+ALTER TABLE chat_completions
+    DROP COLUMN IF EXISTS prompt_version;
+DROP TABLE IF EXISTS course_prompts;
@@ -0,0 +1,34 @@
+-- +goose up
+ALTER TYPE user_role ADD VALUE IF NOT EXISTS 'admin';
+
+-- Every call of the language model is stored with the user it was made for,
+-- the feature and its cost by the configured prices, so daily token quotas
+-- and spend are computed from this table.
+ALTER TABLE chat_completions
+    ADD COLUMN IF NOT EXISTS user_id INTEGER NULL REFERENCES users (id) ON DELETE CASCADE,
+    ADD COLUMN IF NOT EXISTS feature VARCHAR(255) NOT NULL DEFAULT 'recommendations',
+    ADD COLUMN IF NOT EXISTS cost    NUMERIC(14, 6) NOT NULL DEFAULT 0,
+    ALTER COLUMN test_session_id DROP NOT NULL;
+
+UPDATE chat_completions cc
+SET user_id = ts.user_id
+FROM test_sessions ts
+WHERE ts.id = cc.test_session_id;
+
+ALTER TABLE chat_completions
+    ALTER COLUMN user_id SET NOT NULL,
+    ALTER COLUMN feature DROP DEFAULT;
+
+CREATE INDEX IF NOT EXISTS chat_completions_created_at_idx ON chat_completions (created_at);
+CREATE INDEX IF NOT EXISTS chat_completions_user_id_created_at_idx ON chat_completions (user_id, created_at);
+
+-- +goose down
+DROP INDEX IF EXISTS chat_completions_user_id_created_at_idx;
+DROP INDEX IF EXISTS chat_completions_created_at_idx;
+DELETE FROM chat_completions WHERE test_session_id IS NULL;
+ALTER TABLE chat_completions
+    DROP COLUMN IF EXISTS cost,
+    DROP COLUMN IF EXISTS feature,
+    DROP COLUMN IF EXISTS user_id,
+    ALTER COLUMN test_session_id SET NOT NULL;
+UPDATE users SET role = 'editor' WHERE role = 'admin';
@@ -0,0 +1,62 @@
+-- +goose up
+CREATE TYPE message_role AS ENUM ('user', 'assistant');
+CREATE TYPE chat_owner_type AS ENUM ('test_session', 'tutor_chat');
+
+-- A tutor chat is a conversation of a user with the language model about a
+-- card, optionally within a test session. The card question and answer are
+-- the context of the chat and are not stored as messages.
+CREATE TABLE IF NOT EXISTS tutor_chats
+(
+    id              SERIAL PRIMARY KEY,
+    uuid            UUID        NOT NULL UNIQUE,
+    user_id         INTEGER     NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    card_id         INTEGER     NOT NULL REFERENCES cards (id) ON DELETE CASCADE,
+    test_session_id INTEGER     NULL REFERENCES test_sessions (id) ON DELETE CASCADE,
+    created_at      TIMESTAMPTZ NOT NULL,
+    updated_at      TIMESTAMPTZ NOT NULL,
+    UNIQUE NULLS NOT DISTINCT (user_id, card_id, test_session_id)
+);
+
+CREATE TABLE IF NOT EXISTS tutor_messages
+(
+    id         SERIAL PRIMARY KEY,
+    chat_id    INTEGER      NOT NULL REFERENCES tutor_chats (id) ON DELETE CASCADE,
+    role       message_role NOT NULL,
+    content    TEXT         NOT NULL,
+    created_at TIMESTAMPTZ  NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS tutor_messages_chat_id_idx ON tutor_messages (chat_id, id);
+
+-- Chat completions belong to a test session or a tutor chat instead of only
+-- to a test session.
+ALTER TABLE chat_completions
+    ADD COLUMN IF NOT EXISTS owner_type chat_owner_type NULL,
+    ADD COLUMN IF NOT EXISTS owner_id   INTEGER NULL;
+
+UPDATE chat_completions
+SET owner_type = 'test_session',
+    owner_id   = test_session_id
+WHERE test_session_id IS NOT NULL;
+
+ALTER TABLE chat_completions
+    ALTER COLUMN owner_type SET NOT NULL,
+    ALTER COLUMN owner_id SET NOT NULL,
+    DROP COLUMN IF EXISTS test_session_id;
+
+CREATE INDEX IF NOT EXISTS chat_completions_owner_idx ON chat_completions (owner_type, owner_id);
+
+-- +goose down
+ALTER TABLE chat_completions
+    ADD COLUMN IF NOT EXISTS test_session_id INTEGER NULL REFERENCES test_sessions (id) ON DELETE CASCADE;
+UPDATE chat_completions
+SET test_session_id = owner_id
+WHERE owner_type = 'test_session';
+DROP INDEX IF EXISTS chat_completions_owner_idx;
+ALTER TABLE chat_completions
+    DROP COLUMN IF EXISTS owner_id,
+    DROP COLUMN IF EXISTS owner_type;
+DROP TABLE IF EXISTS tutor_messages;
+DROP TABLE IF EXISTS tutor_chats;
+DROP TYPE IF EXISTS chat_owner_type;
+DROP TYPE IF EXISTS message_role;
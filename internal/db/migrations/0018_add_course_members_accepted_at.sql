@@ -0,0 +1,10 @@
+-- +goose up
+-- A shared deck is seen by the member only after they accept the invite.
+-- Decks shared before invites existed are invites again, their members were
+-- added without asking.
+ALTER TABLE course_members
+    ADD COLUMN IF NOT EXISTS accepted_at TIMESTAMPTZ NULL;
+
+-- +goose down
+ALTER TABLE course_members
+    DROP COLUMN IF EXISTS accepted_at;
@@ -0,0 +1,14 @@
+-- +goose up
+-- The cmd tools call the language model too, their completions have no user
+-- and no owner row but count towards the daily quota of everyone.
+ALTER TYPE chat_owner_type ADD VALUE IF NOT EXISTS 'tool';
+
+ALTER TABLE chat_completions
+    ALTER COLUMN user_id DROP NOT NULL,
+    ALTER COLUMN owner_id DROP NOT NULL;
+
+-- +goose down
+DELETE FROM chat_completions WHERE user_id IS NULL OR owner_id IS NULL;
+ALTER TABLE chat_completions
+    ALTER COLUMN user_id SET NOT NULL,
+    ALTER COLUMN owner_id SET NOT NULL;
@@ -0,0 +1,98 @@
+-- +goose up
+-- Modules of a private deck belong to the deck, so decks never share modules
+-- with public courses or other decks. Modules of public courses have no
+-- course and stay shared by name.
+ALTER TABLE modules
+    ADD COLUMN IF NOT EXISTS course_id INTEGER NULL REFERENCES courses (id) ON DELETE RESTRICT,
+    DROP CONSTRAINT IF EXISTS modules_name_key,
+    ADD CONSTRAINT modules_name_course_id_key UNIQUE NULLS NOT DISTINCT (name, course_id);
+
+INSERT INTO modules (uuid, name, course_id, created_at, updated_at)
+SELECT DISTINCT ON (c.course_id, m.name) gen_random_uuid(), m.name, c.course_id, now(), now()
+FROM cards c
+JOIN courses co ON co.id = c.course_id
+JOIN modules m ON m.id = c.module_id
+WHERE co.owner_id IS NOT NULL AND m.course_id IS NULL
+ON CONFLICT ON CONSTRAINT modules_name_course_id_key DO NOTHING;
+
+UPDATE test_sessions ts
+SET module_ids = ARRAY(
+    SELECT coalesce(dm.id, m.id)
+    FROM unnest(ts.module_ids) WITH ORDINALITY AS m(id, n)
+    LEFT JOIN modules om ON om.id = m.id
+    LEFT JOIN modules dm ON dm.course_id = ts.course_id AND dm.name = om.name
+    ORDER BY m.n
+)
+FROM courses co
+WHERE co.id = ts.course_id AND co.owner_id IS NOT NULL;
+
+-- The hash is recomputed the same way as store.Card.GetHash does, so the next
+-- upload of the deck does not recreate every card.
+UPDATE cards
+SET module_id = scoped.module_id,
+    hash      = scoped.hash
+FROM (
+    SELECT c.id, dm.id AS module_id, encode(sha256(
+        convert_to(dm.id::TEXT, 'UTF8') || '\x00'::BYTEA ||
+        convert_to(c.course_id::TEXT, 'UTF8') || '\x00'::BYTEA ||
+        convert_to(c.question, 'UTF8') || '\x00'::BYTEA ||
+        convert_to(c.answer, 'UTF8') || '\x00'::BYTEA ||
+        convert_to(array_to_string(c.tags, ','), 'UTF8') ||
+        CASE
+            WHEN c.question_body <> '' THEN '\x00'::BYTEA || convert_to(c.question_body, 'UTF8')
+            ELSE ''::BYTEA
+        END ||
+        coalesce(
+            '\x00'::BYTEA || convert_to(t.locale::TEXT, 'UTF8') ||
+            '\x00'::BYTEA || convert_to(t.question, 'UTF8') ||
+            '\x00'::BYTEA || convert_to(t.question_body, 'UTF8') ||
+            '\x00'::BYTEA || convert_to(t.answer, 'UTF8'),
+            ''::BYTEA
+        )
+    ), 'hex') AS hash
+    FROM cards c
+    JOIN courses co ON co.id = c.course_id
+    JOIN modules m ON m.id = c.module_id
+    JOIN modules dm ON dm.course_id = c.course_id AND dm.name = m.name
+    LEFT JOIN card_translations t ON t.card_id = c.id AND t.locale = 'en'
+    WHERE co.owner_id IS NOT NULL AND m.course_id IS NULL
+) scoped
+WHERE scoped.id = cards.id;
+
+UPDATE changelog ce
+SET module_id = dm.id
+FROM modules m, modules dm, courses co
+WHERE m.id = ce.module_id AND m.course_id IS NULL
+  AND co.id = ce.course_id AND co.owner_id IS NOT NULL
+  AND dm.course_id = ce.course_id AND dm.name = m.name;
+
+-- +goose down
+-- Deck modules are merged back into the shared modules of the same name, the
+-- hashes are not restored and the next upload of a deck recreates its cards.
+INSERT INTO modules (uuid, name, created_at, updated_at)
+SELECT DISTINCT ON (name) gen_random_uuid(), name, now(), now()
+FROM modules
+WHERE course_id IS NOT NULL
+ON CONFLICT ON CONSTRAINT modules_name_course_id_key DO NOTHING;
+UPDATE cards c
+SET module_id = gm.id
+FROM modules dm, modules gm
+WHERE dm.id = c.module_id AND dm.course_id IS NOT NULL AND gm.course_id IS NULL AND gm.name = dm.name;
+UPDATE changelog ce
+SET module_id = gm.id
+FROM modules dm, modules gm
+WHERE dm.id = ce.module_id AND dm.course_id IS NOT NULL AND gm.course_id IS NULL AND gm.name = dm.name;
+UPDATE test_sessions ts
+SET module_ids = ARRAY(
+    SELECT coalesce(gm.id, m.id)
+    FROM unnest(ts.module_ids) WITH ORDINALITY AS m(id, n)
+    LEFT JOIN modules dm ON dm.id = m.id AND dm.course_id IS NOT NULL
+    LEFT JOIN modules gm ON gm.course_id IS NULL AND gm.name = dm.name
+    ORDER BY m.n
+)
+WHERE ts.module_ids && ARRAY(SELECT id FROM modules WHERE course_id IS NOT NULL);
+DELETE FROM modules WHERE course_id IS NOT NULL;
+ALTER TABLE modules
+    DROP CONSTRAINT IF EXISTS modules_name_course_id_key,
+    DROP COLUMN IF EXISTS course_id,
+    ADD CONSTRAINT modules_name_key UNIQUE (name);
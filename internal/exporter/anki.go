@@ -0,0 +1,238 @@
+package exporter
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha1"
+	"database/sql"
+	"encoding/binary"
+	"encoding/json/v2"
+	"fmt"
+	"hash/fnv"
+	"html"
+	"io"
+	"mime"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zagvozdeen/malicious-learning/internal/store"
+	_ "modernc.org/sqlite"
+)
+
+// ankiSchema is the collection schema of Anki 2.1 legacy packages, which every
+// Anki version can import.
+const ankiSchema = `
+CREATE TABLE col (id integer primary key, crt integer not null, mod integer not null, scm integer not null, ver integer not null, dty integer not null, usn integer not null, ls integer not null, conf text not null, models text not null, decks text not null, dconf text not null, tags text not null);
+CREATE TABLE notes (id integer primary key, guid text not null, mid integer not null, mod integer not null, usn integer not null, tags text not null, flds text not null, sfld text not null, csum integer not null, flags integer not null, data text not null);
+CREATE TABLE cards (id integer primary key, nid integer not null, did integer not null, ord integer not null, mod integer not null, usn integer not null, type integer not null, queue integer not null, due integer not null, ivl integer not null, factor integer not null, reps integer not null, lapses integer not null, left integer not null, odue integer not null, odid integer not null, flags integer not null, data text not null);
+CREATE TABLE revlog (id integer primary key, cid integer not null, usn integer not null, ivl integer not null, lastIvl integer not null, factor integer not null, time integer not null, type integer not null);
+CREATE TABLE graves (usn integer not null, oid integer not null, type integer not null);
+CREATE INDEX ix_notes_usn on notes (usn);
+CREATE INDEX ix_cards_usn on cards (usn);
+CREATE INDEX ix_revlog_usn on revlog (usn);
+CREATE INDEX ix_cards_nid on cards (nid);
+CREATE INDEX ix_cards_sched on cards (did, queue, due);
+CREATE INDEX ix_revlog_cid on revlog (cid);
+CREATE INDEX ix_notes_csum on notes (csum);
+`
+
+const ankiDeckConf = `{"1":{"id":1,"name":"Default","mod":0,"usn":0,"maxTaken":60,"autoplay":true,"timer":0,"replayq":true,"dyn":false,` +
+	`"new":{"delays":[1,10],"ints":[1,4,7],"initialFactor":2500,"order":1,"perDay":20,"bury":false},` +
+	`"lapse":{"delays":[10],"mult":0,"minInt":1,"leechFails":8,"leechAction":0},` +
+	`"rev":{"perDay":200,"ease4":1.3,"ivlFct":1,"maxIvl":36500,"bury":false,"hardFactor":1.2}}}`
+
+// ankiID derives a stable positive id, so a re-exported deck updates the
+// note type and decks imported before instead of duplicating them.
+func ankiID(parts ...string) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(strings.Join(parts, "\x00")))
+	return int64(h.Sum64() >> 11)
+}
+
+func ankiDeck(id int64, name string, now int64) map[string]any {
+	return map[string]any{
+		"id": id, "name": name, "mod": now, "usn": -1, "desc": "", "dyn": 0, "conf": 1,
+		"collapsed": false, "extendNew": 10, "extendRev": 50,
+		"newToday": []int{0, 0}, "revToday": []int{0, 0}, "lrnToday": []int{0, 0}, "timeToday": []int{0, 0},
+	}
+}
+
+func ankiModel(id, deckID int64, css string, now int64) map[string]any {
+	field := func(name string, ord int) map[string]any {
+		return map[string]any{"name": name, "ord": ord, "sticky": false, "rtl": false, "font": "Arial", "size": 20, "media": []string{}}
+	}
+	return map[string]any{
+		"id": id, "name": "Malicious Learning", "type": 0, "mod": now, "usn": -1, "sortf": 0, "did": deckID,
+		"tmpls": []map[string]any{{
+			"name": "Card 1", "ord": 0, "did": nil, "bqfmt": "", "bafmt": "",
+			"qfmt": "{{Front}}", "afmt": "{{FrontSide}}\n<hr id=answer>\n{{Back}}",
+		}},
+		"flds":      []map[string]any{field("Front", 0), field("Back", 1)},
+		"css":       ".card{font-family:sans-serif;text-align:left}\n" + css,
+		"latexPre":  "\\documentclass[12pt]{article}\n\\begin{document}\n",
+		"latexPost": "\\end{document}",
+		"tags":      []string{}, "vers": []string{}, "req": []any{[]any{0, "all", []int{0}}},
+	}
+}
+
+// ankiChecksum is the first 8 hex digits of sha1 of the sort field as Anki
+// uses it for duplicate detection.
+func ankiChecksum(s string) int64 {
+	sum := sha1.Sum([]byte(s))
+	return int64(binary.BigEndian.Uint32(sum[:4]))
+}
+
+// writeAnki writes an .apkg package: a zip with the collection.anki2 SQLite
+// database, a "media" JSON index and media files named by their index.
+func writeAnki(ctx context.Context, w io.Writer, c *course) error {
+	tmp, err := os.CreateTemp("", "export-*.anki2")
+	if err != nil {
+		return err
+	}
+	_ = tmp.Close()
+	defer os.Remove(tmp.Name())
+
+	media := make(map[string]string, len(c.assets))
+	names := make(map[string]string, len(c.assets))
+	for hash, asset := range c.assets {
+		ext := ""
+		if exts, _ := mime.ExtensionsByType(asset.MimeType); len(exts) > 0 {
+			ext = exts[0]
+		}
+		names[hash] = hash + ext
+		media[strconv.Itoa(len(media))] = hash
+	}
+
+	if err = writeAnkiCollection(ctx, tmp.Name(), c, names); err != nil {
+		return fmt.Errorf("failed to write collection: %w", err)
+	}
+
+	zw := zip.NewWriter(w)
+	if err = addZipFile(zw, "collection.anki2", tmp.Name()); err != nil {
+		return err
+	}
+	index := make(map[string]string, len(media))
+	for key, hash := range media {
+		index[key] = names[hash]
+		fw, err := zw.Create(key)
+		if err != nil {
+			return err
+		}
+		if _, err = fw.Write(c.assets[hash].Content); err != nil {
+			return err
+		}
+	}
+	fw, err := zw.Create("media")
+	if err != nil {
+		return err
+	}
+	if err = json.MarshalWrite(fw, index); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+func writeAnkiCollection(ctx context.Context, file string, c *course, names map[string]string) (err error) {
+	db, err := sql.Open("sqlite", "file:"+file)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := db.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+	if _, err = db.ExecContext(ctx, ankiSchema); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	mod := now.Unix()
+	modelID := ankiID("model", c.Slug)
+	rootID := ankiID("deck", c.Slug)
+	decks := map[string]any{
+		"1":                           ankiDeck(1, "Default", mod),
+		strconv.FormatInt(rootID, 10): ankiDeck(rootID, c.Name, mod),
+	}
+	deckIDs := make(map[int]int64)
+	for _, card := range c.cards {
+		if _, ok := deckIDs[card.ModuleID]; ok {
+			continue
+		}
+		id := ankiID("deck", c.Slug, card.ModuleName)
+		deckIDs[card.ModuleID] = id
+		decks[strconv.FormatInt(id, 10)] = ankiDeck(id, c.Name+"::"+card.ModuleName, mod)
+	}
+	models := map[string]any{strconv.FormatInt(modelID, 10): ankiModel(modelID, rootID, c.css, mod)}
+
+	modelsJSON, err := json.Marshal(models)
+	if err != nil {
+		return err
+	}
+	decksJSON, err := json.Marshal(decks)
+	if err != nil {
+		return err
+	}
+	conf := fmt.Sprintf(`{"nextPos":%d,"estTimes":true,"activeDecks":[1],"sortType":"noteFld","timeLim":0,"sortBackwards":false,"addToCur":true,"curDeck":1,"newSpread":0,"dueCounts":true,"curModel":"%d","collapseTime":1200}`, len(c.cards)+1, modelID)
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO col VALUES (1, ?, ?, ?, 11, 0, 0, 0, ?, ?, ?, ?, '{}')",
+		mod, mod*1000, mod*1000, conf, string(modelsJSON), string(decksJSON), ankiDeckConf,
+	)
+	if err != nil {
+		return err
+	}
+
+	mediaName := func(asset *store.Asset) string { return names[asset.Hash] }
+	base := now.UnixMilli()
+	for i, card := range c.cards {
+		front := "<h3>" + card.Question + "</h3>" + c.rewriteAssets(card.QuestionBody, mediaName)
+		back := c.rewriteAssets(card.Answer, mediaName)
+		// The sort field is plain text like Anki stores it.
+		sortField := html.UnescapeString(card.Question)
+		id := base + int64(i)
+		tags := ""
+		if len(card.Tags) > 0 {
+			tags = " " + strings.Join(card.Tags, " ") + " "
+		}
+		// The guid is built from the uid, so importing a newer export updates
+		// notes of the cards that have changed since.
+		guid := fmt.Sprintf("ml-%s-%d", c.Slug, card.UID)
+		_, err = tx.ExecContext(ctx,
+			"INSERT INTO notes VALUES (?, ?, ?, ?, -1, ?, ?, ?, ?, 0, '')",
+			id, guid, modelID, mod, tags, front+"\x1f"+back, sortField, ankiChecksum(sortField),
+		)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx,
+			"INSERT INTO cards VALUES (?, ?, ?, 0, ?, -1, 0, 0, ?, 0, 0, 0, 0, 0, 0, 0, 0, '')",
+			id, id, deckIDs[card.ModuleID], mod, i+1,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func addZipFile(zw *zip.Writer, name, file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fw, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(fw, f)
+	return err
+}
@@ -0,0 +1,77 @@
+package exporter
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+
+	"github.com/zagvozdeen/malicious-learning/internal/store"
+)
+
+const bookletCSS = `body{font-family:sans-serif;max-width:50rem;margin:0 auto;padding:1rem;line-height:1.5}
+article{break-inside:avoid;border-bottom:1px solid #ccc;padding:1rem 0}
+pre{white-space:pre-wrap;padding:.5rem;border-radius:.25rem}
+img,svg{max-width:100%}
+table{border-collapse:collapse}td,th{border:1px solid #ccc;padding:.25rem .5rem}
+.cloze-answer{font-weight:bold}
+@media print{h2{break-before:page}}`
+
+// markdownEscaper escapes ASCII punctuation, so plain text like card names is
+// shown as is and not read as markdown or inline HTML.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`, "<", `\<`, ">", `\>`,
+	"&", `\&`, "#", `\#`, "!", `\!`, "|", `\|`, "~", `\~`,
+)
+
+func escapeMarkdown(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+// dataURI embeds an asset into the booklet so it stays self-contained.
+func dataURI(asset *store.Asset) string {
+	return "data:" + asset.MimeType + ";base64," + base64.StdEncoding.EncodeToString(asset.Content)
+}
+
+func writeHTML(w io.Writer, c *course) error {
+	bw := bufio.NewWriter(w)
+	title := html.EscapeString(c.Name)
+	_, _ = fmt.Fprintf(bw, "<!DOCTYPE html>\n<html lang=\"ru\">\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n", title)
+	_, _ = fmt.Fprintf(bw, "<style>%s</style>\n<style>%s</style>\n</head>\n<body>\n<h1>%s</h1>\n", bookletCSS, c.css, title)
+	module := -1
+	for i, card := range c.cards {
+		if card.ModuleID != module {
+			module = card.ModuleID
+			_, _ = fmt.Fprintf(bw, "<h2>%s</h2>\n", html.EscapeString(card.ModuleName))
+		}
+		_, _ = fmt.Fprintf(bw, "<article>\n<h3>%d. %s</h3>\n", i+1, card.Question)
+		if card.QuestionBody != "" {
+			_, _ = fmt.Fprintf(bw, "%s\n<hr>\n", c.rewriteAssets(card.QuestionBody, dataURI))
+		}
+		_, _ = fmt.Fprintf(bw, "%s\n</article>\n", c.rewriteAssets(card.Answer, dataURI))
+	}
+	_, _ = io.WriteString(bw, "</body>\n</html>\n")
+	return bw.Flush()
+}
+
+// writeMarkdown writes the booklet as markdown with the rendered cards kept as
+// inline HTML, so any markdown viewer shows them with highlighting.
+func writeMarkdown(w io.Writer, c *course) error {
+	bw := bufio.NewWriter(w)
+	_, _ = fmt.Fprintf(bw, "# %s\n\n<style>%s</style>\n", escapeMarkdown(c.Name), c.css)
+	module := -1
+	for i, card := range c.cards {
+		if card.ModuleID != module {
+			module = card.ModuleID
+			_, _ = fmt.Fprintf(bw, "\n## %s\n", escapeMarkdown(card.ModuleName))
+		}
+		_, _ = fmt.Fprintf(bw, "\n### %d. %s\n\n", i+1, escapeMarkdown(html.UnescapeString(card.Question)))
+		if card.QuestionBody != "" {
+			_, _ = fmt.Fprintf(bw, "<div>%s</div>\n\n", c.rewriteAssets(card.QuestionBody, dataURI))
+		}
+		_, _ = fmt.Fprintf(bw, "<div>%s</div>\n", c.rewriteAssets(card.Answer, dataURI))
+	}
+	return bw.Flush()
+}
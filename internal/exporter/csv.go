@@ -0,0 +1,29 @@
+package exporter
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+)
+
+// writeCSV writes the columns read by cmd/import, so an exported table can be
+// imported back into another course. The question and answer are HTML like
+// the cards store them, the import turns them back into markdown.
+func writeCSV(w io.Writer, c *course) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"question", "answer", "module", "tags"}); err != nil {
+		return err
+	}
+	for _, card := range c.cards {
+		question := card.Question
+		if card.QuestionBody != "" {
+			question += "<br>" + card.QuestionBody
+		}
+		err := cw.Write([]string{question, card.Answer, card.ModuleName, strings.Join(card.Tags, " ")})
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,133 @@
+// Package exporter writes courses from the database to formats usable outside
+// the app: Anki packages, CSV tables and printable booklets.
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/zagvozdeen/malicious-learning/internal/converter"
+	"github.com/zagvozdeen/malicious-learning/internal/db/null"
+	"github.com/zagvozdeen/malicious-learning/internal/store"
+)
+
+type Format string
+
+const (
+	FormatAnki     Format = "apkg"
+	FormatCSV      Format = "csv"
+	FormatHTML     Format = "html"
+	FormatMarkdown Format = "md"
+)
+
+var formats = []Format{FormatAnki, FormatCSV, FormatHTML, FormatMarkdown}
+
+func ParseFormat(s string) (Format, error) {
+	if f := Format(s); slices.Contains(formats, f) {
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown export format %q", s)
+}
+
+func (f Format) ContentType() string {
+	switch f {
+	case FormatAnki:
+		return "application/zip"
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatHTML:
+		return "text/html; charset=utf-8"
+	default:
+		return "text/markdown; charset=utf-8"
+	}
+}
+
+func (f Format) Filename(slug string) string {
+	return slug + "." + string(f)
+}
+
+var assetRe = regexp.MustCompile(`/api/assets/([0-9a-f]{64})`)
+
+// course is a course with its cards and the code theme stylesheet.
+type course struct {
+	*store.Course
+
+	cards []store.FullCard
+	css   string
+	// assets are the assets referenced by the cards, keyed by hash.
+	assets map[string]*store.Asset
+}
+
+type Exporter struct {
+	store store.Storage
+}
+
+func New(storage store.Storage) *Exporter {
+	return &Exporter{store: storage}
+}
+
+// Export writes active cards of the course in the format. When forgottenBy is
+// set only cards forgotten by the user are exported.
+func (e *Exporter) Export(ctx context.Context, w io.Writer, format Format, courseSlug string, forgottenBy null.Int) error {
+	c, err := e.load(ctx, courseSlug, forgottenBy)
+	if err != nil {
+		return err
+	}
+	switch format {
+	case FormatAnki:
+		return writeAnki(ctx, w, c)
+	case FormatCSV:
+		return writeCSV(w, c)
+	case FormatHTML:
+		return writeHTML(w, c)
+	case FormatMarkdown:
+		return writeMarkdown(w, c)
+	default:
+		return fmt.Errorf("unknown export format %q", format)
+	}
+}
+
+func (e *Exporter) load(ctx context.Context, courseSlug string, forgottenBy null.Int) (*course, error) {
+	co, err := e.store.GetCourseBySlug(ctx, courseSlug)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get course %q: %w", courseSlug, err)
+	}
+	cards, err := e.store.GetCardsForExport(ctx, courseSlug, forgottenBy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cards: %w", err)
+	}
+	// Exports are printed or shown on a white background, so they get the
+	// light code theme.
+	css := &strings.Builder{}
+	if err = converter.WriteThemeCSS(css, converter.LightTheme); err != nil {
+		return nil, err
+	}
+	c := &course{Course: co, cards: cards, css: css.String(), assets: make(map[string]*store.Asset)}
+	for _, card := range c.cards {
+		for _, m := range assetRe.FindAllStringSubmatch(card.QuestionBody+card.Answer, -1) {
+			if _, ok := c.assets[m[1]]; ok {
+				continue
+			}
+			asset, err := e.store.GetAssetByHash(ctx, m[1])
+			if err != nil {
+				return nil, fmt.Errorf("failed to get asset %s: %w", m[1], err)
+			}
+			c.assets[m[1]] = asset
+		}
+	}
+	return c, nil
+}
+
+// rewriteAssets replaces asset URLs in s using fn called with the asset.
+func (c *course) rewriteAssets(s string, fn func(asset *store.Asset) string) string {
+	return assetRe.ReplaceAllStringFunc(s, func(m string) string {
+		if asset, ok := c.assets[assetRe.FindStringSubmatch(m)[1]]; ok {
+			return fn(asset)
+		}
+		return m
+	})
+}
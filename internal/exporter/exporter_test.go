@@ -0,0 +1,161 @@
+package exporter
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json/v2"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zagvozdeen/malicious-learning/internal/store"
+)
+
+const testAssetHash = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+func testCourse() *course {
+	asset := &store.Asset{Hash: testAssetHash, Name: "map.png", MimeType: "image/png", Content: []byte("\x89PNG\r\n\x1a\n")}
+	return &course{
+		Course: &store.Course{Slug: "go", Name: "Go *для* C# & <ru>"},
+		cards: []store.FullCard{
+			{
+				Card: store.Card{
+					UID:      1,
+					Question: "Что такое &lt;-chan &amp; chan&lt;-?",
+					Answer:   "<p>Каналы только для <code>чтения</code>.</p>",
+					Tags:     []string{"go", "channels"},
+					ModuleID: 1,
+				},
+				ModuleName: "Каналы_и_[горутины]",
+			},
+			{
+				Card: store.Card{
+					UID:          2,
+					Question:     "Как устроена map?",
+					QuestionBody: `<p><img src="/api/assets/` + testAssetHash + `" alt="map"></p>`,
+					Answer:       "<p>Хеш-таблица, &quot;бакеты&quot; по 8 ключей.</p>",
+					ModuleID:     2,
+				},
+				ModuleName: "Map",
+			},
+		},
+		css:    ".chroma{}",
+		assets: map[string]*store.Asset{testAssetHash: asset},
+	}
+}
+
+func TestWriteAnki(t *testing.T) {
+	c := testCourse()
+	buf := &bytes.Buffer{}
+	require.NoError(t, writeAnki(context.Background(), buf, c))
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	files := make(map[string][]byte)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		files[f.Name], err = io.ReadAll(rc)
+		require.NoError(t, err)
+		require.NoError(t, rc.Close())
+	}
+	var media map[string]string
+	require.NoError(t, json.Unmarshal(files["media"], &media))
+	assert.Equal(t, map[string]string{"0": testAssetHash + ".png"}, media)
+	assert.Equal(t, c.assets[testAssetHash].Content, files["0"])
+
+	file := filepath.Join(t.TempDir(), "collection.anki2")
+	require.NoError(t, os.WriteFile(file, files["collection.anki2"], 0o644))
+	db, err := sql.Open("sqlite", "file:"+file)
+	require.NoError(t, err)
+	defer db.Close()
+
+	var decksJSON string
+	require.NoError(t, db.QueryRow("SELECT decks FROM col").Scan(&decksJSON))
+	var decks map[string]struct {
+		Name string `json:"name"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(decksJSON), &decks))
+	var deckNames []string
+	for _, d := range decks {
+		deckNames = append(deckNames, d.Name)
+	}
+	assert.ElementsMatch(t, []string{"Default", c.Name, c.Name + "::Каналы_и_[горутины]", c.Name + "::Map"}, deckNames)
+
+	rows, err := db.Query("SELECT n.guid, n.tags, n.flds, n.sfld, n.csum, c.did FROM notes n JOIN cards c ON c.nid = n.id ORDER BY n.id")
+	require.NoError(t, err)
+	defer rows.Close()
+	type note struct {
+		guid, tags, flds, sfld string
+		csum, did              int64
+	}
+	var notes []note
+	for rows.Next() {
+		var n note
+		require.NoError(t, rows.Scan(&n.guid, &n.tags, &n.flds, &n.sfld, &n.csum, &n.did))
+		notes = append(notes, n)
+	}
+	require.NoError(t, rows.Err())
+	require.Len(t, notes, 2)
+
+	assert.Equal(t, "ml-go-1", notes[0].guid)
+	assert.Equal(t, " go channels ", notes[0].tags)
+	front, back, ok := strings.Cut(notes[0].flds, "\x1f")
+	require.True(t, ok)
+	assert.Equal(t, "<h3>Что такое &lt;-chan &amp; chan&lt;-?</h3>", front, "the question is HTML already")
+	assert.Equal(t, c.cards[0].Answer, back)
+	assert.Equal(t, "Что такое <-chan & chan<-?", notes[0].sfld)
+	assert.Equal(t, ankiChecksum(notes[0].sfld), notes[0].csum)
+	assert.Equal(t, ankiID("deck", "go", "Каналы_и_[горутины]"), notes[0].did)
+
+	assert.Equal(t, "ml-go-2", notes[1].guid)
+	assert.Contains(t, notes[1].flds, `<img src="`+testAssetHash+`.png" alt="map">`)
+	assert.NotContains(t, notes[1].flds, "/api/assets/")
+	assert.Equal(t, ankiID("deck", "go", "Map"), notes[1].did)
+}
+
+func TestWriteCSV(t *testing.T) {
+	c := testCourse()
+	buf := &bytes.Buffer{}
+	require.NoError(t, writeCSV(buf, c))
+
+	records, err := csv.NewReader(buf).ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"question", "answer", "module", "tags"},
+		{c.cards[0].Question, c.cards[0].Answer, "Каналы_и_[горутины]", "go channels"},
+		{c.cards[1].Question + "<br>" + c.cards[1].QuestionBody, c.cards[1].Answer, "Map", ""},
+	}, records)
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	c := testCourse()
+	buf := &bytes.Buffer{}
+	require.NoError(t, writeMarkdown(buf, c))
+	md := buf.String()
+
+	assert.True(t, strings.HasPrefix(md, "# Go \\*для\\* C\\# \\& \\<ru\\>\n"), md)
+	assert.Contains(t, md, "\n## Каналы\\_и\\_\\[горутины\\]\n")
+	assert.Contains(t, md, "\n### 1. Что такое \\<-chan \\& chan\\<-?\n")
+	assert.Contains(t, md, "\n## Map\n")
+	assert.Contains(t, md, `<div><p><img src="data:image/png;base64,iVBORw0KGgo=" alt="map"></p></div>`)
+}
+
+func TestWriteHTML(t *testing.T) {
+	c := testCourse()
+	buf := &bytes.Buffer{}
+	require.NoError(t, writeHTML(buf, c))
+	page := buf.String()
+
+	assert.Contains(t, page, "<title>Go *для* C# &amp; &lt;ru&gt;</title>")
+	assert.Contains(t, page, "<h2>Каналы_и_[горутины]</h2>")
+	assert.Contains(t, page, "<h3>1. Что такое &lt;-chan &amp; chan&lt;-?</h3>")
+	assert.Contains(t, page, `<img src="data:image/png;base64,iVBORw0KGgo=" alt="map">`)
+}
@@ -0,0 +1,75 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Fake is a deterministic provider for tests and local development, it
+// answers without any network and counts one token per word.
+type Fake struct {
+	// Reply returns the answer to the request, by default the answer counts
+	// the words of the last message.
+	Reply func(req Request) string
+	// Now returns the creation time of responses, by default time.Now.
+	Now func() time.Time
+}
+
+var _ Provider = (*Fake)(nil)
+
+func (f *Fake) Chat(ctx context.Context, req Request, onDelta func(delta string)) (*Response, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	content := f.reply(req)
+	if onDelta != nil {
+		for _, delta := range strings.SplitAfter(content, " ") {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			if delta != "" {
+				onDelta(delta)
+			}
+		}
+	}
+	var prompt int64
+	for _, msg := range req.Messages {
+		prompt += countTokens(msg.Content)
+	}
+	completion := countTokens(content)
+	now := time.Now
+	if f.Now != nil {
+		now = f.Now
+	}
+	model := req.Model
+	if model == "" {
+		model = "fake"
+	}
+	return &Response{
+		Model:   model,
+		Content: content,
+		Usage: Usage{
+			PromptTokens:     prompt,
+			CompletionTokens: completion,
+			TotalTokens:      prompt + completion,
+		},
+		Created: now().Unix(),
+	}, nil
+}
+
+func (f *Fake) reply(req Request) string {
+	if f.Reply != nil {
+		return f.Reply(req)
+	}
+	var last string
+	if len(req.Messages) > 0 {
+		last = req.Messages[len(req.Messages)-1].Content
+	}
+	return fmt.Sprintf("Fake answer of %d words.", countTokens(last))
+}
+
+func countTokens(s string) int64 {
+	return int64(len(strings.Fields(s)))
+}
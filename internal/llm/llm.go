@@ -0,0 +1,66 @@
+// Package llm talks to chat language models through a provider that may be
+// a real OpenAI-compatible endpoint or a local fake.
+package llm
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/zagvozdeen/malicious-learning/internal/config"
+)
+
+type Role string
+
+const (
+	RoleSystem    Role = "system"
+	RoleUser      Role = "user"
+	RoleAssistant Role = "assistant"
+)
+
+type Message struct {
+	Role    Role
+	Content string
+}
+
+type Request struct {
+	Model    string
+	Messages []Message
+}
+
+type Usage struct {
+	PromptTokens     int64
+	CompletionTokens int64
+	TotalTokens      int64
+}
+
+type Response struct {
+	// Model is the model that answered, it may differ from the requested one.
+	Model   string
+	Content string
+	Usage   Usage
+	// Created is the unix time the completion was created at.
+	Created int64
+}
+
+// Provider completes chats. When onDelta is not nil the answer is streamed and
+// onDelta is called with every new piece of the content as it arrives.
+type Provider interface {
+	Chat(ctx context.Context, req Request, onDelta func(delta string)) (*Response, error)
+}
+
+// New returns the provider selected by LLM_PROVIDER.
+func New(cfg *config.Config, log *slog.Logger) (Provider, error) {
+	switch cfg.LLMProvider {
+	case "", "openai":
+		var debug *slog.Logger
+		if cfg.NeuroDebug {
+			debug = log
+		}
+		return NewOpenAI(cfg.NeuroAPI, cfg.NeuroToken, debug), nil
+	case "fake":
+		return &Fake{}, nil
+	default:
+		return nil, fmt.Errorf("unknown llm provider %q", cfg.LLMProvider)
+	}
+}
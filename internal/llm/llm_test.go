@@ -0,0 +1,114 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFake(t *testing.T) {
+	f := &Fake{
+		Reply: func(req Request) string { return "one two three" },
+		Now:   func() time.Time { return time.Unix(100, 0) },
+	}
+	var deltas []string
+	res, err := f.Chat(context.Background(), Request{
+		Model:    "m",
+		Messages: []Message{{Role: RoleSystem, Content: "be brief"}, {Role: RoleUser, Content: "hi"}},
+	}, func(delta string) {
+		deltas = append(deltas, delta)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"one ", "two ", "three"}, deltas)
+	assert.Equal(t, &Response{
+		Model:   "m",
+		Content: "one two three",
+		Usage:   Usage{PromptTokens: 3, CompletionTokens: 3, TotalTokens: 6},
+		Created: 100,
+	}, res)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = f.Chat(ctx, Request{}, nil)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestOpenAI(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if !strings.Contains(readBody(t, r), `"stream":true`) {
+			fmt.Fprint(w, `{"id":"1","object":"chat.completion","created":7,"model":"m-1",`+
+				`"choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":"Hello world"}}],`+
+				`"usage":{"prompt_tokens":2,"completion_tokens":2,"total_tokens":4}}`)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, chunk := range []string{
+			`{"id":"1","object":"chat.completion.chunk","created":7,"model":"m-1","choices":[{"index":0,"delta":{"role":"assistant","content":"Hello"}}]}`,
+			`{"id":"1","object":"chat.completion.chunk","created":7,"model":"m-1","choices":[{"index":0,"delta":{"content":" world"},"finish_reason":"stop"}]}`,
+			`{"id":"1","object":"chat.completion.chunk","created":7,"model":"m-1","choices":[],"usage":{"prompt_tokens":2,"completion_tokens":2,"total_tokens":4}}`,
+		} {
+			fmt.Fprintf(w, "data: %s\n\n", chunk)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer srv.Close()
+
+	o := NewOpenAI(srv.URL, "token", nil)
+	req := Request{Model: "m", Messages: []Message{{Role: RoleUser, Content: "hi"}}}
+	want := &Response{
+		Model:   "m-1",
+		Content: "Hello world",
+		Usage:   Usage{PromptTokens: 2, CompletionTokens: 2, TotalTokens: 4},
+		Created: 7,
+	}
+
+	res, err := o.Chat(context.Background(), req, nil)
+	require.NoError(t, err)
+	assert.Equal(t, want, res)
+
+	var deltas []string
+	res, err = o.Chat(context.Background(), req, func(delta string) {
+		deltas = append(deltas, delta)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Hello", " world"}, deltas)
+	assert.Equal(t, want, res)
+}
+
+func readBody(t *testing.T, r *http.Request) string {
+	b, err := io.ReadAll(r.Body)
+	require.NoError(t, err)
+	return string(b)
+}
+
+func TestPrices(t *testing.T) {
+	prices, err := ParsePrices("gpt-5-mini=0.25/2, gpt-5=1.25/10")
+	require.NoError(t, err)
+	assert.Equal(t, Prices{
+		"gpt-5-mini": {Prompt: 0.25, Completion: 2},
+		"gpt-5":      {Prompt: 1.25, Completion: 10},
+	}, prices)
+
+	usage := Usage{PromptTokens: 2_000_000, CompletionTokens: 500_000}
+	assert.InDelta(t, 1.5, prices.Cost("gpt-5-mini", usage), 1e-9)
+	assert.InDelta(t, 1.5, prices.Cost("gpt-5-mini-2025-08-07", usage), 1e-9)
+	assert.InDelta(t, 7.5, prices.Cost("gpt-5-2025-08-07", usage), 1e-9)
+	assert.Zero(t, prices.Cost("claude", usage))
+
+	prices, err = ParsePrices("")
+	require.NoError(t, err)
+	assert.Empty(t, prices)
+	for _, s := range []string{"gpt-5", "gpt-5=1", "gpt-5=a/1", "gpt-5=1/b"} {
+		_, err = ParsePrices(s)
+		assert.Error(t, err, s)
+	}
+}
@@ -0,0 +1,109 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/zagvozdeen/malicious-learning/internal/config"
+	"github.com/zagvozdeen/malicious-learning/internal/db/null"
+	"github.com/zagvozdeen/malicious-learning/internal/store"
+)
+
+var ErrQuotaExceeded = errors.New("llm daily quota exceeded")
+
+// UsageStore stores chat completions, the daily quotas are counted from them.
+type UsageStore interface {
+	CreateChatCompletions(ctx context.Context, cc *store.ChatCompletions) error
+	GetLLMTotals(ctx context.Context, since time.Time, userID null.Int) (store.LLMTotals, error)
+}
+
+// Metered calls the provider within the daily token quotas and stores the
+// usage and cost of every call. The server and the cmd tools share it, so
+// quotas and spend cover every call of the model.
+//
+// The quotas are soft: they are checked before the call and the tokens are
+// known only after it, so calls running at the same time may together spend
+// more than the quota.
+type Metered struct {
+	provider        Provider
+	usage           UsageStore
+	prices          Prices
+	dailyTokens     int
+	userDailyTokens int
+}
+
+func NewMetered(cfg *config.Config, provider Provider, usage UsageStore, prices Prices) *Metered {
+	return &Metered{
+		provider:        provider,
+		usage:           usage,
+		prices:          prices,
+		dailyTokens:     cfg.LLMDailyTokens,
+		userDailyTokens: cfg.LLMUserDailyTokens,
+	}
+}
+
+// Chat calls the model for cc.UserID, or for no user when it is not valid.
+// The caller fills the user, the owner, the feature and the prompt version
+// of cc, the rest is filled from the response.
+func (m *Metered) Chat(ctx context.Context, cc *store.ChatCompletions, req Request, onDelta func(delta string)) (*Response, error) {
+	err := m.CheckQuota(ctx, cc.UserID)
+	if err != nil {
+		return nil, err
+	}
+	res, err := m.provider.Chat(ctx, req, onDelta)
+	if err != nil {
+		return nil, err
+	}
+	uid, err := uuid.NewV7()
+	if err != nil {
+		return nil, err
+	}
+	cc.UUID = uid.String()
+	cc.Model = res.Model
+	cc.PromptTokens = res.Usage.PromptTokens
+	cc.CompletionTokens = res.Usage.CompletionTokens
+	cc.TotalTokens = res.Usage.TotalTokens
+	cc.Cost = m.prices.Cost(res.Model, res.Usage)
+	cc.Date = res.Created
+	cc.CreatedAt = time.Now()
+	cc.UpdatedAt = cc.CreatedAt
+	err = m.usage.CreateChatCompletions(ctx, cc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create chat completions: %w", err)
+	}
+	return res, nil
+}
+
+// CheckQuota returns ErrQuotaExceeded when the user or everyone have spent
+// the daily tokens. Only the quota of everyone applies without a user.
+func (m *Metered) CheckQuota(ctx context.Context, userID null.Int) error {
+	today := StartOfDay(time.Now())
+	if m.userDailyTokens > 0 && userID.Valid {
+		t, err := m.usage.GetLLMTotals(ctx, today, userID)
+		if err != nil {
+			return fmt.Errorf("failed to get user llm usage: %w", err)
+		}
+		if t.TotalTokens >= int64(m.userDailyTokens) {
+			return fmt.Errorf("%w: user %d spent %d tokens", ErrQuotaExceeded, userID.V, t.TotalTokens)
+		}
+	}
+	if m.dailyTokens > 0 {
+		t, err := m.usage.GetLLMTotals(ctx, today, null.Int{})
+		if err != nil {
+			return fmt.Errorf("failed to get llm usage: %w", err)
+		}
+		if t.TotalTokens >= int64(m.dailyTokens) {
+			return fmt.Errorf("%w: %d tokens spent", ErrQuotaExceeded, t.TotalTokens)
+		}
+	}
+	return nil
+}
+
+// StartOfDay is the start of the day of t, quotas are reset at it.
+func StartOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
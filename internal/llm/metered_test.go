@@ -0,0 +1,67 @@
+package llm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zagvozdeen/malicious-learning/internal/config"
+	"github.com/zagvozdeen/malicious-learning/internal/db/null"
+	"github.com/zagvozdeen/malicious-learning/internal/store"
+	"github.com/zagvozdeen/malicious-learning/internal/store/enum"
+)
+
+type usageStore struct {
+	created []*store.ChatCompletions
+}
+
+func (s *usageStore) CreateChatCompletions(_ context.Context, cc *store.ChatCompletions) error {
+	s.created = append(s.created, cc)
+	return nil
+}
+
+func (s *usageStore) GetLLMTotals(_ context.Context, _ time.Time, userID null.Int) (store.LLMTotals, error) {
+	var t store.LLMTotals
+	for _, cc := range s.created {
+		if !userID.Valid || cc.UserID == userID {
+			t.Requests++
+			t.TotalTokens += cc.TotalTokens
+		}
+	}
+	return t, nil
+}
+
+func TestMetered(t *testing.T) {
+	usage := &usageStore{}
+	m := NewMetered(&config.Config{LLMDailyTokens: 12, LLMUserDailyTokens: 6}, &Fake{
+		Reply: func(req Request) string { return "one two three" },
+	}, usage, Prices{"m": {Prompt: 1e6, Completion: 2e6}})
+	req := Request{Model: "m", Messages: []Message{{Role: RoleUser, Content: "a b c"}}}
+
+	res, err := m.Chat(context.Background(), &store.ChatCompletions{
+		UserID:    null.WrapInt(1),
+		OwnerType: enum.ChatOwnerTypeTutorChat,
+		OwnerID:   null.WrapInt(2),
+		Feature:   "tutor",
+	}, req, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "one two three", res.Content)
+	require.Len(t, usage.created, 1)
+	assert.Equal(t, int64(6), usage.created[0].TotalTokens)
+	assert.InDelta(t, 9, usage.created[0].Cost, 0.001)
+	assert.NotEmpty(t, usage.created[0].UUID)
+
+	_, err = m.Chat(context.Background(), &store.ChatCompletions{UserID: null.WrapInt(1)}, req, nil)
+	assert.ErrorIs(t, err, ErrQuotaExceeded, "user spent the quota")
+
+	tool := &store.ChatCompletions{OwnerType: enum.ChatOwnerTypeTool, Feature: "renamer"}
+	_, err = m.Chat(context.Background(), tool, req, nil)
+	require.NoError(t, err, "tools have no user quota")
+	assert.False(t, tool.UserID.Valid)
+
+	_, err = m.Chat(context.Background(), &store.ChatCompletions{UserID: null.WrapInt(3)}, req, nil)
+	assert.ErrorIs(t, err, ErrQuotaExceeded, "everyone spent the quota")
+	assert.Len(t, usage.created, 2)
+}
@@ -0,0 +1,106 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/option"
+)
+
+// OpenAI is a provider for any endpoint compatible with the chat completions
+// API of OpenAI.
+type OpenAI struct {
+	client openai.Client
+}
+
+var _ Provider = (*OpenAI)(nil)
+
+// NewOpenAI returns a provider for the endpoint, requests and responses are
+// logged to debug when it is not nil.
+func NewOpenAI(baseURL, token string, debug *slog.Logger) *OpenAI {
+	options := []option.RequestOption{
+		option.WithBaseURL(baseURL),
+		option.WithAPIKey(token),
+	}
+	if debug != nil {
+		options = append(options, option.WithDebugLog(slog.NewLogLogger(debug.Handler(), slog.LevelDebug)))
+	}
+	return &OpenAI{client: openai.NewClient(options...)}
+}
+
+func (o *OpenAI) Chat(ctx context.Context, req Request, onDelta func(delta string)) (*Response, error) {
+	params := openai.ChatCompletionNewParams{
+		Messages: make([]openai.ChatCompletionMessageParamUnion, 0, len(req.Messages)),
+		Model:    req.Model,
+	}
+	for _, msg := range req.Messages {
+		switch msg.Role {
+		case RoleSystem:
+			params.Messages = append(params.Messages, openai.SystemMessage(msg.Content))
+		case RoleAssistant:
+			params.Messages = append(params.Messages, openai.AssistantMessage(msg.Content))
+		default:
+			params.Messages = append(params.Messages, openai.UserMessage(msg.Content))
+		}
+	}
+	if onDelta == nil {
+		return o.complete(ctx, params)
+	}
+	return o.stream(ctx, params, onDelta)
+}
+
+func (o *OpenAI) complete(ctx context.Context, params openai.ChatCompletionNewParams) (*Response, error) {
+	res, err := o.client.Chat.Completions.New(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create chat completion: %w", err)
+	}
+	if len(res.Choices) == 0 {
+		return nil, errors.New("empty chat model choices")
+	}
+	return newResponse(res), nil
+}
+
+func (o *OpenAI) stream(ctx context.Context, params openai.ChatCompletionNewParams, onDelta func(delta string)) (*Response, error) {
+	params.StreamOptions = openai.ChatCompletionStreamOptionsParam{
+		IncludeUsage: openai.Bool(true),
+	}
+	stream := o.client.Chat.Completions.NewStreaming(ctx, params)
+	defer stream.Close()
+
+	acc := openai.ChatCompletionAccumulator{}
+	for stream.Next() {
+		chunk := stream.Current()
+		if !acc.AddChunk(chunk) {
+			return nil, errors.New("failed to accumulate chat model stream")
+		}
+		if len(chunk.Choices) == 0 {
+			continue
+		}
+		if delta := chunk.Choices[0].Delta.Content; delta != "" {
+			onDelta(delta)
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return nil, fmt.Errorf("failed to stream chat model: %w", err)
+	}
+	if len(acc.Choices) == 0 {
+		return nil, errors.New("empty chat model choices")
+	}
+	return newResponse(&acc.ChatCompletion), nil
+}
+
+func newResponse(res *openai.ChatCompletion) *Response {
+	return &Response{
+		Model:   res.Model,
+		Content: res.Choices[0].Message.Content,
+		Usage: Usage{
+			PromptTokens:     res.Usage.PromptTokens,
+			CompletionTokens: res.Usage.CompletionTokens,
+			TotalTokens:      res.Usage.TotalTokens,
+		},
+		Created: res.Created,
+	}
+}
@@ -0,0 +1,65 @@
+package llm
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Price is the price of a million prompt and completion tokens of a model in
+// the currency of the account.
+type Price struct {
+	Prompt     float64
+	Completion float64
+}
+
+// Prices are prices by model name.
+type Prices map[string]Price
+
+// ParsePrices parses prices of the form "gpt-5-mini=0.25/2,gpt-5=1.25/10"
+// where the numbers are prices of a million prompt and completion tokens.
+func ParsePrices(s string) (Prices, error) {
+	prices := make(Prices)
+	for item := range strings.SplitSeq(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		model, price, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid price %q: missing =", item)
+		}
+		prompt, completion, ok := strings.Cut(price, "/")
+		if !ok {
+			return nil, fmt.Errorf("invalid price %q: missing /", item)
+		}
+		var p Price
+		var err error
+		p.Prompt, err = strconv.ParseFloat(strings.TrimSpace(prompt), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid prompt price %q: %w", item, err)
+		}
+		p.Completion, err = strconv.ParseFloat(strings.TrimSpace(completion), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid completion price %q: %w", item, err)
+		}
+		prices[strings.TrimSpace(model)] = p
+	}
+	return prices, nil
+}
+
+// Cost returns the cost of the usage. Providers answer with dated model
+// names like gpt-5-mini-2025-08-07, so the longest configured prefix of the
+// model is used when there is no exact price. Unknown models cost nothing.
+func (p Prices) Cost(model string, usage Usage) float64 {
+	price, ok := p[model]
+	if !ok {
+		var prefix string
+		for name, pr := range p {
+			if strings.HasPrefix(model, name) && len(name) > len(prefix) {
+				prefix, price = name, pr
+			}
+		}
+	}
+	return (float64(usage.PromptTokens)*price.Prompt + float64(usage.CompletionTokens)*price.Completion) / 1e6
+}
@@ -0,0 +1,157 @@
+// Package patch formats file edits as unified diffs and as mbox patches that
+// git am applies.
+package patch
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+	"time"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// contextLines is the number of unchanged lines around a change in a hunk.
+const contextLines = 3
+
+// Patch is a commit editing one file of the repository.
+type Patch struct {
+	AuthorName  string
+	AuthorEmail string
+	Date        time.Time
+	Subject     string
+	// Body is the commit message after the subject, it may be empty.
+	Body string
+	// Path is the path of the file from the repository root.
+	Path string
+	Old  string
+	New  string
+}
+
+// Diff returns the unified diff of the file from old to new in the format of
+// git diff, it is empty when the contents are equal.
+func Diff(path, old, new string) string {
+	a, b := splitLines(old), splitLines(new)
+	groups := difflib.NewMatcher(a, b).GetGroupedOpCodes(contextLines)
+	if len(groups) == 0 {
+		return ""
+	}
+	w := &strings.Builder{}
+	fmt.Fprintf(w, "diff --git a/%s b/%s\n", path, path)
+	fmt.Fprintf(w, "--- a/%s\n", path)
+	fmt.Fprintf(w, "+++ b/%s\n", path)
+	for _, group := range groups {
+		first, last := group[0], group[len(group)-1]
+		fmt.Fprintf(w, "@@ -%s +%s @@\n", hunkRange(first.I1, last.I2), hunkRange(first.J1, last.J2))
+		for _, op := range group {
+			if op.Tag == 'e' {
+				writeLines(w, ' ', a[op.I1:op.I2])
+				continue
+			}
+			if op.Tag == 'r' || op.Tag == 'd' {
+				writeLines(w, '-', a[op.I1:op.I2])
+			}
+			if op.Tag == 'r' || op.Tag == 'i' {
+				writeLines(w, '+', b[op.J1:op.J2])
+			}
+		}
+	}
+	return w.String()
+}
+
+// splitLines splits the text keeping line endings, the last line has none
+// when the text does not end with a newline.
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+func hunkRange(start, stop int) string {
+	switch n := stop - start; n {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	default:
+		return fmt.Sprintf("%d,%d", start+1, n)
+	}
+}
+
+func writeLines(w *strings.Builder, prefix byte, lines []string) {
+	for _, line := range lines {
+		w.WriteByte(prefix)
+		w.WriteString(line)
+		if !strings.HasSuffix(line, "\n") {
+			w.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+// WriteMbox writes the patches as an mbox in the format of git format-patch,
+// one message per patch.
+func WriteMbox(w io.Writer, patches []Patch) error {
+	for i, p := range patches {
+		subject := p.Subject
+		if len(patches) > 1 {
+			subject = fmt.Sprintf("[PATCH %d/%d] %s", i+1, len(patches), subject)
+		} else {
+			subject = "[PATCH] " + subject
+		}
+		msg := &strings.Builder{}
+		// The zero hash and the date are the constant mbox separator of git.
+		msg.WriteString("From 0000000000000000000000000000000000000000 Mon Sep 17 00:00:00 2001\n")
+		fmt.Fprintf(msg, "From: %s <%s>\n", mime.QEncoding.Encode("utf-8", p.AuthorName), p.AuthorEmail)
+		fmt.Fprintf(msg, "Date: %s\n", p.Date.Format(time.RFC1123Z))
+		fmt.Fprintf(msg, "Subject: %s\n", mime.QEncoding.Encode("utf-8", subject))
+		msg.WriteString("MIME-Version: 1.0\n")
+		msg.WriteString("Content-Type: text/plain; charset=UTF-8\n")
+		msg.WriteString("Content-Transfer-Encoding: 8bit\n\n")
+		if body := strings.TrimSpace(p.Body); body != "" {
+			msg.WriteString(neutralize(body) + "\n\n")
+		}
+		msg.WriteString("---\n\n")
+		msg.WriteString(Diff(p.Path, p.Old, p.New))
+		msg.WriteString("-- \nmalicious-learning\n\n")
+		if _, err := io.WriteString(w, msg.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Quote prefixes every line of the text with "> ", it is how text written by
+// users goes into a commit message: git am takes no quoted line for a diff.
+func Quote(text string) string {
+	lines := strings.Split(normalizeNewlines(text), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight("> "+line, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// mailPrefixes start lines git am reads as the start of the diff, of the next
+// message or as headers in the body.
+var mailPrefixes = []string{"diff ", "---", "Index:", "From ", ">From ", "From:", "Subject:", "Date:"}
+
+// neutralize quotes lines of the commit message git am would not keep in it,
+// a message must not carry changes of its own.
+func neutralize(body string) string {
+	lines := strings.Split(normalizeNewlines(body), "\n")
+	for i, line := range lines {
+		for _, prefix := range mailPrefixes {
+			if strings.HasPrefix(line, prefix) {
+				lines[i] = "> " + line
+				break
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func normalizeNewlines(s string) string {
+	return strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(s)
+}
@@ -0,0 +1,151 @@
+package patch
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		old  string
+		new  string
+		want string
+	}{
+		{
+			name: "equal",
+			old:  "a\nb\n",
+			new:  "a\nb\n",
+			want: "",
+		},
+		{
+			name: "changed line",
+			old:  "a\nb\nc\nd\ne\nf\ng\nh\n",
+			new:  "a\nb\nc\nd\nE\nf\ng\nh\n",
+			want: "diff --git a/x.md b/x.md\n--- a/x.md\n+++ b/x.md\n" +
+				"@@ -2,7 +2,7 @@\n b\n c\n d\n-e\n+E\n f\n g\n h\n",
+		},
+		{
+			name: "inserted first line",
+			old:  "b\n",
+			new:  "a\nb\n",
+			want: "diff --git a/x.md b/x.md\n--- a/x.md\n+++ b/x.md\n" +
+				"@@ -1 +1,2 @@\n+a\n b\n",
+		},
+		{
+			name: "into empty file",
+			old:  "",
+			new:  "a\n",
+			want: "diff --git a/x.md b/x.md\n--- a/x.md\n+++ b/x.md\n" +
+				"@@ -0,0 +1 @@\n+a\n",
+		},
+		{
+			name: "no newline at end of file",
+			old:  "a\nb",
+			new:  "a\nb\n",
+			want: "diff --git a/x.md b/x.md\n--- a/x.md\n+++ b/x.md\n" +
+				"@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+b\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Diff("x.md", tt.old, tt.new))
+		})
+	}
+}
+
+func TestWriteMbox(t *testing.T) {
+	p := Patch{
+		AuthorName:  "Анна",
+		AuthorEmail: "anna@example.com",
+		Date:        time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC),
+		Subject:     "Fix card",
+		Body:        "Typo in the answer.",
+		Path:        "data/courses/ml/1_q.md",
+		Old:         "a\n",
+		New:         "b\n",
+	}
+	buf := &bytes.Buffer{}
+	require.NoError(t, WriteMbox(buf, []Patch{p, p}))
+	out := buf.String()
+
+	assert.Equal(t, 2, bytes.Count(buf.Bytes(), []byte("From 0000000000000000000000000000000000000000 ")))
+	assert.Contains(t, out, "From: =?utf-8?q?=D0=90=D0=BD=D0=BD=D0=B0?= <anna@example.com>\n")
+	assert.Contains(t, out, "Date: Mon, 19 Oct 2026 12:00:00 +0000\n")
+	assert.Contains(t, out, "Subject: [PATCH 1/2] Fix card\n")
+	assert.Contains(t, out, "Subject: [PATCH 2/2] Fix card\n")
+	assert.Contains(t, out, "\nTypo in the answer.\n\n---\n\ndiff --git a/data/courses/ml/1_q.md b/data/courses/ml/1_q.md\n")
+}
+
+func TestQuote(t *testing.T) {
+	assert.Equal(t, "> a\n>\n> diff --git a/x b/x", Quote("a\r\n\ndiff --git a/x b/x"))
+}
+
+// TestWriteMboxHostileBody applies the mbox with git am and checks that a body
+// carrying a diff of its own changes nothing but the patched file.
+func TestWriteMboxHostileBody(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	hostile := "Typo.\n" +
+		"Subject: Owned\n" +
+		"---\n" +
+		"diff --git a/Makefile b/Makefile\n" +
+		"--- a/Makefile\n" +
+		"+++ b/Makefile\n" +
+		"@@ -1 +1 @@\n" +
+		"-all:\n" +
+		"+all: owned\n"
+	for name, body := range map[string]string{
+		"quoted":     Quote(hostile) + "\n\nSuggested in the app.",
+		"not quoted": hostile,
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			git := func(stdin string, args ...string) string {
+				cmd := exec.Command("git", args...)
+				cmd.Dir = dir
+				cmd.Stdin = strings.NewReader(stdin)
+				cmd.Env = append(os.Environ(),
+					"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+					"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+					"GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1",
+				)
+				out, err := cmd.CombinedOutput()
+				require.NoError(t, err, string(out))
+				return string(out)
+			}
+			require.NoError(t, os.MkdirAll(filepath.Join(dir, "data"), 0o755))
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "Makefile"), []byte("all:\n"), 0o644))
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "data", "1_q.md"), []byte("a\n"), 0o644))
+			git("", "init", "-q")
+			git("", "add", "-A")
+			git("", "commit", "-q", "-m", "init")
+
+			buf := &bytes.Buffer{}
+			require.NoError(t, WriteMbox(buf, []Patch{{
+				AuthorName:  "Анна",
+				AuthorEmail: "anna@example.com",
+				Date:        time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC),
+				Subject:     "Fix card",
+				Body:        body,
+				Path:        "data/1_q.md",
+				Old:         "a\n",
+				New:         "b\n",
+			}}))
+			git(buf.String(), "am", "-q")
+
+			assert.Equal(t, "data/1_q.md\n", git("", "diff", "--name-only", "HEAD~1", "HEAD"))
+			assert.Equal(t, "Fix card\n", git("", "log", "-1", "--format=%s"))
+			assert.Contains(t, git("", "log", "-1", "--format=%b"), "diff --git a/Makefile b/Makefile")
+		})
+	}
+}
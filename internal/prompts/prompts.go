@@ -0,0 +1,156 @@
+// Package prompts renders prompts of the language model from text/template
+// files. Embedded templates are versioned by their file names, e.g.
+// recommendations.v2.tmpl, and the latest version is used unless the course
+// overrides the template in its 0_index.yaml.
+package prompts
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+//go:embed templates
+var templates embed.FS
+
+const (
+	Recommendations = "recommendations"
+	Tutor           = "tutor"
+	// Draft is the prompt of cmd/draft, courses do not override it.
+	Draft = "draft"
+)
+
+// samples are the data of the prompts a course may override.
+var samples = map[string]any{
+	Recommendations: RecommendationsData{
+		Course:  "Go",
+		Answers: []Answer{{UID: 1, Condition: "Забыл", Question: "Что такое Go?"}},
+	},
+	Tutor: TutorData{
+		Course:    "Go",
+		Condition: "Забыл",
+		Question:  "Что такое Go?",
+		Answer:    "Язык программирования.",
+	},
+}
+
+// Template is a parsed prompt of a known version.
+type Template struct {
+	Name string
+	// Version is stored with chat completions to compare prompts, e.g.
+	// recommendations.v2 or recommendations.go-1a2b3c4d for an override.
+	Version string
+	tmpl    *template.Template
+}
+
+// Latest returns the embedded template of the name with the highest version.
+func Latest(name string) (*Template, error) {
+	entries, err := fs.ReadDir(templates, "templates")
+	if err != nil {
+		return nil, err
+	}
+	var latest string
+	var version int
+	for _, entry := range entries {
+		rest, ok := strings.CutPrefix(entry.Name(), name+".v")
+		if !ok {
+			continue
+		}
+		rest, ok = strings.CutSuffix(rest, ".tmpl")
+		if !ok {
+			continue
+		}
+		v, err := strconv.Atoi(rest)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version of template %s: %w", entry.Name(), err)
+		}
+		if v > version {
+			latest, version = entry.Name(), v
+		}
+	}
+	if latest == "" {
+		return nil, fmt.Errorf("template %s not found", name)
+	}
+	b, err := fs.ReadFile(templates, "templates/"+latest)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(name, fmt.Sprintf("%s.v%d", name, version), string(b))
+}
+
+// Override parses the template of the course 0_index.yaml, the version is
+// derived from the text so every edit of the override gets a new version.
+func Override(name, courseSlug, text string) (*Template, error) {
+	sample, ok := samples[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown prompt %q", name)
+	}
+	sum := sha256.Sum256([]byte(text))
+	t, err := Parse(name, fmt.Sprintf("%s.%s-%s", name, courseSlug, hex.EncodeToString(sum[:4])), text)
+	if err != nil {
+		return nil, err
+	}
+	// Fields missing from the data are only reported on execution.
+	_, err = t.Execute(sample)
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func Parse(name, version, text string) (*Template, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse prompt %s: %w", version, err)
+	}
+	return &Template{Name: name, Version: version, tmpl: tmpl}, nil
+}
+
+func (t *Template) Execute(data any) (string, error) {
+	b := &strings.Builder{}
+	err := t.tmpl.Execute(b, data)
+	if err != nil {
+		return "", fmt.Errorf("failed to execute prompt %s: %w", t.Version, err)
+	}
+	return strings.TrimSpace(b.String()), nil
+}
+
+// RecommendationsData is the data of the recommendations prompt.
+type RecommendationsData struct {
+	Course  string
+	Answers []Answer
+}
+
+// Answer is a card the user answered in the test session.
+type Answer struct {
+	UID int
+	// Condition is «Вспомнил» or «Забыл».
+	Condition string
+	Question  string
+	// Body is the HTML of the question, it may be empty.
+	Body string
+}
+
+// TutorData is the data of the system prompt of a tutor chat about a card.
+type TutorData struct {
+	Course string
+	// Condition is how the user answered the card in the test session of the
+	// chat, it is empty for chats outside of sessions.
+	Condition    string
+	Question     string
+	QuestionBody string
+	Answer       string
+}
+
+// DraftData is the data of the prompt of an answer drafted for a card.
+type DraftData struct {
+	Course string
+	// Module is the module of the card, it may be empty.
+	Module   string
+	Question string
+}
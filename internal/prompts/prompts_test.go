@@ -0,0 +1,81 @@
+package prompts
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatestRecommendations(t *testing.T) {
+	tmpl, err := Latest(Recommendations)
+	require.NoError(t, err)
+	assert.Equal(t, "recommendations.v1", tmpl.Version)
+
+	prompt, err := tmpl.Execute(RecommendationsData{
+		Course: "Go",
+		Answers: []Answer{
+			{UID: 1, Condition: "Забыл", Question: "Что такое Go?"},
+			{UID: 2, Condition: "Вспомнил", Question: "Что такое канал?", Body: "<p>Код</p>"},
+		},
+	})
+	require.NoError(t, err)
+	assert.Contains(t, prompt, "по курсу «Go»")
+	assert.NotContains(t, prompt, "машинному обучению")
+	assert.True(t, strings.HasSuffix(prompt, "```"))
+	assert.Contains(t, prompt, "```\n1. Забыл. Что такое Go?.\n2. Вспомнил. Что такое канал?.\n<p>Код</p>\n```")
+}
+
+func TestLatestTutor(t *testing.T) {
+	tmpl, err := Latest(Tutor)
+	require.NoError(t, err)
+	assert.Equal(t, "tutor.v1", tmpl.Version)
+
+	prompt, err := tmpl.Execute(TutorData{Course: "Go", Question: "<p>Что такое канал?</p>", Answer: "<p>Труба.</p>"})
+	require.NoError(t, err)
+	assert.Contains(t, prompt, "по курсу «Go»")
+	assert.NotContains(t, prompt, "В тесте")
+	assert.Contains(t, prompt, "```\n<p>Что такое канал?</p>\n```")
+	assert.True(t, strings.HasSuffix(prompt, "```\n<p>Труба.</p>\n```"))
+
+	prompt, err = tmpl.Execute(TutorData{Course: "Go", Condition: "Забыл", Question: "Q", QuestionBody: "B", Answer: "A"})
+	require.NoError(t, err)
+	assert.Contains(t, prompt, "ответил на карточку: «Забыл».\n")
+	assert.Contains(t, prompt, "```\nQ\nB\n```")
+}
+
+func TestLatestDraft(t *testing.T) {
+	tmpl, err := Latest(Draft)
+	require.NoError(t, err)
+	assert.Equal(t, "draft.v1", tmpl.Version)
+
+	prompt, err := tmpl.Execute(DraftData{Course: "Go", Module: "Практика", Question: "Что такое канал?"})
+	require.NoError(t, err)
+	assert.Contains(t, prompt, "по курсу «Go», модуль «Практика». Вопрос карточки: «Что такое канал?».")
+	assert.Contains(t, prompt, "||текст||")
+
+	prompt, err = tmpl.Execute(DraftData{Course: "Go", Question: "Q"})
+	require.NoError(t, err)
+	assert.Contains(t, prompt, "по курсу «Go». Вопрос")
+}
+
+func TestOverride(t *testing.T) {
+	tmpl, err := Override(Recommendations, "go", "Курс {{ .Course }}, ответов {{ len .Answers }}")
+	require.NoError(t, err)
+	assert.Regexp(t, `^recommendations\.go-[0-9a-f]{8}$`, tmpl.Version)
+	prompt, err := tmpl.Execute(RecommendationsData{Course: "Go"})
+	require.NoError(t, err)
+	assert.Equal(t, "Курс Go, ответов 0", prompt)
+
+	other, err := Override(Recommendations, "go", "Курс {{ .Course }}")
+	require.NoError(t, err)
+	assert.NotEqual(t, tmpl.Version, other.Version)
+
+	_, err = Override("unknown", "go", "text")
+	assert.Error(t, err)
+	_, err = Override(Recommendations, "go", "{{ .Course")
+	assert.Error(t, err)
+	_, err = Override(Recommendations, "go", "{{ .Missing }}")
+	assert.Error(t, err)
+}
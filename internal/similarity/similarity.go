@@ -0,0 +1,128 @@
+// Package similarity finds similar texts with TF-IDF weighted bag of words
+// and cosine similarity. It needs no model, so it runs on every conversion.
+package similarity
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// stemLength is the number of runes words are cut to. It is a crude stemmer
+// that merges most inflections of Russian and English words.
+const stemLength = 6
+
+var stopWords = map[string]struct{}{
+	"это": {}, "как": {}, "что": {}, "для": {}, "или": {}, "при": {}, "так": {},
+	"его": {}, "они": {}, "она": {}, "если": {}, "чем": {}, "все": {}, "также": {},
+	"the": {}, "and": {}, "for": {}, "are": {}, "that": {}, "with": {}, "this": {},
+	"from": {}, "not": {}, "can": {}, "you": {}, "which": {},
+}
+
+// Match is a text similar to another one.
+type Match struct {
+	// Index is the index of the similar text in the indexed slice.
+	Index int
+	// Score is the cosine similarity from 0 to 1.
+	Score float64
+}
+
+// Index is a TF-IDF index of texts.
+type Index struct {
+	vectors  []map[string]float64
+	postings map[string][]int
+}
+
+// New indexes texts. Terms found in more than half of the texts are dropped,
+// they say nothing about similarity.
+func New(texts []string) *Index {
+	idx := &Index{
+		vectors:  make([]map[string]float64, len(texts)),
+		postings: make(map[string][]int),
+	}
+	counts := make([]map[string]int, len(texts))
+	df := make(map[string]int)
+	for i, text := range texts {
+		counts[i] = make(map[string]int)
+		for _, term := range Terms(text) {
+			if counts[i][term] == 0 {
+				df[term]++
+			}
+			counts[i][term]++
+		}
+	}
+	n := float64(len(texts))
+	for i := range texts {
+		vector := make(map[string]float64, len(counts[i]))
+		var norm float64
+		for term, count := range counts[i] {
+			if len(texts) > 2 && df[term]*2 > len(texts) {
+				continue
+			}
+			w := (1 + math.Log(float64(count))) * math.Log(1+n/float64(df[term]))
+			vector[term] = w
+			norm += w * w
+		}
+		norm = math.Sqrt(norm)
+		for term := range vector {
+			vector[term] /= norm
+			idx.postings[term] = append(idx.postings[term], i)
+		}
+		idx.vectors[i] = vector
+	}
+	return idx
+}
+
+// Similar returns up to n texts most similar to the i-th text with a score of
+// at least minScore, best first.
+func (idx *Index) Similar(i int, n int, minScore float64) []Match {
+	scores := make(map[int]float64)
+	for term, w := range idx.vectors[i] {
+		for _, j := range idx.postings[term] {
+			if j != i {
+				scores[j] += w * idx.vectors[j][term]
+			}
+		}
+	}
+	matches := make([]Match, 0, len(scores))
+	for j, score := range scores {
+		if score >= minScore {
+			matches = append(matches, Match{Index: j, Score: min(score, 1)})
+		}
+	}
+	slices.SortFunc(matches, func(a, b Match) int {
+		if c := cmp.Compare(b.Score, a.Score); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Index, b.Index)
+	})
+	if len(matches) > n {
+		matches = matches[:n]
+	}
+	return matches
+}
+
+// Terms splits the text into lower-cased stemmed words, skipping short words
+// and stop words.
+func Terms(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	terms := make([]string, 0, len(words))
+	for _, word := range words {
+		if utf8.RuneCountInString(word) < 3 {
+			continue
+		}
+		if _, ok := stopWords[word]; ok {
+			continue
+		}
+		if r := []rune(word); len(r) > stemLength {
+			word = string(r[:stemLength])
+		}
+		terms = append(terms, word)
+	}
+	return terms
+}
@@ -0,0 +1,37 @@
+package similarity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTerms(t *testing.T) {
+	assert.Equal(t, []string{"хеш", "таблиц", "работа", "hash", "tables"}, Terms("Как хеш-таблицы работают? Hash tables, и т.д."))
+}
+
+func TestIndexSimilar(t *testing.T) {
+	idx := New([]string{
+		"Как устроена хеш-таблица и разрешение коллизий",
+		"Разрешение коллизий в хеш-таблице методом цепочек",
+		"Что такое градиентный спуск",
+		"Стохастический градиентный спуск и его варианты",
+		"Индексы в базах данных",
+	})
+
+	matches := idx.Similar(0, 3, 0.1)
+	require.NotEmpty(t, matches)
+	assert.Equal(t, 1, matches[0].Index)
+	assert.InDelta(t, 0.5, matches[0].Score, 0.5)
+	for _, m := range matches {
+		assert.NotEqual(t, 0, m.Index)
+		assert.NotEqual(t, 2, m.Index)
+	}
+
+	matches = idx.Similar(2, 1, 0.1)
+	require.Len(t, matches, 1)
+	assert.Equal(t, 3, matches[0].Index)
+
+	assert.Empty(t, idx.Similar(4, 3, 0.1))
+}
@@ -0,0 +1,36 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+type Asset struct {
+	Hash      string    `json:"hash"`
+	Name      string    `json:"name"`
+	MimeType  string    `json:"mime_type"`
+	Content   []byte    `json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (s *Store) GetAssetByHash(ctx context.Context, hash string) (*Asset, error) {
+	asset := &Asset{}
+	err := s.querier(ctx).QueryRow(
+		ctx,
+		"SELECT hash, name, mime_type, content, created_at FROM assets WHERE hash = $1",
+		hash,
+	).Scan(&asset.Hash, &asset.Name, &asset.MimeType, &asset.Content, &asset.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return asset, nil
+}
+
+func (s *Store) CreateAsset(ctx context.Context, asset *Asset) (err error) {
+	_, err = s.querier(ctx).Exec(
+		ctx,
+		"INSERT INTO assets (hash, name, mime_type, content, created_at) VALUES ($1, $2, $3, $4, $5) ON CONFLICT (hash) DO NOTHING",
+		asset.Hash, asset.Name, asset.MimeType, asset.Content, asset.CreatedAt,
+	)
+	return err
+}
@@ -0,0 +1,125 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/zagvozdeen/malicious-learning/internal/db/null"
+	"github.com/zagvozdeen/malicious-learning/internal/store/enum"
+)
+
+// CardDraft is a card markdown file written in the app.
+type CardDraft struct {
+	ID         int    `json:"id"`
+	UUID       string `json:"uuid"`
+	CourseID   int    `json:"course_id"`
+	CourseSlug string `json:"course_slug"`
+	CourseName string `json:"course_name"`
+	FileName   string `json:"file_name"`
+	// Content is the whole markdown file, null removes the file.
+	Content     null.String      `json:"content"`
+	Status      enum.DraftStatus `json:"status"`
+	AuthorID    int              `json:"author_id"`
+	PublishedAt *time.Time       `json:"published_at"`
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+}
+
+const cardDraftColumns = `
+	d.id, d.uuid, d.course_id, co.slug, co.name, d.file_name, d.content, d.status, d.author_id, d.published_at, d.created_at, d.updated_at`
+
+func scanCardDraft(row pgx.Row, d *CardDraft) error {
+	return row.Scan(
+		&d.ID,
+		&d.UUID,
+		&d.CourseID,
+		&d.CourseSlug,
+		&d.CourseName,
+		&d.FileName,
+		&d.Content,
+		&d.Status,
+		&d.AuthorID,
+		&d.PublishedAt,
+		&d.CreatedAt,
+		&d.UpdatedAt,
+	)
+}
+
+func (s *Store) CreateCardDraft(ctx context.Context, d *CardDraft) error {
+	return s.querier(ctx).QueryRow(ctx, `
+		INSERT INTO card_drafts (uuid, course_id, file_name, content, status, author_id, published_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id
+	`,
+		d.UUID,
+		d.CourseID,
+		d.FileName,
+		d.Content,
+		d.Status,
+		d.AuthorID,
+		d.PublishedAt,
+		d.CreatedAt,
+		d.UpdatedAt,
+	).Scan(&d.ID)
+}
+
+func (s *Store) UpdateCardDraft(ctx context.Context, d *CardDraft) error {
+	_, err := s.querier(ctx).Exec(ctx, `
+		UPDATE card_drafts SET content = $1, status = $2, published_at = $3, updated_at = $4 WHERE id = $5
+	`, d.Content, d.Status, d.PublishedAt, d.UpdatedAt, d.ID)
+	return err
+}
+
+// SupersedeCardDrafts marks drafts of the file published before the draft as
+// superseded by it.
+func (s *Store) SupersedeCardDrafts(ctx context.Context, d *CardDraft, updatedAt time.Time) error {
+	_, err := s.querier(ctx).Exec(ctx, `
+		UPDATE card_drafts SET status = 'superseded', updated_at = $1
+		WHERE course_id = $2 AND file_name = $3 AND status = 'published' AND id <> $4
+	`, updatedAt, d.CourseID, d.FileName, d.ID)
+	return err
+}
+
+func (s *Store) GetCardDraftByUUID(ctx context.Context, uuid string) (*CardDraft, error) {
+	d := &CardDraft{}
+	err := scanCardDraft(s.querier(ctx).QueryRow(ctx, `
+		SELECT `+cardDraftColumns+`
+		FROM card_drafts d
+		JOIN courses co ON co.id = d.course_id
+		WHERE d.uuid = $1
+	`, uuid), d)
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// GetCardDrafts returns drafts with the status in the order they were
+// published or created.
+func (s *Store) GetCardDrafts(ctx context.Context, status enum.DraftStatus) ([]CardDraft, error) {
+	rows, err := s.querier(ctx).Query(ctx, `
+		SELECT `+cardDraftColumns+`
+		FROM card_drafts d
+		JOIN courses co ON co.id = d.course_id
+		WHERE d.status = $1
+		ORDER BY d.published_at NULLS LAST, d.id
+	`, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	drafts := make([]CardDraft, 0)
+	for rows.Next() {
+		var d CardDraft
+		err = scanCardDraft(rows, &d)
+		if err != nil {
+			return nil, err
+		}
+		drafts = append(drafts, d)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return drafts, nil
+}
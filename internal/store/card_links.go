@@ -0,0 +1,94 @@
+package store
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type CardLink struct {
+	CourseID       int `json:"course_id"`
+	UID            int `json:"uid"`
+	TargetCourseID int `json:"target_course_id"`
+	TargetUID      int `json:"target_uid"`
+}
+
+// ReplaceCardLinks replaces links from cards of the courses with the links of
+// the latest conversion.
+func (s *Store) ReplaceCardLinks(ctx context.Context, courseIDs []int, links []CardLink) error {
+	_, err := s.querier(ctx).Exec(ctx, "DELETE FROM card_links WHERE course_id = ANY($1)", courseIDs)
+	if err != nil {
+		return err
+	}
+	for _, link := range links {
+		_, err = s.querier(ctx).Exec(ctx, `
+			INSERT INTO card_links (course_id, uid, target_course_id, target_uid)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT DO NOTHING
+		`, link.CourseID, link.UID, link.TargetCourseID, link.TargetUID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetLinkedCards returns active cards the card links to.
+func (s *Store) GetLinkedCards(ctx context.Context, card *Card) ([]Card, error) {
+	rows, err := s.querier(ctx).Query(ctx, `
+		SELECT c.id, c.uid, c.uuid, c.question, c.question_body, c.answer, c.module_id, c.course_id, c.is_active, c.hash, c.created_at, c.updated_at
+		FROM card_links l
+		JOIN cards c ON c.course_id = l.target_course_id AND c.uid = l.target_uid AND c.is_active = TRUE
+		WHERE l.course_id = $1 AND l.uid = $2
+		ORDER BY c.course_id, c.uid
+	`, card.CourseID, card.UID)
+	if err != nil {
+		return nil, err
+	}
+	return scanLinkedCards(rows)
+}
+
+// GetBacklinkedCards returns active cards linking to the card.
+func (s *Store) GetBacklinkedCards(ctx context.Context, card *Card) ([]Card, error) {
+	rows, err := s.querier(ctx).Query(ctx, `
+		SELECT c.id, c.uid, c.uuid, c.question, c.question_body, c.answer, c.module_id, c.course_id, c.is_active, c.hash, c.created_at, c.updated_at
+		FROM card_links l
+		JOIN cards c ON c.course_id = l.course_id AND c.uid = l.uid AND c.is_active = TRUE
+		WHERE l.target_course_id = $1 AND l.target_uid = $2
+		ORDER BY c.course_id, c.uid
+	`, card.CourseID, card.UID)
+	if err != nil {
+		return nil, err
+	}
+	return scanLinkedCards(rows)
+}
+
+func scanLinkedCards(rows pgx.Rows) ([]Card, error) {
+	defer rows.Close()
+	cards := make([]Card, 0)
+	for rows.Next() {
+		var card Card
+		err := rows.Scan(
+			&card.ID,
+			&card.UID,
+			&card.UUID,
+			&card.Question,
+			&card.QuestionBody,
+			&card.Answer,
+			&card.ModuleID,
+			&card.CourseID,
+			&card.IsActive,
+			&card.Hash,
+			&card.CreatedAt,
+			&card.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		cards = append(cards, card)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return cards, nil
+}
@@ -0,0 +1,79 @@
+package store
+
+import (
+	"context"
+)
+
+type CardSimilarity struct {
+	CourseID        int
+	UID             int
+	SimilarCourseID int
+	SimilarUID      int
+	Score           float64
+}
+
+type SimilarCard struct {
+	Card
+	Score float64 `json:"score"`
+}
+
+// ReplaceCardSimilarities replaces similarities of cards of the courses with
+// the ones computed by the latest conversion.
+func (s *Store) ReplaceCardSimilarities(ctx context.Context, courseIDs []int, similarities []CardSimilarity) error {
+	_, err := s.querier(ctx).Exec(ctx, "DELETE FROM card_similarities WHERE course_id = ANY($1)", courseIDs)
+	if err != nil {
+		return err
+	}
+	for _, sim := range similarities {
+		_, err = s.querier(ctx).Exec(ctx, `
+			INSERT INTO card_similarities (course_id, uid, similar_course_id, similar_uid, score)
+			VALUES ($1, $2, $3, $4, $5)
+		`, sim.CourseID, sim.UID, sim.SimilarCourseID, sim.SimilarUID, sim.Score)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetSimilarCards returns active cards similar to the card, most similar first.
+func (s *Store) GetSimilarCards(ctx context.Context, card *Card) ([]SimilarCard, error) {
+	rows, err := s.querier(ctx).Query(ctx, `
+		SELECT c.id, c.uid, c.uuid, c.question, c.question_body, c.answer, c.module_id, c.course_id, c.is_active, c.hash, c.created_at, c.updated_at, cs.score
+		FROM card_similarities cs
+		JOIN cards c ON c.course_id = cs.similar_course_id AND c.uid = cs.similar_uid AND c.is_active = TRUE
+		WHERE cs.course_id = $1 AND cs.uid = $2
+		ORDER BY cs.score DESC, c.uid
+	`, card.CourseID, card.UID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	cards := make([]SimilarCard, 0)
+	for rows.Next() {
+		var card SimilarCard
+		err = rows.Scan(
+			&card.ID,
+			&card.UID,
+			&card.UUID,
+			&card.Question,
+			&card.QuestionBody,
+			&card.Answer,
+			&card.ModuleID,
+			&card.CourseID,
+			&card.IsActive,
+			&card.Hash,
+			&card.CreatedAt,
+			&card.UpdatedAt,
+			&card.Score,
+		)
+		if err != nil {
+			return nil, err
+		}
+		cards = append(cards, card)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return cards, nil
+}
@@ -0,0 +1,140 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/zagvozdeen/malicious-learning/internal/db/null"
+	"github.com/zagvozdeen/malicious-learning/internal/store/enum"
+)
+
+// CardSuggestion is an edit of a card file proposed by a learner.
+type CardSuggestion struct {
+	ID         int    `json:"id"`
+	UUID       string `json:"uuid"`
+	CardID     int    `json:"card_id"`
+	CourseID   int    `json:"course_id"`
+	CourseSlug string `json:"course_slug"`
+	Question   string `json:"question"`
+	FileName   string `json:"file_name"`
+	// Base is the file in data/courses the edit was made on.
+	Base           string                `json:"base"`
+	Content        string                `json:"content"`
+	Comment        null.String           `json:"comment"`
+	Status         enum.SuggestionStatus `json:"status"`
+	AuthorID       int                   `json:"author_id"`
+	AuthorName     string                `json:"author_name"`
+	AuthorUsername null.String           `json:"author_username"`
+	AuthorEmail    null.String           `json:"-"`
+	ReviewerID     null.Int              `json:"reviewer_id"`
+	ReviewedAt     *time.Time            `json:"reviewed_at"`
+	CreatedAt      time.Time             `json:"created_at"`
+	UpdatedAt      time.Time             `json:"updated_at"`
+}
+
+const cardSuggestionColumns = `
+	cs.id, cs.uuid, cs.card_id, cs.course_id, co.slug, c.question, cs.file_name, cs.base, cs.content, cs.comment, cs.status,
+	cs.author_id, concat_ws(' ', u.first_name, u.last_name), u.username, u.email, cs.reviewer_id, cs.reviewed_at, cs.created_at, cs.updated_at`
+
+const cardSuggestionJoins = `
+	JOIN courses co ON co.id = cs.course_id
+	JOIN cards c ON c.id = cs.card_id
+	JOIN users u ON u.id = cs.author_id`
+
+func scanCardSuggestion(row pgx.Row, sg *CardSuggestion) error {
+	return row.Scan(
+		&sg.ID,
+		&sg.UUID,
+		&sg.CardID,
+		&sg.CourseID,
+		&sg.CourseSlug,
+		&sg.Question,
+		&sg.FileName,
+		&sg.Base,
+		&sg.Content,
+		&sg.Comment,
+		&sg.Status,
+		&sg.AuthorID,
+		&sg.AuthorName,
+		&sg.AuthorUsername,
+		&sg.AuthorEmail,
+		&sg.ReviewerID,
+		&sg.ReviewedAt,
+		&sg.CreatedAt,
+		&sg.UpdatedAt,
+	)
+}
+
+func (s *Store) CreateCardSuggestion(ctx context.Context, sg *CardSuggestion) error {
+	return s.querier(ctx).QueryRow(ctx, `
+		INSERT INTO card_suggestions (uuid, card_id, course_id, file_name, base, content, comment, status, author_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id
+	`,
+		sg.UUID,
+		sg.CardID,
+		sg.CourseID,
+		sg.FileName,
+		sg.Base,
+		sg.Content,
+		sg.Comment,
+		sg.Status,
+		sg.AuthorID,
+		sg.CreatedAt,
+		sg.UpdatedAt,
+	).Scan(&sg.ID)
+}
+
+// ReviewCardSuggestion sets the status of a pending suggestion and reports
+// whether it was still pending.
+func (s *Store) ReviewCardSuggestion(ctx context.Context, sg *CardSuggestion) (bool, error) {
+	tag, err := s.querier(ctx).Exec(ctx, `
+		UPDATE card_suggestions SET status = $1, reviewer_id = $2, reviewed_at = $3, updated_at = $4
+		WHERE id = $5 AND status = 'pending'
+	`, sg.Status, sg.ReviewerID, sg.ReviewedAt, sg.UpdatedAt, sg.ID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func (s *Store) GetCardSuggestionByUUID(ctx context.Context, uuid string) (*CardSuggestion, error) {
+	sg := &CardSuggestion{}
+	err := scanCardSuggestion(s.querier(ctx).QueryRow(ctx, `
+		SELECT `+cardSuggestionColumns+`
+		FROM card_suggestions cs`+cardSuggestionJoins+`
+		WHERE cs.uuid = $1
+	`, uuid), sg)
+	if err != nil {
+		return nil, err
+	}
+	return sg, nil
+}
+
+// GetCardSuggestions returns suggestions with the status, oldest first.
+func (s *Store) GetCardSuggestions(ctx context.Context, status enum.SuggestionStatus) ([]CardSuggestion, error) {
+	rows, err := s.querier(ctx).Query(ctx, `
+		SELECT `+cardSuggestionColumns+`
+		FROM card_suggestions cs`+cardSuggestionJoins+`
+		WHERE cs.status = $1
+		ORDER BY cs.id
+	`, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	suggestions := make([]CardSuggestion, 0)
+	for rows.Next() {
+		var sg CardSuggestion
+		err = scanCardSuggestion(rows, &sg)
+		if err != nil {
+			return nil, err
+		}
+		suggestions = append(suggestions, sg)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return suggestions, nil
+}
@@ -10,21 +10,30 @@ import (
 	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/zagvozdeen/malicious-learning/internal/db/null"
+	"github.com/zagvozdeen/malicious-learning/internal/store/enum"
 )
 
 type Card struct {
-	ID        int       `json:"id"`
-	UID       int       `json:"uid"`
-	UUID      string    `json:"uuid"`
-	Question  string    `json:"question"`
-	Answer    string    `json:"answer"`
-	Tags      []string  `json:"tags"`
-	ModuleID  int       `json:"module_id"`
-	CourseID  int       `json:"course_id"`
-	IsActive  bool      `json:"is_active"`
-	Hash      string    `json:"hash"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID           int       `json:"id"`
+	UID          int       `json:"uid"`
+	UUID         string    `json:"uuid"`
+	Question     string    `json:"question"`
+	QuestionBody string    `json:"question_body"`
+	Answer       string    `json:"answer"`
+	Tags         []string  `json:"tags"`
+	ModuleID     int       `json:"module_id"`
+	CourseID     int       `json:"course_id"`
+	IsActive     bool      `json:"is_active"`
+	Hash         string    `json:"hash"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	// PlainAnswer is the text of the answer and its translations without
+	// markup, it is indexed for search together with the question and tags.
+	PlainAnswer string `json:"-"`
+	// Translations are the localized variants of the card, they are stored
+	// with the card and never returned as is.
+	Translations []CardTranslation `json:"-"`
 }
 
 func (c *Card) GetHash() string {
@@ -38,15 +47,34 @@ func (c *Card) GetHash() string {
 	hasher.Write([]byte(c.Answer))
 	hasher.Write([]byte{0})
 	hasher.Write([]byte(strings.Join(c.Tags, ",")))
+	// The body is hashed only when present to keep hashes of older cards.
+	if c.QuestionBody != "" {
+		hasher.Write([]byte{0})
+		hasher.Write([]byte(c.QuestionBody))
+	}
+	for _, t := range c.Translations {
+		hasher.Write([]byte{0})
+		hasher.Write([]byte(t.Locale.String()))
+		hasher.Write([]byte{0})
+		hasher.Write([]byte(t.Question))
+		hasher.Write([]byte{0})
+		hasher.Write([]byte(t.QuestionBody))
+		hasher.Write([]byte{0})
+		hasher.Write([]byte(t.Answer))
+	}
 	return hex.EncodeToString(hasher.Sum(nil))
 }
 
-func (s *Store) GetAllCards(ctx context.Context) (cards []Card, err error) {
+// GetAllCards returns active cards of courses the user has access to.
+func (s *Store) GetAllCards(ctx context.Context, userID int) (cards []Card, err error) {
 	var rows pgx.Rows
-	rows, err = s.querier(ctx).Query(
-		ctx,
-		"SELECT id, uid, uuid, question, answer, module_id, is_active, hash, created_at, updated_at FROM cards WHERE is_active = TRUE ORDER BY uid",
-	)
+	rows, err = s.querier(ctx).Query(ctx, `
+		SELECT c.id, c.uid, c.uuid, c.question, c.question_body, c.answer, c.module_id, c.is_active, c.hash, c.created_at, c.updated_at
+		FROM cards c
+		JOIN courses co ON co.id = c.course_id
+		WHERE c.is_active = TRUE AND `+courseAccessible("$1")+`
+		ORDER BY c.uid
+	`, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -58,6 +86,7 @@ func (s *Store) GetAllCards(ctx context.Context) (cards []Card, err error) {
 			&card.UID,
 			&card.UUID,
 			&card.Question,
+			&card.QuestionBody,
 			&card.Answer,
 			&card.ModuleID,
 			&card.IsActive,
@@ -88,7 +117,7 @@ func (s *Store) GetCards(ctx context.Context, courseSlug string, moduleIDs []int
 	}
 	args = append(args, courseSlug)
 	sql := fmt.Sprintf(
-		"SELECT c.id, c.uid, c.uuid, c.question, c.answer, c.module_id, c.is_active, c.hash, c.created_at, c.updated_at FROM cards c JOIN courses co ON co.id = c.course_id WHERE c.is_active = true AND c.module_id in (%s) AND co.slug = $%d ORDER BY c.uid",
+		"SELECT c.id, c.uid, c.uuid, c.question, c.question_body, c.answer, c.module_id, c.is_active, c.hash, c.created_at, c.updated_at FROM cards c JOIN courses co ON co.id = c.course_id WHERE c.is_active = true AND c.module_id in (%s) AND co.slug = $%d ORDER BY c.uid",
 		strings.Join(pattern, ","),
 		len(args),
 	)
@@ -106,6 +135,7 @@ func (s *Store) GetCards(ctx context.Context, courseSlug string, moduleIDs []int
 			&card.UID,
 			&card.UUID,
 			&card.Question,
+			&card.QuestionBody,
 			&card.Answer,
 			&card.ModuleID,
 			&card.IsActive,
@@ -124,24 +154,26 @@ func (s *Store) GetCards(ctx context.Context, courseSlug string, moduleIDs []int
 	return cards, nil
 }
 
-func (s *Store) IsExistsCardByUIDAndHash(ctx context.Context, uid int, hash string) (exists bool, err error) {
+func (s *Store) IsExistsCardByUIDAndHash(ctx context.Context, courseID, uid int, hash string) (exists bool, err error) {
 	err = s.querier(ctx).QueryRow(
 		ctx,
-		"SELECT EXISTS(SELECT 1 FROM cards WHERE uid = $1 AND hash = $2 AND is_active = TRUE)",
-		uid, hash,
+		"SELECT EXISTS(SELECT 1 FROM cards WHERE course_id = $1 AND uid = $2 AND hash = $3 AND is_active = TRUE)",
+		courseID, uid, hash,
 	).Scan(&exists)
 	return
 }
 
+// CreateCard inserts the card with its translations.
 func (s *Store) CreateCard(ctx context.Context, card *Card) error {
-	return s.querier(ctx).QueryRow(ctx, `
-		INSERT INTO cards (uid, uuid, question, answer, tags, module_id, course_id, is_active, hash, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	err := s.querier(ctx).QueryRow(ctx, `
+		INSERT INTO cards (uid, uuid, question, question_body, answer, tags, module_id, course_id, is_active, hash, created_at, updated_at, plain_answer, search_vector)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, `+searchVector+`)
 		RETURNING id
 	`,
 		card.UID,
 		card.UUID,
 		card.Question,
+		card.QuestionBody,
 		card.Answer,
 		card.Tags,
 		card.ModuleID,
@@ -150,14 +182,174 @@ func (s *Store) CreateCard(ctx context.Context, card *Card) error {
 		card.Hash,
 		card.CreatedAt,
 		card.UpdatedAt,
+		card.PlainAnswer,
 	).Scan(&card.ID)
+	if err != nil {
+		return err
+	}
+	for _, t := range card.Translations {
+		_, err = s.querier(ctx).Exec(ctx, `
+			INSERT INTO card_translations (card_id, locale, question, question_body, answer)
+			VALUES ($1, $2, $3, $4, $5)
+		`, card.ID, t.Locale, t.Question, t.QuestionBody, t.Answer)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func (s *Store) DeactivateCard(ctx context.Context, card *Card) (err error) {
-	_, err = s.querier(ctx).Exec(
+// DeactivateCard deactivates the active version of the card and returns the
+// number of deactivated rows.
+func (s *Store) DeactivateCard(ctx context.Context, card *Card) (int64, error) {
+	tag, err := s.querier(ctx).Exec(
 		ctx,
-		"UPDATE cards SET is_active = FALSE, updated_at = $1 WHERE uid = $2 AND is_active = TRUE",
-		card.UpdatedAt, card.UID,
+		"UPDATE cards SET is_active = FALSE, updated_at = $1 WHERE course_id = $2 AND uid = $3 AND is_active = TRUE",
+		card.UpdatedAt, card.CourseID, card.UID,
 	)
-	return
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+type FullCard struct {
+	Card
+
+	ModuleName string `json:"module_name"`
+}
+
+// GetCardsForExport returns active cards of the course. When forgottenBy is
+// set, only cards the user has marked as forgotten in any of their sessions
+// are returned, matched by uid to survive card updates.
+func (s *Store) GetCardsForExport(ctx context.Context, courseSlug string, forgottenBy null.Int) ([]FullCard, error) {
+	rows, err := s.querier(ctx).Query(ctx, `
+		SELECT c.id, c.uid, c.uuid, c.question, c.question_body, c.answer, c.tags, c.module_id, c.course_id, c.is_active, c.hash, c.created_at, c.updated_at, m.name
+		FROM cards c
+		JOIN courses co ON co.id = c.course_id
+		JOIN modules m ON m.id = c.module_id
+		WHERE c.is_active = TRUE AND co.slug = $1 AND ($2::INTEGER IS NULL OR c.uid IN (
+			SELECT fc.uid
+			FROM user_answers ua
+			JOIN test_sessions ts ON ts.id = ua.test_session_id
+			JOIN cards fc ON fc.id = ua.card_id
+			WHERE ts.user_id = $2 AND ua.status = $3
+		))
+		ORDER BY c.module_id, c.uid
+	`, courseSlug, forgottenBy, enum.UserAnswerStatusForgot)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cards := make([]FullCard, 0)
+	for rows.Next() {
+		var card FullCard
+		err = rows.Scan(
+			&card.ID,
+			&card.UID,
+			&card.UUID,
+			&card.Question,
+			&card.QuestionBody,
+			&card.Answer,
+			&card.Tags,
+			&card.ModuleID,
+			&card.CourseID,
+			&card.IsActive,
+			&card.Hash,
+			&card.CreatedAt,
+			&card.UpdatedAt,
+			&card.ModuleName,
+		)
+		if err != nil {
+			return nil, err
+		}
+		cards = append(cards, card)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return cards, nil
+}
+
+// DeactivateCardsExcept deactivates active cards of the course whose uids are
+// not listed, i.e. cards whose files were removed, and returns them.
+func (s *Store) DeactivateCardsExcept(ctx context.Context, courseID int, uids []int, updatedAt time.Time) ([]Card, error) {
+	rows, err := s.querier(ctx).Query(ctx, `
+		UPDATE cards SET is_active = FALSE, updated_at = $1
+		WHERE course_id = $2 AND is_active = TRUE AND NOT (uid = ANY($3))
+		RETURNING id, uid, question, module_id, course_id
+	`, updatedAt, courseID, uids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cards []Card
+	for rows.Next() {
+		var card Card
+		err = rows.Scan(&card.ID, &card.UID, &card.Question, &card.ModuleID, &card.CourseID)
+		if err != nil {
+			return nil, err
+		}
+		cards = append(cards, card)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return cards, nil
+}
+
+func (s *Store) GetCardByUUID(ctx context.Context, uuid string) (*Card, error) {
+	card := &Card{}
+	err := s.querier(ctx).QueryRow(ctx, `
+		SELECT id, uid, uuid, question, question_body, answer, tags, module_id, course_id, is_active, hash, created_at, updated_at
+		FROM cards
+		WHERE uuid = $1
+	`, uuid).Scan(
+		&card.ID,
+		&card.UID,
+		&card.UUID,
+		&card.Question,
+		&card.QuestionBody,
+		&card.Answer,
+		&card.Tags,
+		&card.ModuleID,
+		&card.CourseID,
+		&card.IsActive,
+		&card.Hash,
+		&card.CreatedAt,
+		&card.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return card, nil
+}
+
+func (s *Store) GetCardByID(ctx context.Context, id int) (*Card, error) {
+	card := &Card{}
+	err := s.querier(ctx).QueryRow(ctx, `
+		SELECT id, uid, uuid, question, question_body, answer, tags, module_id, course_id, is_active, hash, created_at, updated_at
+		FROM cards
+		WHERE id = $1
+	`, id).Scan(
+		&card.ID,
+		&card.UID,
+		&card.UUID,
+		&card.Question,
+		&card.QuestionBody,
+		&card.Answer,
+		&card.Tags,
+		&card.ModuleID,
+		&card.CourseID,
+		&card.IsActive,
+		&card.Hash,
+		&card.CreatedAt,
+		&card.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return card, nil
 }
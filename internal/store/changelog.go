@@ -0,0 +1,85 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/zagvozdeen/malicious-learning/internal/store/enum"
+)
+
+// changelogLimit is the number of latest entries returned by GetChangelog.
+const changelogLimit = 200
+
+type ChangelogEntry struct {
+	ID                int             `json:"id"`
+	ContentRevisionID int             `json:"content_revision_id"`
+	CourseID          int             `json:"course_id"`
+	ModuleID          int             `json:"module_id"`
+	ModuleName        string          `json:"module_name"`
+	CardUID           int             `json:"card_uid"`
+	Question          string          `json:"question"`
+	Kind              enum.ChangeKind `json:"kind"`
+	CreatedAt         time.Time       `json:"created_at"`
+}
+
+func (s *Store) CreateChangelogEntries(ctx context.Context, entries []ChangelogEntry) error {
+	for i := range entries {
+		err := s.querier(ctx).QueryRow(ctx, `
+			INSERT INTO changelog (content_revision_id, course_id, module_id, card_uid, question, kind, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			RETURNING id
+		`,
+			entries[i].ContentRevisionID,
+			entries[i].CourseID,
+			entries[i].ModuleID,
+			entries[i].CardUID,
+			entries[i].Question,
+			entries[i].Kind,
+			entries[i].CreatedAt,
+		).Scan(&entries[i].ID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Store) GetChangelog(ctx context.Context, courseSlug string) ([]ChangelogEntry, error) {
+	rows, err := s.querier(ctx).Query(ctx, `
+		SELECT cl.id, cl.content_revision_id, cl.course_id, cl.module_id, m.name, cl.card_uid, cl.question, cl.kind, cl.created_at
+		FROM changelog cl
+		JOIN courses co ON co.id = cl.course_id
+		JOIN modules m ON m.id = cl.module_id
+		WHERE co.slug = $1
+		ORDER BY cl.id DESC
+		LIMIT $2
+	`, courseSlug, changelogLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]ChangelogEntry, 0)
+	for rows.Next() {
+		var entry ChangelogEntry
+		err = rows.Scan(
+			&entry.ID,
+			&entry.ContentRevisionID,
+			&entry.CourseID,
+			&entry.ModuleID,
+			&entry.ModuleName,
+			&entry.CardUID,
+			&entry.Question,
+			&entry.Kind,
+			&entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
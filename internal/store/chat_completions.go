@@ -3,33 +3,127 @@ package store
 import (
 	"context"
 	"time"
+
+	"github.com/zagvozdeen/malicious-learning/internal/db/null"
+	"github.com/zagvozdeen/malicious-learning/internal/store/enum"
 )
 
 type ChatCompletions struct {
-	ID               int       `json:"id"`
-	UUID             string    `json:"uuid"`
-	TestSessionID    int       `json:"test_session_id"`
-	Model            string    `json:"model"`
-	CompletionTokens int64     `json:"completion_tokens"`
-	PromptTokens     int64     `json:"prompt_tokens"`
-	TotalTokens      int64     `json:"total_tokens"`
-	Date             int64     `json:"date"`
-	CreatedAt        time.Time `json:"created_at"`
-	UpdatedAt        time.Time `json:"updated_at"`
+	ID   int    `json:"id"`
+	UUID string `json:"uuid"`
+	// UserID is null for completions of the cmd tools.
+	UserID null.Int `json:"user_id"`
+	// OwnerType and OwnerID are the test session or the tutor chat the
+	// completion was made for, the id is null for a tool.
+	OwnerType enum.ChatOwnerType `json:"owner_type"`
+	OwnerID   null.Int           `json:"owner_id"`
+	// Feature is the name of the prompt, e.g. recommendations.
+	Feature string `json:"feature"`
+	Model   string `json:"model"`
+	// PromptVersion is the version of the prompt template, it is null for
+	// completions made before templates.
+	PromptVersion    null.String `json:"prompt_version"`
+	CompletionTokens int64       `json:"completion_tokens"`
+	PromptTokens     int64       `json:"prompt_tokens"`
+	TotalTokens      int64       `json:"total_tokens"`
+	// Cost is the cost by the prices configured at the time of the call.
+	Cost      float64   `json:"cost"`
+	Date      int64     `json:"date"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 func (s *Store) CreateChatCompletions(ctx context.Context, cc *ChatCompletions) error {
 	return s.querier(ctx).QueryRow(
 		ctx,
-		"INSERT INTO chat_completions (uuid, test_session_id, model, completion_tokens, prompt_tokens, total_tokens, date, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id",
+		"INSERT INTO chat_completions (uuid, user_id, owner_type, owner_id, feature, model, prompt_version, completion_tokens, prompt_tokens, total_tokens, cost, date, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14) RETURNING id",
 		cc.UUID,
-		cc.TestSessionID,
+		cc.UserID,
+		cc.OwnerType,
+		cc.OwnerID,
+		cc.Feature,
 		cc.Model,
+		cc.PromptVersion,
 		cc.CompletionTokens,
 		cc.PromptTokens,
 		cc.TotalTokens,
+		cc.Cost,
 		cc.Date,
 		cc.CreatedAt,
 		cc.UpdatedAt,
 	).Scan(&cc.ID)
 }
+
+// LLMTotals are tokens and cost of chat completions.
+type LLMTotals struct {
+	Requests    int64   `json:"requests"`
+	TotalTokens int64   `json:"total_tokens"`
+	Cost        float64 `json:"cost"`
+}
+
+// GetLLMTotals sums chat completions created since the time, of the user when
+// userID is valid and of everyone otherwise.
+func (s *Store) GetLLMTotals(ctx context.Context, since time.Time, userID null.Int) (LLMTotals, error) {
+	var t LLMTotals
+	err := s.querier(ctx).QueryRow(ctx, `
+		SELECT count(*), coalesce(sum(total_tokens), 0), coalesce(sum(cost), 0)::float8
+		FROM chat_completions
+		WHERE created_at >= $1 AND ($2::int IS NULL OR user_id = $2)
+	`, since, userID).Scan(&t.Requests, &t.TotalTokens, &t.Cost)
+	return t, err
+}
+
+// LLMUsage is the usage of a model by a user in a day, the user is null for
+// the cmd tools.
+type LLMUsage struct {
+	Day              time.Time `json:"day"`
+	UserID           null.Int  `json:"user_id"`
+	UserName         string    `json:"user_name"`
+	Model            string    `json:"model"`
+	Requests         int64     `json:"requests"`
+	PromptTokens     int64     `json:"prompt_tokens"`
+	CompletionTokens int64     `json:"completion_tokens"`
+	TotalTokens      int64     `json:"total_tokens"`
+	Cost             float64   `json:"cost"`
+}
+
+// GetLLMUsage returns usage by day, user and model of chat completions
+// created in [from, to), newest days first. Days start at midnight in the
+// location of from, so they match the days of the quotas computed in Go
+// whatever the time zone of the database session is.
+func (s *Store) GetLLMUsage(ctx context.Context, from, to time.Time) ([]LLMUsage, error) {
+	var days []time.Time
+	for d := from; d.Before(to); d = d.AddDate(0, 0, 1) {
+		days = append(days, d)
+	}
+	days = append(days, to)
+	rows, err := s.querier(ctx).Query(ctx, `
+		WITH bounds AS (SELECT d, row_number() OVER (ORDER BY d) AS n FROM unnest($1::timestamptz[]) AS d),
+			days AS (SELECT b.d AS day, e.d AS next FROM bounds b JOIN bounds e ON e.n = b.n + 1)
+		SELECT days.day, cc.user_id, concat_ws(' ', u.first_name, u.last_name), cc.model,
+			count(*), sum(cc.prompt_tokens), sum(cc.completion_tokens), sum(cc.total_tokens), sum(cc.cost)::float8
+		FROM chat_completions cc
+		JOIN days ON cc.created_at >= days.day AND cc.created_at < days.next
+		LEFT JOIN users u ON u.id = cc.user_id
+		GROUP BY days.day, cc.user_id, u.first_name, u.last_name, cc.model
+		ORDER BY days.day DESC, sum(cc.cost) DESC, cc.user_id NULLS LAST, cc.model
+	`, days)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	usage := make([]LLMUsage, 0)
+	for rows.Next() {
+		var u LLMUsage
+		err = rows.Scan(&u.Day, &u.UserID, &u.UserName, &u.Model, &u.Requests, &u.PromptTokens, &u.CompletionTokens, &u.TotalTokens, &u.Cost)
+		if err != nil {
+			return nil, err
+		}
+		u.Day = u.Day.In(from.Location())
+		usage = append(usage, u)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return usage, nil
+}
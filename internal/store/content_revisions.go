@@ -0,0 +1,38 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+type ContentRevision struct {
+	ID               int       `json:"id"`
+	Hash             string    `json:"hash"`
+	DurationMs       int64     `json:"duration_ms"`
+	CardsTotal       int       `json:"cards_total"`
+	CardsCreated     int       `json:"cards_created"`
+	CardsDeactivated int       `json:"cards_deactivated"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// LockContent takes the transaction-level advisory lock guarding content
+// conversion, so instances started together convert content one by one.
+func (s *Store) LockContent(ctx context.Context) (err error) {
+	_, err = s.querier(ctx).Exec(ctx, "SELECT pg_advisory_xact_lock(hashtext('content_conversion'))")
+	return
+}
+
+func (s *Store) CreateContentRevision(ctx context.Context, revision *ContentRevision) error {
+	return s.querier(ctx).QueryRow(ctx, `
+		INSERT INTO content_revisions (hash, duration_ms, cards_total, cards_created, cards_deactivated, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`,
+		revision.Hash,
+		revision.DurationMs,
+		revision.CardsTotal,
+		revision.CardsCreated,
+		revision.CardsDeactivated,
+		revision.CreatedAt,
+	).Scan(&revision.ID)
+}
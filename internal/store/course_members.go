@@ -0,0 +1,115 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/zagvozdeen/malicious-learning/internal/db/null"
+)
+
+// CourseMember is a user a private deck is shared with. The deck is shown to
+// the member after they accept the invite.
+type CourseMember struct {
+	UserID     int         `json:"user_id"`
+	FirstName  string      `json:"first_name"`
+	Username   null.String `json:"username"`
+	AcceptedAt *time.Time  `json:"accepted_at"`
+	CreatedAt  time.Time   `json:"created_at"`
+}
+
+// DeckInvite is a private deck shared with the user and not accepted yet.
+type DeckInvite struct {
+	CourseSlug     string      `json:"course_slug"`
+	CourseName     string      `json:"course_name"`
+	OwnerFirstName string      `json:"owner_first_name"`
+	OwnerUsername  null.String `json:"owner_username"`
+	CreatedAt      time.Time   `json:"created_at"`
+}
+
+func (s *Store) AddCourseMember(ctx context.Context, courseID, userID int, createdAt time.Time) error {
+	_, err := s.querier(ctx).Exec(ctx, `
+		INSERT INTO course_members (course_id, user_id, created_at) VALUES ($1, $2, $3)
+		ON CONFLICT DO NOTHING
+	`, courseID, userID, createdAt)
+	return err
+}
+
+// AcceptCourseMember accepts the invite of the user to the deck, it returns
+// pgx.ErrNoRows when there is no pending invite.
+func (s *Store) AcceptCourseMember(ctx context.Context, courseID, userID int, acceptedAt time.Time) error {
+	tag, err := s.querier(ctx).Exec(ctx, `
+		UPDATE course_members SET accepted_at = $3
+		WHERE course_id = $1 AND user_id = $2 AND accepted_at IS NULL
+	`, courseID, userID, acceptedAt)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+func (s *Store) RemoveCourseMember(ctx context.Context, courseID, userID int) error {
+	_, err := s.querier(ctx).Exec(ctx, `
+		DELETE FROM course_members WHERE course_id = $1 AND user_id = $2
+	`, courseID, userID)
+	return err
+}
+
+func (s *Store) GetCourseMembers(ctx context.Context, courseID int) ([]CourseMember, error) {
+	rows, err := s.querier(ctx).Query(ctx, `
+		SELECT u.id, u.first_name, u.username, cm.accepted_at, cm.created_at
+		FROM course_members cm
+		JOIN users u ON u.id = cm.user_id
+		WHERE cm.course_id = $1
+		ORDER BY cm.created_at, u.id
+	`, courseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	members := make([]CourseMember, 0)
+	for rows.Next() {
+		var m CourseMember
+		err = rows.Scan(&m.UserID, &m.FirstName, &m.Username, &m.AcceptedAt, &m.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, m)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// GetDeckInvites returns decks shared with the user that wait for an answer.
+func (s *Store) GetDeckInvites(ctx context.Context, userID int) ([]DeckInvite, error) {
+	rows, err := s.querier(ctx).Query(ctx, `
+		SELECT co.slug, co.name, u.first_name, u.username, cm.created_at
+		FROM course_members cm
+		JOIN courses co ON co.id = cm.course_id
+		JOIN users u ON u.id = co.owner_id
+		WHERE cm.user_id = $1 AND cm.accepted_at IS NULL
+		ORDER BY cm.created_at, co.id
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	invites := make([]DeckInvite, 0)
+	for rows.Next() {
+		var invite DeckInvite
+		err = rows.Scan(&invite.CourseSlug, &invite.CourseName, &invite.OwnerFirstName, &invite.OwnerUsername, &invite.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		invites = append(invites, invite)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return invites, nil
+}
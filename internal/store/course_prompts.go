@@ -0,0 +1,42 @@
+package store
+
+import (
+	"context"
+)
+
+// CoursePrompt is a prompt template overridden by the course.
+type CoursePrompt struct {
+	Name     string
+	Version  string
+	Template string
+}
+
+// ReplaceCoursePrompts replaces prompt overrides of the course.
+func (s *Store) ReplaceCoursePrompts(ctx context.Context, courseID int, prompts []CoursePrompt) error {
+	_, err := s.querier(ctx).Exec(ctx, "DELETE FROM course_prompts WHERE course_id = $1", courseID)
+	if err != nil {
+		return err
+	}
+	for _, p := range prompts {
+		_, err = s.querier(ctx).Exec(ctx, `
+			INSERT INTO course_prompts (course_id, name, version, template) VALUES ($1, $2, $3, $4)
+		`, courseID, p.Name, p.Version, p.Template)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetCoursePrompt returns the override of the prompt, pgx.ErrNoRows means the
+// course uses the default template.
+func (s *Store) GetCoursePrompt(ctx context.Context, courseID int, name string) (*CoursePrompt, error) {
+	p := &CoursePrompt{}
+	err := s.querier(ctx).QueryRow(ctx, `
+		SELECT name, version, template FROM course_prompts WHERE course_id = $1 AND name = $2
+	`, courseID, name).Scan(&p.Name, &p.Version, &p.Template)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
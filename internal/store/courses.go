@@ -3,34 +3,68 @@ package store
 import (
 	"context"
 	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/zagvozdeen/malicious-learning/internal/db/null"
 )
 
 type Course struct {
-	ID        int       `json:"id"`
-	UUID      string    `json:"uuid"`
-	Slug      string    `json:"slug"`
-	Name      string    `json:"name"`
+	ID   int    `json:"id"`
+	UUID string `json:"uuid"`
+	Slug string `json:"slug"`
+	Name string `json:"name"`
+	// OwnerID is set for private decks uploaded by users, courses of
+	// data/courses have no owner.
+	OwnerID   null.Int  `json:"owner_id"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
+// courseAccessible is the condition of the course aliased co being visible
+// to the user passed as the parameter. Members see a deck once they accept
+// the invite.
+func courseAccessible(param string) string {
+	return "(co.owner_id IS NULL OR co.owner_id = " + param +
+		" OR EXISTS (SELECT 1 FROM course_members cm WHERE cm.course_id = co.id AND cm.user_id = " + param + " AND cm.accepted_at IS NOT NULL))"
+}
+
 func (s *Store) GetCourses(ctx context.Context) ([]Course, error) {
 	rows, err := s.querier(ctx).Query(
 		ctx,
-		"SELECT id, uuid, slug, name, created_at, updated_at FROM courses ORDER BY id",
+		"SELECT id, uuid, slug, name, owner_id, created_at, updated_at FROM courses ORDER BY id",
 	)
 	if err != nil {
 		return nil, err
 	}
+	return scanCourses(rows)
+}
+
+// GetCoursesForUser returns public courses and private decks the user owns or
+// has accepted the invite to.
+func (s *Store) GetCoursesForUser(ctx context.Context, userID int) ([]Course, error) {
+	rows, err := s.querier(ctx).Query(ctx, `
+		SELECT co.id, co.uuid, co.slug, co.name, co.owner_id, co.created_at, co.updated_at
+		FROM courses co
+		WHERE `+courseAccessible("$1")+`
+		ORDER BY co.owner_id NULLS FIRST, co.id
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	return scanCourses(rows)
+}
+
+func scanCourses(rows pgx.Rows) ([]Course, error) {
 	defer rows.Close()
 	courses := make([]Course, 0)
 	for rows.Next() {
 		var course Course
-		err = rows.Scan(
+		err := rows.Scan(
 			&course.ID,
 			&course.UUID,
 			&course.Slug,
 			&course.Name,
+			&course.OwnerID,
 			&course.CreatedAt,
 			&course.UpdatedAt,
 		)
@@ -39,7 +73,7 @@ func (s *Store) GetCourses(ctx context.Context) ([]Course, error) {
 		}
 		courses = append(courses, course)
 	}
-	if err = rows.Err(); err != nil {
+	if err := rows.Err(); err != nil {
 		return nil, err
 	}
 	return courses, nil
@@ -49,16 +83,47 @@ func (s *Store) GetCourseBySlug(ctx context.Context, slug string) (*Course, erro
 	course := &Course{}
 	err := s.querier(ctx).QueryRow(
 		ctx,
-		"SELECT id, uuid, slug, name, created_at, updated_at FROM courses WHERE slug = $1",
+		"SELECT id, uuid, slug, name, owner_id, created_at, updated_at FROM courses WHERE slug = $1",
 		slug,
-	).Scan(&course.ID, &course.UUID, &course.Slug, &course.Name, &course.CreatedAt, &course.UpdatedAt)
+	).Scan(&course.ID, &course.UUID, &course.Slug, &course.Name, &course.OwnerID, &course.CreatedAt, &course.UpdatedAt)
 	return course, err
 }
 
-func (s *Store) CreateCourse(ctx context.Context, course *Course) error {
-	return s.querier(ctx).QueryRow(
+func (s *Store) GetCourseByID(ctx context.Context, id int) (*Course, error) {
+	course := &Course{}
+	err := s.querier(ctx).QueryRow(
 		ctx,
-		"INSERT INTO courses (uuid, slug, name, created_at, updated_at) VALUES ($1, $2, $3, $4, $5) RETURNING id",
-		course.UUID, course.Slug, course.Name, course.CreatedAt, course.UpdatedAt,
-	).Scan(&course.ID)
+		"SELECT id, uuid, slug, name, owner_id, created_at, updated_at FROM courses WHERE id = $1",
+		id,
+	).Scan(&course.ID, &course.UUID, &course.Slug, &course.Name, &course.OwnerID, &course.CreatedAt, &course.UpdatedAt)
+	return course, err
+}
+
+// HasCourseAccess reports whether the course is public or the user owns it or
+// is a member of it.
+func (s *Store) HasCourseAccess(ctx context.Context, courseID, userID int) (bool, error) {
+	var ok bool
+	err := s.querier(ctx).QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM courses co WHERE co.id = $1 AND `+courseAccessible("$2")+`)
+	`, courseID, userID).Scan(&ok)
+	return ok, err
+}
+
+// CreateCourse inserts the course, it returns pgx.ErrNoRows when a course with
+// the same slug already exists.
+func (s *Store) CreateCourse(ctx context.Context, course *Course) error {
+	return s.querier(ctx).QueryRow(ctx, `
+		INSERT INTO courses (uuid, slug, name, owner_id, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (slug) DO NOTHING
+		RETURNING id, uuid, name, owner_id, created_at, updated_at
+	`,
+		course.UUID, course.Slug, course.Name, course.OwnerID, course.CreatedAt, course.UpdatedAt,
+	).Scan(&course.ID, &course.UUID, &course.Name, &course.OwnerID, &course.CreatedAt, &course.UpdatedAt)
+}
+
+func (s *Store) UpdateCourseName(ctx context.Context, course *Course) error {
+	_, err := s.querier(ctx).Exec(ctx, `
+		UPDATE courses SET name = $1, updated_at = $2 WHERE id = $3
+	`, course.Name, course.UpdatedAt, course.ID)
+	return err
 }
@@ -0,0 +1,77 @@
+package enum
+
+import (
+	"database/sql/driver"
+	"encoding/json/jsontext"
+	"errors"
+	"fmt"
+)
+
+type ChangeKind struct {
+	slug  string
+	title string
+}
+
+func NewChangeKind(s string) (ChangeKind, error) {
+	switch s {
+	case ChangeKindAdded.slug:
+		return ChangeKindAdded, nil
+	case ChangeKindChanged.slug:
+		return ChangeKindChanged, nil
+	case ChangeKindRemoved.slug:
+		return ChangeKindRemoved, nil
+	default:
+		return ChangeKind{}, fmt.Errorf("unknown change kind: %s", s)
+	}
+}
+
+var (
+	ChangeKindAdded   = ChangeKind{"added", "Добавлено"}
+	ChangeKindChanged = ChangeKind{"changed", "Обновлено"}
+	ChangeKindRemoved = ChangeKind{"removed", "Удалено"}
+)
+
+func (c ChangeKind) String() string {
+	return c.slug
+}
+
+func (c ChangeKind) Title() string {
+	return c.title
+}
+
+func (c *ChangeKind) Scan(src any) error {
+	s, ok := src.(string)
+	if !ok {
+		return errors.New("can not assert change kind to string")
+	}
+	r, err := NewChangeKind(s)
+	if err != nil {
+		return err
+	}
+	*c = r
+	return nil
+}
+
+func (c ChangeKind) Value() (driver.Value, error) {
+	return c.String(), nil
+}
+
+func (c ChangeKind) MarshalJSONTo(enc *jsontext.Encoder) error {
+	return enc.WriteToken(jsontext.String(c.slug))
+}
+
+func (c *ChangeKind) UnmarshalJSONFrom(dec *jsontext.Decoder) error {
+	tok, err := dec.ReadToken()
+	if err != nil {
+		return err
+	}
+	if tok.Kind() != '"' {
+		return errors.New("change kind must be a JSON string")
+	}
+	e, err := NewChangeKind(tok.String())
+	if err != nil {
+		return err
+	}
+	*c = e
+	return nil
+}
@@ -0,0 +1,78 @@
+package enum
+
+import (
+	"database/sql/driver"
+	"encoding/json/jsontext"
+	"errors"
+	"fmt"
+)
+
+type ChatOwnerType struct {
+	slug  string
+	title string
+}
+
+func NewChatOwnerType(s string) (ChatOwnerType, error) {
+	switch s {
+	case ChatOwnerTypeTestSession.slug:
+		return ChatOwnerTypeTestSession, nil
+	case ChatOwnerTypeTutorChat.slug:
+		return ChatOwnerTypeTutorChat, nil
+	case ChatOwnerTypeTool.slug:
+		return ChatOwnerTypeTool, nil
+	default:
+		return ChatOwnerType{}, fmt.Errorf("unknown chat owner type: %s", s)
+	}
+}
+
+var (
+	ChatOwnerTypeTestSession = ChatOwnerType{"test_session", "Тестовая сессия"}
+	ChatOwnerTypeTutorChat   = ChatOwnerType{"tutor_chat", "Чат с репетитором"}
+	// ChatOwnerTypeTool is a cmd tool, its completions have no owner id.
+	ChatOwnerTypeTool = ChatOwnerType{"tool", "Утилита"}
+)
+
+func (d ChatOwnerType) String() string {
+	return d.slug
+}
+
+func (d ChatOwnerType) Title() string {
+	return d.title
+}
+
+func (d *ChatOwnerType) Scan(src any) error {
+	s, ok := src.(string)
+	if !ok {
+		return errors.New("can not assert chat owner type to string")
+	}
+	r, err := NewChatOwnerType(s)
+	if err != nil {
+		return err
+	}
+	*d = r
+	return nil
+}
+
+func (d ChatOwnerType) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+func (d ChatOwnerType) MarshalJSONTo(enc *jsontext.Encoder) error {
+	return enc.WriteToken(jsontext.String(d.slug))
+}
+
+func (d *ChatOwnerType) UnmarshalJSONFrom(dec *jsontext.Decoder) error {
+	tok, err := dec.ReadToken()
+	if err != nil {
+		return err
+	}
+	if tok.Kind() != '"' {
+		return errors.New("chat owner type must be a JSON string")
+	}
+	e, err := NewChatOwnerType(tok.String())
+	if err != nil {
+		return err
+	}
+	*d = e
+	return nil
+}
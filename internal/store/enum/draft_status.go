@@ -0,0 +1,85 @@
+package enum
+
+import (
+	"database/sql/driver"
+	"encoding/json/jsontext"
+	"errors"
+	"fmt"
+)
+
+type DraftStatus struct {
+	slug  string
+	title string
+}
+
+func NewDraftStatus(s string) (DraftStatus, error) {
+	switch s {
+	case DraftStatusDraft.slug:
+		return DraftStatusDraft, nil
+	case DraftStatusPublished.slug:
+		return DraftStatusPublished, nil
+	case DraftStatusLanded.slug:
+		return DraftStatusLanded, nil
+	case DraftStatusSuperseded.slug:
+		return DraftStatusSuperseded, nil
+	case DraftStatusDiscarded.slug:
+		return DraftStatusDiscarded, nil
+	default:
+		return DraftStatus{}, fmt.Errorf("unknown draft status: %s", s)
+	}
+}
+
+var (
+	DraftStatusDraft     = DraftStatus{"draft", "Черновик"}
+	DraftStatusPublished = DraftStatus{"published", "Опубликован"}
+	// DraftStatusLanded is a published draft found in data/courses, the
+	// repository is the source of the card again.
+	DraftStatusLanded     = DraftStatus{"landed", "В репозитории"}
+	DraftStatusSuperseded = DraftStatus{"superseded", "Заменён"}
+	DraftStatusDiscarded  = DraftStatus{"discarded", "Отменён"}
+)
+
+func (d DraftStatus) String() string {
+	return d.slug
+}
+
+func (d DraftStatus) Title() string {
+	return d.title
+}
+
+func (d *DraftStatus) Scan(src any) error {
+	s, ok := src.(string)
+	if !ok {
+		return errors.New("can not assert draft status to string")
+	}
+	r, err := NewDraftStatus(s)
+	if err != nil {
+		return err
+	}
+	*d = r
+	return nil
+}
+
+func (d DraftStatus) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+func (d DraftStatus) MarshalJSONTo(enc *jsontext.Encoder) error {
+	return enc.WriteToken(jsontext.String(d.slug))
+}
+
+func (d *DraftStatus) UnmarshalJSONFrom(dec *jsontext.Decoder) error {
+	tok, err := dec.ReadToken()
+	if err != nil {
+		return err
+	}
+	if tok.Kind() != '"' {
+		return errors.New("draft status must be a JSON string")
+	}
+	e, err := NewDraftStatus(tok.String())
+	if err != nil {
+		return err
+	}
+	*d = e
+	return nil
+}
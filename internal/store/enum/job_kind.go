@@ -0,0 +1,71 @@
+package enum
+
+import (
+	"database/sql/driver"
+	"encoding/json/jsontext"
+	"errors"
+	"fmt"
+)
+
+type JobKind struct {
+	slug  string
+	title string
+}
+
+func NewJobKind(s string) (JobKind, error) {
+	switch s {
+	case JobKindRecommendations.slug:
+		return JobKindRecommendations, nil
+	default:
+		return JobKind{}, fmt.Errorf("unknown job kind: %s", s)
+	}
+}
+
+var (
+	JobKindRecommendations = JobKind{"recommendations", "Рекомендации"}
+)
+
+func (d JobKind) String() string {
+	return d.slug
+}
+
+func (d JobKind) Title() string {
+	return d.title
+}
+
+func (d *JobKind) Scan(src any) error {
+	s, ok := src.(string)
+	if !ok {
+		return errors.New("can not assert job kind to string")
+	}
+	r, err := NewJobKind(s)
+	if err != nil {
+		return err
+	}
+	*d = r
+	return nil
+}
+
+func (d JobKind) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+func (d JobKind) MarshalJSONTo(enc *jsontext.Encoder) error {
+	return enc.WriteToken(jsontext.String(d.slug))
+}
+
+func (d *JobKind) UnmarshalJSONFrom(dec *jsontext.Decoder) error {
+	tok, err := dec.ReadToken()
+	if err != nil {
+		return err
+	}
+	if tok.Kind() != '"' {
+		return errors.New("job kind must be a JSON string")
+	}
+	e, err := NewJobKind(tok.String())
+	if err != nil {
+		return err
+	}
+	*d = e
+	return nil
+}
@@ -0,0 +1,80 @@
+package enum
+
+import (
+	"database/sql/driver"
+	"encoding/json/jsontext"
+	"errors"
+	"fmt"
+)
+
+type JobStatus struct {
+	slug  string
+	title string
+}
+
+func NewJobStatus(s string) (JobStatus, error) {
+	switch s {
+	case JobStatusPending.slug:
+		return JobStatusPending, nil
+	case JobStatusRunning.slug:
+		return JobStatusRunning, nil
+	case JobStatusDone.slug:
+		return JobStatusDone, nil
+	case JobStatusDead.slug:
+		return JobStatusDead, nil
+	default:
+		return JobStatus{}, fmt.Errorf("unknown job status: %s", s)
+	}
+}
+
+var (
+	JobStatusPending = JobStatus{"pending", "В очереди"}
+	JobStatusRunning = JobStatus{"running", "Выполняется"}
+	JobStatusDone    = JobStatus{"done", "Готово"}
+	JobStatusDead    = JobStatus{"dead", "Не выполнено"}
+)
+
+func (d JobStatus) String() string {
+	return d.slug
+}
+
+func (d JobStatus) Title() string {
+	return d.title
+}
+
+func (d *JobStatus) Scan(src any) error {
+	s, ok := src.(string)
+	if !ok {
+		return errors.New("can not assert job status to string")
+	}
+	r, err := NewJobStatus(s)
+	if err != nil {
+		return err
+	}
+	*d = r
+	return nil
+}
+
+func (d JobStatus) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+func (d JobStatus) MarshalJSONTo(enc *jsontext.Encoder) error {
+	return enc.WriteToken(jsontext.String(d.slug))
+}
+
+func (d *JobStatus) UnmarshalJSONFrom(dec *jsontext.Decoder) error {
+	tok, err := dec.ReadToken()
+	if err != nil {
+		return err
+	}
+	if tok.Kind() != '"' {
+		return errors.New("job status must be a JSON string")
+	}
+	e, err := NewJobStatus(tok.String())
+	if err != nil {
+		return err
+	}
+	*d = e
+	return nil
+}
@@ -0,0 +1,92 @@
+package enum
+
+import (
+	"database/sql/driver"
+	"encoding/json/jsontext"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type Locale struct {
+	slug  string
+	title string
+}
+
+func NewLocale(s string) (Locale, error) {
+	switch s {
+	case LocaleRu.slug:
+		return LocaleRu, nil
+	case LocaleEn.slug:
+		return LocaleEn, nil
+	default:
+		return Locale{}, fmt.Errorf("unknown locale: %s", s)
+	}
+}
+
+var (
+	LocaleRu = Locale{"ru", "Русский"}
+	LocaleEn = Locale{"en", "English"}
+
+	// DefaultLocale is the language course files are written in, other
+	// locales fall back to it.
+	DefaultLocale = LocaleRu
+	Locales       = []Locale{LocaleRu, LocaleEn}
+)
+
+// LocaleFromLanguageCode maps an IETF language tag, as sent by Telegram in
+// language_code, to a supported locale.
+func LocaleFromLanguageCode(code string) (Locale, bool) {
+	lang, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(code)), "-")
+	l, err := NewLocale(lang)
+	return l, err == nil
+}
+
+func (l Locale) String() string {
+	return l.slug
+}
+
+func (l Locale) Title() string {
+	return l.title
+}
+
+func (l Locale) IsDefault() bool {
+	return l == DefaultLocale
+}
+
+func (l *Locale) Scan(src any) error {
+	s, ok := src.(string)
+	if !ok {
+		return errors.New("can not assert locale to string")
+	}
+	r, err := NewLocale(s)
+	if err != nil {
+		return err
+	}
+	*l = r
+	return nil
+}
+
+func (l Locale) Value() (driver.Value, error) {
+	return l.String(), nil
+}
+
+func (l Locale) MarshalJSONTo(enc *jsontext.Encoder) error {
+	return enc.WriteToken(jsontext.String(l.slug))
+}
+
+func (l *Locale) UnmarshalJSONFrom(dec *jsontext.Decoder) error {
+	tok, err := dec.ReadToken()
+	if err != nil {
+		return err
+	}
+	if tok.Kind() != '"' {
+		return errors.New("locale must be a JSON string")
+	}
+	e, err := NewLocale(tok.String())
+	if err != nil {
+		return err
+	}
+	*l = e
+	return nil
+}
@@ -0,0 +1,74 @@
+package enum
+
+import (
+	"database/sql/driver"
+	"encoding/json/jsontext"
+	"errors"
+	"fmt"
+)
+
+type MessageRole struct {
+	slug  string
+	title string
+}
+
+func NewMessageRole(s string) (MessageRole, error) {
+	switch s {
+	case MessageRoleUser.slug:
+		return MessageRoleUser, nil
+	case MessageRoleAssistant.slug:
+		return MessageRoleAssistant, nil
+	default:
+		return MessageRole{}, fmt.Errorf("unknown message role: %s", s)
+	}
+}
+
+var (
+	MessageRoleUser      = MessageRole{"user", "Пользователь"}
+	MessageRoleAssistant = MessageRole{"assistant", "Ассистент"}
+)
+
+func (d MessageRole) String() string {
+	return d.slug
+}
+
+func (d MessageRole) Title() string {
+	return d.title
+}
+
+func (d *MessageRole) Scan(src any) error {
+	s, ok := src.(string)
+	if !ok {
+		return errors.New("can not assert message role to string")
+	}
+	r, err := NewMessageRole(s)
+	if err != nil {
+		return err
+	}
+	*d = r
+	return nil
+}
+
+func (d MessageRole) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+func (d MessageRole) MarshalJSONTo(enc *jsontext.Encoder) error {
+	return enc.WriteToken(jsontext.String(d.slug))
+}
+
+func (d *MessageRole) UnmarshalJSONFrom(dec *jsontext.Decoder) error {
+	tok, err := dec.ReadToken()
+	if err != nil {
+		return err
+	}
+	if tok.Kind() != '"' {
+		return errors.New("message role must be a JSON string")
+	}
+	e, err := NewMessageRole(tok.String())
+	if err != nil {
+		return err
+	}
+	*d = e
+	return nil
+}
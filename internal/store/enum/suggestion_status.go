@@ -0,0 +1,77 @@
+package enum
+
+import (
+	"database/sql/driver"
+	"encoding/json/jsontext"
+	"errors"
+	"fmt"
+)
+
+type SuggestionStatus struct {
+	slug  string
+	title string
+}
+
+func NewSuggestionStatus(s string) (SuggestionStatus, error) {
+	switch s {
+	case SuggestionStatusPending.slug:
+		return SuggestionStatusPending, nil
+	case SuggestionStatusAccepted.slug:
+		return SuggestionStatusAccepted, nil
+	case SuggestionStatusRejected.slug:
+		return SuggestionStatusRejected, nil
+	default:
+		return SuggestionStatus{}, fmt.Errorf("unknown suggestion status: %s", s)
+	}
+}
+
+var (
+	SuggestionStatusPending  = SuggestionStatus{"pending", "На проверке"}
+	SuggestionStatusAccepted = SuggestionStatus{"accepted", "Принято"}
+	SuggestionStatusRejected = SuggestionStatus{"rejected", "Отклонено"}
+)
+
+func (d SuggestionStatus) String() string {
+	return d.slug
+}
+
+func (d SuggestionStatus) Title() string {
+	return d.title
+}
+
+func (d *SuggestionStatus) Scan(src any) error {
+	s, ok := src.(string)
+	if !ok {
+		return errors.New("can not assert suggestion status to string")
+	}
+	r, err := NewSuggestionStatus(s)
+	if err != nil {
+		return err
+	}
+	*d = r
+	return nil
+}
+
+func (d SuggestionStatus) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+func (d SuggestionStatus) MarshalJSONTo(enc *jsontext.Encoder) error {
+	return enc.WriteToken(jsontext.String(d.slug))
+}
+
+func (d *SuggestionStatus) UnmarshalJSONFrom(dec *jsontext.Decoder) error {
+	tok, err := dec.ReadToken()
+	if err != nil {
+		return err
+	}
+	if tok.Kind() != '"' {
+		return errors.New("suggestion status must be a JSON string")
+	}
+	e, err := NewSuggestionStatus(tok.String())
+	if err != nil {
+		return err
+	}
+	*d = e
+	return nil
+}
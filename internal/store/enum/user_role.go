@@ -0,0 +1,84 @@
+package enum
+
+import (
+	"database/sql/driver"
+	"encoding/json/jsontext"
+	"errors"
+	"fmt"
+)
+
+type UserRole struct {
+	slug  string
+	title string
+}
+
+func NewUserRole(s string) (UserRole, error) {
+	switch s {
+	case UserRoleUser.slug:
+		return UserRoleUser, nil
+	case UserRoleEditor.slug:
+		return UserRoleEditor, nil
+	case UserRoleAdmin.slug:
+		return UserRoleAdmin, nil
+	default:
+		return UserRole{}, fmt.Errorf("unknown user role: %s", s)
+	}
+}
+
+var (
+	UserRoleUser = UserRole{"user", "Пользователь"}
+	// UserRoleEditor can author cards in the app.
+	UserRoleEditor = UserRole{"editor", "Редактор"}
+	// UserRoleAdmin is an editor who also sees usage and spend of the app.
+	UserRoleAdmin = UserRole{"admin", "Администратор"}
+)
+
+func (r UserRole) String() string {
+	return r.slug
+}
+
+func (r UserRole) Title() string {
+	return r.title
+}
+
+// CanEdit reports whether the role may author cards and review suggestions.
+func (r UserRole) CanEdit() bool {
+	return r == UserRoleEditor || r == UserRoleAdmin
+}
+
+func (r *UserRole) Scan(src any) error {
+	s, ok := src.(string)
+	if !ok {
+		return errors.New("can not assert user role to string")
+	}
+	role, err := NewUserRole(s)
+	if err != nil {
+		return err
+	}
+	*r = role
+	return nil
+}
+
+func (r UserRole) Value() (driver.Value, error) {
+	return r.String(), nil
+}
+
+func (r UserRole) MarshalJSONTo(enc *jsontext.Encoder) error {
+	return enc.WriteToken(jsontext.String(r.slug))
+}
+
+func (r *UserRole) UnmarshalJSONFrom(dec *jsontext.Decoder) error {
+	tok, err := dec.ReadToken()
+	if err != nil {
+		return err
+	}
+	if tok.Kind() != '"' {
+		return errors.New("user role must be a JSON string")
+	}
+	e, err := NewUserRole(tok.String())
+	if err != nil {
+		return err
+	}
+	*r = e
+	return nil
+}
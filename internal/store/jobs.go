@@ -0,0 +1,106 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/zagvozdeen/malicious-learning/internal/db/null"
+	"github.com/zagvozdeen/malicious-learning/internal/store/enum"
+)
+
+// Job is background work of the queue in the jobs table.
+type Job struct {
+	ID   int          `json:"id"`
+	UUID string       `json:"uuid"`
+	Kind enum.JobKind `json:"kind"`
+	// Key is the subject of the job, e.g. the test session id.
+	Key         string         `json:"key"`
+	Status      enum.JobStatus `json:"status"`
+	Attempts    int            `json:"attempts"`
+	MaxAttempts int            `json:"max_attempts"`
+	RunAt       time.Time      `json:"run_at"`
+	LockedAt    *time.Time     `json:"locked_at"`
+	LastError   null.String    `json:"last_error"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+const jobColumns = `
+	id, uuid, kind, key, status, attempts, max_attempts, run_at, locked_at, last_error, created_at, updated_at`
+
+func scanJob(row pgx.Row, job *Job) error {
+	return row.Scan(
+		&job.ID,
+		&job.UUID,
+		&job.Kind,
+		&job.Key,
+		&job.Status,
+		&job.Attempts,
+		&job.MaxAttempts,
+		&job.RunAt,
+		&job.LockedAt,
+		&job.LastError,
+		&job.CreatedAt,
+		&job.UpdatedAt,
+	)
+}
+
+// CreateJob enqueues the job and reports whether it was created, it is not
+// when a job of the same kind and key is already pending or running.
+func (s *Store) CreateJob(ctx context.Context, job *Job) (bool, error) {
+	err := s.querier(ctx).QueryRow(ctx, `
+		INSERT INTO jobs (uuid, kind, key, status, attempts, max_attempts, run_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (kind, key) WHERE status IN ('pending', 'running') DO NOTHING
+		RETURNING id
+	`,
+		job.UUID,
+		job.Kind,
+		job.Key,
+		job.Status,
+		job.Attempts,
+		job.MaxAttempts,
+		job.RunAt,
+		job.CreatedAt,
+		job.UpdatedAt,
+	).Scan(&job.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ClaimJob locks the next due job as running and counts the attempt. Jobs
+// left running since staleBefore, e.g. by a restart, are claimed again. It
+// returns pgx.ErrNoRows when there is nothing to run.
+func (s *Store) ClaimJob(ctx context.Context, now, staleBefore time.Time) (*Job, error) {
+	job := &Job{}
+	err := scanJob(s.querier(ctx).QueryRow(ctx, `
+		UPDATE jobs SET status = 'running', attempts = attempts + 1, locked_at = $1, updated_at = $1
+		WHERE id = (
+			SELECT id FROM jobs
+			WHERE (status = 'pending' AND run_at <= $1) OR (status = 'running' AND locked_at <= $2)
+			ORDER BY run_at, id
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+jobColumns+`
+	`, now, staleBefore), job)
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+func (s *Store) UpdateJob(ctx context.Context, job *Job) error {
+	_, err := s.querier(ctx).Exec(ctx, `
+		UPDATE jobs SET status = $1, attempts = $2, run_at = $3, locked_at = $4, last_error = $5, updated_at = $6
+		WHERE id = $7
+	`, job.Status, job.Attempts, job.RunAt, job.LockedAt, job.LastError, job.UpdatedAt, job.ID)
+	return err
+}
@@ -3,19 +3,25 @@ package store
 import (
 	"context"
 	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/zagvozdeen/malicious-learning/internal/db/null"
 )
 
+// Module groups cards. Modules of public courses are shared by name, modules
+// of a private deck belong to the deck and have its CourseID.
 type Module struct {
 	ID        int       `json:"id"`
 	UUID      string    `json:"uuid"`
 	Name      string    `json:"name"`
+	CourseID  null.Int  `json:"course_id"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
 func (s *Store) GetModulesByCourseSlug(ctx context.Context, slug string) ([]Module, error) {
 	rows, err := s.querier(ctx).Query(ctx, `
-		SELECT DISTINCT m.id, m.uuid, m.name, m.created_at, m.updated_at
+		SELECT DISTINCT m.id, m.uuid, m.name, m.course_id, m.created_at, m.updated_at
 		FROM modules m
 		JOIN cards c ON c.module_id = m.id
 		JOIN courses co ON co.id = c.course_id
@@ -34,6 +40,7 @@ func (s *Store) GetModulesByCourseSlug(ctx context.Context, slug string) ([]Modu
 			&module.ID,
 			&module.UUID,
 			&module.Name,
+			&module.CourseID,
 			&module.CreatedAt,
 			&module.UpdatedAt,
 		)
@@ -48,16 +55,19 @@ func (s *Store) GetModulesByCourseSlug(ctx context.Context, slug string) ([]Modu
 	return modules, nil
 }
 
-func (s *Store) GetModuleByName(ctx context.Context, name string) (*Module, error) {
+// GetModuleByName finds the module of the deck with courseID, or the shared
+// module when courseID is not valid.
+func (s *Store) GetModuleByName(ctx context.Context, name string, courseID null.Int) (*Module, error) {
 	module := &Module{}
 	err := s.querier(ctx).QueryRow(
 		ctx,
-		"SELECT id, uuid, name, created_at, updated_at FROM modules WHERE name = $1",
-		name,
+		"SELECT id, uuid, name, course_id, created_at, updated_at FROM modules WHERE name = $1 AND course_id IS NOT DISTINCT FROM $2",
+		name, courseID,
 	).Scan(
 		&module.ID,
 		&module.UUID,
 		&module.Name,
+		&module.CourseID,
 		&module.CreatedAt,
 		&module.UpdatedAt,
 	)
@@ -67,10 +77,27 @@ func (s *Store) GetModuleByName(ctx context.Context, name string) (*Module, erro
 	return module, nil
 }
 
+// CreateModule inserts the module or loads the existing one with the same name
+// and course.
 func (s *Store) CreateModule(ctx context.Context, module *Module) error {
-	return s.querier(ctx).QueryRow(
-		ctx,
-		"INSERT INTO modules (uuid, name, created_at, updated_at) VALUES ($1, $2, $3, $4) RETURNING id",
-		module.UUID, module.Name, module.CreatedAt, module.UpdatedAt,
-	).Scan(&module.ID)
+	return s.querier(ctx).QueryRow(ctx, `
+		INSERT INTO modules (uuid, name, course_id, created_at, updated_at) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT ON CONSTRAINT modules_name_course_id_key DO UPDATE SET name = EXCLUDED.name
+		RETURNING id, uuid, created_at, updated_at
+	`,
+		module.UUID, module.Name, module.CourseID, module.CreatedAt, module.UpdatedAt,
+	).Scan(&module.ID, &module.UUID, &module.CreatedAt, &module.UpdatedAt)
+}
+
+// DeleteModule deletes the shared module, it fails while cards reference it.
+// Modules of decks are not found, they go away with the deck.
+func (s *Store) DeleteModule(ctx context.Context, id int) error {
+	tag, err := s.querier(ctx).Exec(ctx, "DELETE FROM modules WHERE id = $1 AND course_id IS NULL", id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
 }
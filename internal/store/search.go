@@ -0,0 +1,92 @@
+package store
+
+import (
+	"context"
+	"html"
+	"strings"
+)
+
+// searchVector builds cards.search_vector from the question ($3), tags ($6)
+// and plain answer ($13) of the inserted card. Russian and English stemming
+// are both applied since cards mix the languages.
+const searchVector = `
+	setweight(to_tsvector('russian', $3) || to_tsvector('english', $3), 'A') ||
+	setweight(to_tsvector('simple', array_to_string($6::VARCHAR[], ' ')), 'B') ||
+	setweight(to_tsvector('russian', $13) || to_tsvector('english', $13), 'C')`
+
+// Highlighted words are wrapped in control characters, the snippet is escaped
+// before they are turned into <mark> tags.
+const (
+	markStart = "\x01"
+	markStop  = "\x02"
+)
+
+type SearchHit struct {
+	UUID       string  `json:"uuid"`
+	UID        int     `json:"uid"`
+	Question   string  `json:"question"`
+	CourseSlug string  `json:"course_slug"`
+	ModuleName string  `json:"module_name"`
+	Rank       float64 `json:"rank"`
+	// Snippet is a fragment of the answer as HTML with matches in <mark>.
+	Snippet string `json:"snippet"`
+}
+
+// SearchCards searches active cards by the query written in web search syntax
+// and returns hits ordered by rank. An empty courseSlug searches every course
+// the user has access to.
+func (s *Store) SearchCards(ctx context.Context, query string, courseSlug string, userID int, limit int) ([]SearchHit, error) {
+	rows, err := s.querier(ctx).Query(ctx, `
+		WITH q AS (
+			SELECT websearch_to_tsquery('russian', $1) || websearch_to_tsquery('english', $1) AS query
+		)
+		SELECT
+			c.uuid,
+			c.uid,
+			c.question,
+			co.slug,
+			m.name,
+			ts_rank(c.search_vector, q.query) AS rank,
+			ts_headline('russian', c.plain_answer, q.query, $4)
+		FROM cards c
+		CROSS JOIN q
+		JOIN courses co ON co.id = c.course_id
+		JOIN modules m ON m.id = c.module_id
+		WHERE c.is_active = TRUE
+			AND c.search_vector @@ q.query
+			AND ($2 = '' OR co.slug = $2)
+			AND `+courseAccessible("$5")+`
+		ORDER BY rank DESC, c.uid
+		LIMIT $3
+	`, query, courseSlug, limit, "StartSel="+markStart+", StopSel="+markStop+", MaxFragments=2, MaxWords=20, MinWords=8, FragmentDelimiter=\" … \"", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	hits := make([]SearchHit, 0)
+	for rows.Next() {
+		var hit SearchHit
+		err = rows.Scan(
+			&hit.UUID,
+			&hit.UID,
+			&hit.Question,
+			&hit.CourseSlug,
+			&hit.ModuleName,
+			&hit.Rank,
+			&hit.Snippet,
+		)
+		if err != nil {
+			return nil, err
+		}
+		hit.Snippet = highlight(hit.Snippet)
+		hits = append(hits, hit)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return hits, nil
+}
+
+func highlight(snippet string) string {
+	return strings.NewReplacer(markStart, "<mark>", markStop, "</mark>").Replace(html.EscapeString(snippet))
+}
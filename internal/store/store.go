@@ -3,9 +3,12 @@ package store
 import (
 	"context"
 	"log/slog"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/zagvozdeen/malicious-learning/internal/config"
+	"github.com/zagvozdeen/malicious-learning/internal/db/null"
+	"github.com/zagvozdeen/malicious-learning/internal/store/enum"
 )
 
 type Storage interface {
@@ -16,18 +19,64 @@ type Storage interface {
 	CreateTelegramUpdate(ctx context.Context, update *TelegramUpdate) error
 
 	GetModulesByCourseSlug(ctx context.Context, slug string) ([]Module, error)
-	GetModuleByName(ctx context.Context, name string) (*Module, error)
+	GetModuleByName(ctx context.Context, name string, courseID null.Int) (*Module, error)
 	CreateModule(ctx context.Context, module *Module) error
+	DeleteModule(ctx context.Context, id int) error
 
 	GetCourses(ctx context.Context) ([]Course, error)
+	GetCoursesForUser(ctx context.Context, userID int) ([]Course, error)
 	GetCourseBySlug(ctx context.Context, slug string) (*Course, error)
+	GetCourseByID(ctx context.Context, id int) (*Course, error)
+	HasCourseAccess(ctx context.Context, courseID, userID int) (bool, error)
+	UpdateCourseName(ctx context.Context, course *Course) error
+	ReplaceCourseTranslations(ctx context.Context, courseID int, translations []CourseTranslation) error
+	GetCourseNames(ctx context.Context, locale enum.Locale) (map[int]string, error)
+	ReplaceCoursePrompts(ctx context.Context, courseID int, prompts []CoursePrompt) error
+	GetCoursePrompt(ctx context.Context, courseID int, name string) (*CoursePrompt, error)
 	CreateCourse(ctx context.Context, course *Course) error
+	AddCourseMember(ctx context.Context, courseID, userID int, createdAt time.Time) error
+	AcceptCourseMember(ctx context.Context, courseID, userID int, acceptedAt time.Time) error
+	RemoveCourseMember(ctx context.Context, courseID, userID int) error
+	GetCourseMembers(ctx context.Context, courseID int) ([]CourseMember, error)
+	GetDeckInvites(ctx context.Context, userID int) ([]DeckInvite, error)
 
-	GetAllCards(ctx context.Context) (cards []Card, err error)
+	GetAllCards(ctx context.Context, userID int) (cards []Card, err error)
 	GetCards(ctx context.Context, courseSlug string, moduleIDs []int) ([]Card, error)
-	IsExistsCardByUIDAndHash(ctx context.Context, uid int, hash string) (bool, error)
+	GetCardByUUID(ctx context.Context, uuid string) (*Card, error)
+	GetCardByID(ctx context.Context, id int) (*Card, error)
+	GetCardsForExport(ctx context.Context, courseSlug string, forgottenBy null.Int) ([]FullCard, error)
+	IsExistsCardByUIDAndHash(ctx context.Context, courseID, uid int, hash string) (bool, error)
 	CreateCard(ctx context.Context, card *Card) error
-	DeactivateCard(ctx context.Context, card *Card) error
+	SearchCards(ctx context.Context, query string, courseSlug string, userID int, limit int) ([]SearchHit, error)
+	GetCardTranslations(ctx context.Context, cardIDs []int, locale enum.Locale) (map[int]CardTranslation, error)
+	DeactivateCard(ctx context.Context, card *Card) (int64, error)
+	DeactivateCardsExcept(ctx context.Context, courseID int, uids []int, updatedAt time.Time) ([]Card, error)
+
+	ReplaceCardLinks(ctx context.Context, courseIDs []int, links []CardLink) error
+	GetLinkedCards(ctx context.Context, card *Card) ([]Card, error)
+	GetBacklinkedCards(ctx context.Context, card *Card) ([]Card, error)
+	ReplaceCardSimilarities(ctx context.Context, courseIDs []int, similarities []CardSimilarity) error
+	GetSimilarCards(ctx context.Context, card *Card) ([]SimilarCard, error)
+
+	CreateCardDraft(ctx context.Context, d *CardDraft) error
+	UpdateCardDraft(ctx context.Context, d *CardDraft) error
+	SupersedeCardDrafts(ctx context.Context, d *CardDraft, updatedAt time.Time) error
+	GetCardDraftByUUID(ctx context.Context, uuid string) (*CardDraft, error)
+	GetCardDrafts(ctx context.Context, status enum.DraftStatus) ([]CardDraft, error)
+
+	CreateCardSuggestion(ctx context.Context, sg *CardSuggestion) error
+	ReviewCardSuggestion(ctx context.Context, sg *CardSuggestion) (bool, error)
+	GetCardSuggestionByUUID(ctx context.Context, uuid string) (*CardSuggestion, error)
+	GetCardSuggestions(ctx context.Context, status enum.SuggestionStatus) ([]CardSuggestion, error)
+
+	CreateJob(ctx context.Context, job *Job) (bool, error)
+	ClaimJob(ctx context.Context, now, staleBefore time.Time) (*Job, error)
+	UpdateJob(ctx context.Context, job *Job) error
+
+	LockContent(ctx context.Context) error
+	CreateContentRevision(ctx context.Context, revision *ContentRevision) error
+	CreateChangelogEntries(ctx context.Context, entries []ChangelogEntry) error
+	GetChangelog(ctx context.Context, courseSlug string) ([]ChangelogEntry, error)
 
 	CreateTestSession(ctx context.Context, session *TestSession, answers []UserAnswer) error
 	UpdateTestSession(ctx context.Context, session *TestSession) error
@@ -43,8 +92,23 @@ type Storage interface {
 	GetUserByTID(ctx context.Context, tid int64) (*User, error)
 	GetUserByUsername(ctx context.Context, username string) (*User, error)
 	CreateUser(ctx context.Context, user *User) error
+	UpdateUserLanguageCode(ctx context.Context, user *User) error
+	UpdateUserLocale(ctx context.Context, user *User) error
+	UpdateUserRole(ctx context.Context, user *User) error
+	GetTelegramUsersByModuleIDs(ctx context.Context, courseID int, moduleIDs []int) ([]User, error)
 
 	CreateChatCompletions(ctx context.Context, cc *ChatCompletions) error
+	GetLLMTotals(ctx context.Context, since time.Time, userID null.Int) (LLMTotals, error)
+	GetLLMUsage(ctx context.Context, from, to time.Time) ([]LLMUsage, error)
+
+	GetOrCreateTutorChat(ctx context.Context, chat *TutorChat) error
+	GetTutorChatByUUID(ctx context.Context, uuid string) (*TutorChat, error)
+	CreateTutorMessage(ctx context.Context, m *TutorMessage) error
+	DeleteTutorMessage(ctx context.Context, id int) error
+	GetTutorMessages(ctx context.Context, chatID int) ([]TutorMessage, error)
+
+	GetAssetByHash(ctx context.Context, hash string) (*Asset, error)
+	CreateAsset(ctx context.Context, asset *Asset) error
 }
 
 type Store struct {
@@ -0,0 +1,89 @@
+package store
+
+import (
+	"context"
+
+	"github.com/zagvozdeen/malicious-learning/internal/store/enum"
+)
+
+type CardTranslation struct {
+	Locale       enum.Locale
+	Question     string
+	QuestionBody string
+	Answer       string
+}
+
+type CourseTranslation struct {
+	Locale enum.Locale
+	Name   string
+}
+
+// GetCardTranslations returns translations of the cards to the locale by card
+// ID. Cards without a translation are missing from the map.
+func (s *Store) GetCardTranslations(ctx context.Context, cardIDs []int, locale enum.Locale) (map[int]CardTranslation, error) {
+	rows, err := s.querier(ctx).Query(ctx, `
+		SELECT card_id, locale, question, question_body, answer
+		FROM card_translations
+		WHERE card_id = ANY($1) AND locale = $2
+	`, cardIDs, locale)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	translations := make(map[int]CardTranslation)
+	for rows.Next() {
+		var id int
+		var t CardTranslation
+		err = rows.Scan(&id, &t.Locale, &t.Question, &t.QuestionBody, &t.Answer)
+		if err != nil {
+			return nil, err
+		}
+		translations[id] = t
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return translations, nil
+}
+
+// ReplaceCourseTranslations replaces translations of the course name.
+func (s *Store) ReplaceCourseTranslations(ctx context.Context, courseID int, translations []CourseTranslation) error {
+	_, err := s.querier(ctx).Exec(ctx, "DELETE FROM course_translations WHERE course_id = $1", courseID)
+	if err != nil {
+		return err
+	}
+	for _, t := range translations {
+		_, err = s.querier(ctx).Exec(ctx, `
+			INSERT INTO course_translations (course_id, locale, name) VALUES ($1, $2, $3)
+		`, courseID, t.Locale, t.Name)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetCourseNames returns translated course names to the locale by course ID.
+func (s *Store) GetCourseNames(ctx context.Context, locale enum.Locale) (map[int]string, error) {
+	rows, err := s.querier(ctx).Query(ctx, `
+		SELECT course_id, name FROM course_translations WHERE locale = $1
+	`, locale)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	names := make(map[int]string)
+	for rows.Next() {
+		var id int
+		var name string
+		err = rows.Scan(&id, &name)
+		if err != nil {
+			return nil, err
+		}
+		names[id] = name
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return names, nil
+}
@@ -0,0 +1,116 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/zagvozdeen/malicious-learning/internal/db/null"
+	"github.com/zagvozdeen/malicious-learning/internal/store/enum"
+)
+
+// TutorChat is a conversation of the user with the language model about the
+// card, optionally within a test session.
+type TutorChat struct {
+	ID            int       `json:"id"`
+	UUID          string    `json:"uuid"`
+	UserID        int       `json:"user_id"`
+	CardID        int       `json:"card_id"`
+	TestSessionID null.Int  `json:"test_session_id"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+type TutorMessage struct {
+	ID        int              `json:"id"`
+	ChatID    int              `json:"chat_id"`
+	Role      enum.MessageRole `json:"role"`
+	Content   string           `json:"content"`
+	CreatedAt time.Time        `json:"created_at"`
+}
+
+const tutorChatColumns = `id, uuid, user_id, card_id, test_session_id, created_at, updated_at`
+
+func scanTutorChat(row pgx.Row, chat *TutorChat) error {
+	return row.Scan(
+		&chat.ID,
+		&chat.UUID,
+		&chat.UserID,
+		&chat.CardID,
+		&chat.TestSessionID,
+		&chat.CreatedAt,
+		&chat.UpdatedAt,
+	)
+}
+
+// GetOrCreateTutorChat returns the chat of the user about the card in the
+// test session, it is created from chat when there is none yet.
+func (s *Store) GetOrCreateTutorChat(ctx context.Context, chat *TutorChat) error {
+	_, err := s.querier(ctx).Exec(ctx, `
+		INSERT INTO tutor_chats (uuid, user_id, card_id, test_session_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (user_id, card_id, test_session_id) DO NOTHING
+	`, chat.UUID, chat.UserID, chat.CardID, chat.TestSessionID, chat.CreatedAt, chat.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	return scanTutorChat(s.querier(ctx).QueryRow(ctx, `
+		SELECT `+tutorChatColumns+`
+		FROM tutor_chats
+		WHERE user_id = $1 AND card_id = $2 AND test_session_id IS NOT DISTINCT FROM $3
+	`, chat.UserID, chat.CardID, chat.TestSessionID), chat)
+}
+
+func (s *Store) GetTutorChatByUUID(ctx context.Context, uuid string) (*TutorChat, error) {
+	chat := &TutorChat{}
+	err := scanTutorChat(s.querier(ctx).QueryRow(ctx, `
+		SELECT `+tutorChatColumns+` FROM tutor_chats WHERE uuid = $1
+	`, uuid), chat)
+	if err != nil {
+		return nil, err
+	}
+	return chat, nil
+}
+
+// CreateTutorMessage appends the message to the chat and bumps the chat.
+func (s *Store) CreateTutorMessage(ctx context.Context, m *TutorMessage) error {
+	err := s.querier(ctx).QueryRow(ctx, `
+		INSERT INTO tutor_messages (chat_id, role, content, created_at) VALUES ($1, $2, $3, $4) RETURNING id
+	`, m.ChatID, m.Role, m.Content, m.CreatedAt).Scan(&m.ID)
+	if err != nil {
+		return err
+	}
+	_, err = s.querier(ctx).Exec(ctx, "UPDATE tutor_chats SET updated_at = $1 WHERE id = $2", m.CreatedAt, m.ChatID)
+	return err
+}
+
+// DeleteTutorMessage deletes the message, e.g. a question the model failed
+// to reply to.
+func (s *Store) DeleteTutorMessage(ctx context.Context, id int) error {
+	_, err := s.querier(ctx).Exec(ctx, "DELETE FROM tutor_messages WHERE id = $1", id)
+	return err
+}
+
+// GetTutorMessages returns messages of the chat, oldest first.
+func (s *Store) GetTutorMessages(ctx context.Context, chatID int) ([]TutorMessage, error) {
+	rows, err := s.querier(ctx).Query(ctx, `
+		SELECT id, chat_id, role, content, created_at FROM tutor_messages WHERE chat_id = $1 ORDER BY id
+	`, chatID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	messages := make([]TutorMessage, 0)
+	for rows.Next() {
+		var m TutorMessage
+		err = rows.Scan(&m.ID, &m.ChatID, &m.Role, &m.Content, &m.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, m)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
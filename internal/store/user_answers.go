@@ -21,11 +21,13 @@ type UserAnswer struct {
 type FullUserAnswer struct {
 	UserAnswer
 
-	UID        int    `json:"uid"`
-	Answer     string `json:"answer"`
-	Question   string `json:"question"`
-	ModuleID   int    `json:"module_id"`
-	ModuleName string `json:"module_name"`
+	CardUUID     string `json:"card_uuid"`
+	UID          int    `json:"uid"`
+	Answer       string `json:"answer"`
+	Question     string `json:"question"`
+	QuestionBody string `json:"question_body"`
+	ModuleID     int    `json:"module_id"`
+	ModuleName   string `json:"module_name"`
 }
 
 type TestSessionSummary struct {
@@ -43,7 +45,7 @@ type TestSessionSummary struct {
 
 func (s *Store) GetUserAnswersByTestSessionID(ctx context.Context, id int) ([]FullUserAnswer, error) {
 	rows, err := s.querier(ctx).Query(ctx, `
-		SELECT ua.id, ua.uuid, ua.card_id, ua.test_session_id, ua.status, ua.created_at, ua.updated_at, c.uid, c.answer, c.question, c.module_id, m.name
+		SELECT ua.id, ua.uuid, ua.card_id, ua.test_session_id, ua.status, ua.created_at, ua.updated_at, c.uuid, c.uid, c.answer, c.question, c.question_body, c.module_id, m.name
 		FROM user_answers ua
 		JOIN cards c ON c.id = ua.card_id
 		JOIN modules m ON m.id = c.module_id
@@ -66,9 +68,11 @@ func (s *Store) GetUserAnswersByTestSessionID(ctx context.Context, id int) ([]Fu
 			&answer.Status,
 			&answer.CreatedAt,
 			&answer.UpdatedAt,
+			&answer.CardUUID,
 			&answer.UID,
 			&answer.Answer,
 			&answer.Question,
+			&answer.QuestionBody,
 			&answer.ModuleID,
 			&answer.ModuleName,
 		)
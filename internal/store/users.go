@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/zagvozdeen/malicious-learning/internal/db/null"
+	"github.com/zagvozdeen/malicious-learning/internal/store/enum"
 )
 
 type User struct {
@@ -16,6 +17,11 @@ type User struct {
 	Username  null.String
 	Email     null.String
 	Password  null.String
+	// LanguageCode is the language of the user's Telegram client.
+	LanguageCode null.String
+	// Locale is the language chosen in the profile, it wins over LanguageCode.
+	Locale    null.String
+	Role      enum.UserRole
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
@@ -31,10 +37,22 @@ type LeaderboardEntry struct {
 	StartedSessions int         `json:"started_sessions"`
 }
 
+// PreferredLocale returns the locale to show content in: the profile setting,
+// then the Telegram client language, then the default locale.
+func (u *User) PreferredLocale() enum.Locale {
+	if l, err := enum.NewLocale(u.Locale.V); u.Locale.Valid && err == nil {
+		return l
+	}
+	if l, ok := enum.LocaleFromLanguageCode(u.LanguageCode.V); ok {
+		return l
+	}
+	return enum.DefaultLocale
+}
+
 func (s *Store) GetUserByTID(ctx context.Context, tid int64) (*User, error) {
 	user := &User{}
 	err := s.querier(ctx).QueryRow(ctx, `
-		SELECT id, tid, uuid, first_name, last_name, username, email, password, created_at, updated_at
+		SELECT id, tid, uuid, first_name, last_name, username, email, password, language_code, locale, role, created_at, updated_at
 		FROM users
 		WHERE tid = $1
 	`, tid).Scan(
@@ -46,6 +64,9 @@ func (s *Store) GetUserByTID(ctx context.Context, tid int64) (*User, error) {
 		&user.Username,
 		&user.Email,
 		&user.Password,
+		&user.LanguageCode,
+		&user.Locale,
+		&user.Role,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -58,7 +79,7 @@ func (s *Store) GetUserByTID(ctx context.Context, tid int64) (*User, error) {
 func (s *Store) GetUserByID(ctx context.Context, id int) (*User, error) {
 	user := &User{}
 	err := s.querier(ctx).QueryRow(ctx, `
-		SELECT id, tid, uuid, first_name, last_name, username, email, password, created_at, updated_at
+		SELECT id, tid, uuid, first_name, last_name, username, email, password, language_code, locale, role, created_at, updated_at
 		FROM users
 		WHERE id = $1
 	`, id).Scan(
@@ -70,6 +91,9 @@ func (s *Store) GetUserByID(ctx context.Context, id int) (*User, error) {
 		&user.Username,
 		&user.Email,
 		&user.Password,
+		&user.LanguageCode,
+		&user.Locale,
+		&user.Role,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -81,8 +105,8 @@ func (s *Store) GetUserByID(ctx context.Context, id int) (*User, error) {
 
 func (s *Store) CreateUser(ctx context.Context, user *User) error {
 	return s.querier(ctx).QueryRow(ctx, `
-		INSERT INTO users (tid, uuid, first_name, last_name, username, email, password, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		INSERT INTO users (tid, uuid, first_name, last_name, username, email, password, language_code, locale, role, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 		RETURNING id
 	`,
 		user.TID,
@@ -92,16 +116,40 @@ func (s *Store) CreateUser(ctx context.Context, user *User) error {
 		user.Username,
 		user.Email,
 		user.Password,
+		user.LanguageCode,
+		user.Locale,
+		user.Role,
 		user.CreatedAt,
 		user.UpdatedAt,
 	).Scan(&user.ID)
 }
 
+func (s *Store) UpdateUserLanguageCode(ctx context.Context, user *User) error {
+	_, err := s.querier(ctx).Exec(ctx, `
+		UPDATE users SET language_code = $1, updated_at = $2 WHERE id = $3
+	`, user.LanguageCode, user.UpdatedAt, user.ID)
+	return err
+}
+
+func (s *Store) UpdateUserLocale(ctx context.Context, user *User) error {
+	_, err := s.querier(ctx).Exec(ctx, `
+		UPDATE users SET locale = $1, updated_at = $2 WHERE id = $3
+	`, user.Locale, user.UpdatedAt, user.ID)
+	return err
+}
+
+func (s *Store) UpdateUserRole(ctx context.Context, user *User) error {
+	_, err := s.querier(ctx).Exec(ctx, `
+		UPDATE users SET role = $1, updated_at = $2 WHERE id = $3
+	`, user.Role, user.UpdatedAt, user.ID)
+	return err
+}
+
 func (s *Store) GetUserByUsername(ctx context.Context, username string) (*User, error) {
 	user := &User{}
 	err := s.querier(ctx).QueryRow(
 		ctx,
-		"SELECT id, tid, uuid, first_name, last_name, username, email, password, created_at, updated_at FROM users WHERE username = $1 LIMIT 1",
+		"SELECT id, tid, uuid, first_name, last_name, username, email, password, language_code, locale, role, created_at, updated_at FROM users WHERE username = $1 LIMIT 1",
 		username,
 	).Scan(
 		&user.ID,
@@ -112,6 +160,9 @@ func (s *Store) GetUserByUsername(ctx context.Context, username string) (*User,
 		&user.Username,
 		&user.Email,
 		&user.Password,
+		&user.LanguageCode,
+		&user.Locale,
+		&user.Role,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -166,3 +217,50 @@ func (s *Store) GetLeaderboard(ctx context.Context) ([]LeaderboardEntry, error)
 	}
 	return entries, nil
 }
+
+// GetTelegramUsersByModuleIDs returns telegram users having test sessions of
+// the course with any of the modules.
+func (s *Store) GetTelegramUsersByModuleIDs(ctx context.Context, courseID int, moduleIDs []int) ([]User, error) {
+	rows, err := s.querier(ctx).Query(ctx, `
+		SELECT id, tid, uuid, first_name, last_name, username, email, password, language_code, locale, role, created_at, updated_at
+		FROM users u
+		WHERE u.tid IS NOT NULL AND EXISTS(
+			SELECT 1 FROM test_sessions ts
+			WHERE ts.user_id = u.id AND ts.course_id = $1 AND ts.module_ids && $2
+		)
+		ORDER BY u.id
+	`, courseID, moduleIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []User
+	for rows.Next() {
+		var user User
+		err = rows.Scan(
+			&user.ID,
+			&user.TID,
+			&user.UUID,
+			&user.FirstName,
+			&user.LastName,
+			&user.Username,
+			&user.Email,
+			&user.Password,
+			&user.LanguageCode,
+			&user.Locale,
+			&user.Role,
+			&user.Role,
+			&user.CreatedAt,
+			&user.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
@@ -0,0 +1,46 @@
+import { ref } from 'vue'
+import { useState } from '@/composables/useState.ts'
+
+const DARK_THEME = 'dracula'
+const LIGHT_THEME = 'github'
+
+// An empty theme follows the Telegram color scheme.
+const selected = ref(localStorage.getItem('code-theme') || '')
+
+const currentTheme = () => {
+  if (selected.value) {
+    return selected.value
+  }
+  return window.Telegram?.WebApp?.colorScheme === 'light' ? LIGHT_THEME : DARK_THEME
+}
+
+const apply = () => {
+  let link = document.getElementById('code-theme') as HTMLLinkElement | null
+  if (!link) {
+    link = document.createElement('link')
+    link.id = 'code-theme'
+    link.rel = 'stylesheet'
+    document.head.appendChild(link)
+  }
+  link.href = `${useState().getApiUrl()}/api/code-themes/${currentTheme()}.css`
+}
+
+export const initCodeTheme = () => {
+  apply()
+  window.Telegram?.WebApp?.onEvent('themeChanged', apply)
+}
+
+export const useCodeTheme = () => {
+  return {
+    selected,
+    setTheme: (name: string) => {
+      if (name) {
+        localStorage.setItem('code-theme', name)
+      } else {
+        localStorage.removeItem('code-theme')
+      }
+      selected.value = name
+      apply()
+    },
+  }
+}
@@ -1,9 +1,20 @@
 import type {
   Card,
+  ChangelogEntry,
+  CodeTheme,
   Course,
-  FullUserAnswer, Module,
+  DeckInvite,
+  DeckUpload,
+  FullUserAnswer,
+  Job,
+  Locale,
+  Locales,
+  Module,
+  SearchHit,
   TestSession,
   TestSessionSummary,
+  TutorChat,
+  TutorMessage,
   UserAnswer,
   UserAnswerStatus,
 } from '@/types.ts'
@@ -28,6 +39,9 @@ const fetchJson = async <T>(state: State, notify: Notify, input: RequestInfo, in
     return { ok: false }
   }
 
+  if (res.status === 204) {
+    return { ok: true, data: undefined as T }
+  }
   return { ok: true, data: await res.json() }
 }
 
@@ -80,6 +94,15 @@ const updateUserAnswer = async (state: State, notify: Notify, uuid: string, stat
   })
 }
 
+const regenerateRecommendations = async (state: State, notify: Notify, uuid: string) => {
+  return fetchJson<Job>(state, notify, `${state.getApiUrl()}/api/test-sessions/${uuid}/recommendations`, {
+    method: 'POST',
+    headers: {
+      'Authorization': state.getAuthorizationHeader(),
+    },
+  })
+}
+
 const getAllCards = async (state: State, notify: Notify) => {
   return fetchJson<Card[]>(state, notify, `${state.getApiUrl()}/api/cards`, {
     headers: {
@@ -96,6 +119,36 @@ const getAllCourses = async (state: State, notify: Notify) => {
   })
 }
 
+const uploadDeck = async (state: State, notify: Notify, slug: string, file: File) => {
+  const body = new FormData()
+  body.append('slug', slug)
+  body.append('file', file)
+  return fetchJson<DeckUpload>(state, notify, `${state.getApiUrl()}/api/decks`, {
+    method: 'POST',
+    headers: {
+      'Authorization': state.getAuthorizationHeader(),
+    },
+    body,
+  })
+}
+
+const getDeckInvites = async (state: State, notify: Notify) => {
+  return fetchJson<DeckInvite[]>(state, notify, `${state.getApiUrl()}/api/decks/invites`, {
+    headers: {
+      'Authorization': state.getAuthorizationHeader(),
+    },
+  })
+}
+
+const answerDeckInvite = async (state: State, notify: Notify, slug: string, accept: boolean) => {
+  return fetchJson<void>(state, notify, `${state.getApiUrl()}/api/decks/${slug}/invite`, {
+    method: accept ? 'POST' : 'DELETE',
+    headers: {
+      'Authorization': state.getAuthorizationHeader(),
+    },
+  })
+}
+
 const getModulesByCourseSlug = async (state: State, notify: Notify, slug: string) => {
   return fetchJson<Module[]>(state, notify, `${state.getApiUrl()}/api/modules?course_slug=${slug}`, {
     headers: {
@@ -104,6 +157,48 @@ const getModulesByCourseSlug = async (state: State, notify: Notify, slug: string
   })
 }
 
+const getCodeThemes = async (state: State, notify: Notify) => {
+  return fetchJson<CodeTheme[]>(state, notify, `${state.getApiUrl()}/api/code-themes`, {
+    headers: {
+      'Authorization': state.getAuthorizationHeader(),
+    },
+  })
+}
+
+const searchCards = async (state: State, notify: Notify, q: string) => {
+  return fetchJson<SearchHit[]>(state, notify, `${state.getApiUrl()}/api/search?q=${encodeURIComponent(q)}`, {
+    headers: {
+      'Authorization': state.getAuthorizationHeader(),
+    },
+  })
+}
+
+const getLocales = async (state: State, notify: Notify) => {
+  return fetchJson<Locales>(state, notify, `${state.getApiUrl()}/api/locales`, {
+    headers: {
+      'Authorization': state.getAuthorizationHeader(),
+    },
+  })
+}
+
+const updateLocale = async (state: State, notify: Notify, locale: Locale | null) => {
+  return fetchJson<Locales>(state, notify, `${state.getApiUrl()}/api/locales`, {
+    method: 'PATCH',
+    headers: {
+      'Authorization': state.getAuthorizationHeader(),
+    },
+    body: JSON.stringify({ locale }),
+  })
+}
+
+const getChangelog = async (state: State, notify: Notify, slug: string) => {
+  return fetchJson<ChangelogEntry[]>(state, notify, `${state.getApiUrl()}/api/changelog?course_slug=${slug}`, {
+    headers: {
+      'Authorization': state.getAuthorizationHeader(),
+    },
+  })
+}
+
 const getChanges = (state: State) => {
   return fetch(`${state.getApiUrl()}/api/changes`, {
     headers: {
@@ -112,6 +207,26 @@ const getChanges = (state: State) => {
   })
 }
 
+const createTutorChat = async (state: State, notify: Notify, cardUuid: string, testSessionUuid: string | null) => {
+  const query = testSessionUuid ? `?test_session=${testSessionUuid}` : ''
+  return fetchJson<{ chat: TutorChat; messages: TutorMessage[] }>(state, notify, `${state.getApiUrl()}/api/cards/${cardUuid}/tutor-chats${query}`, {
+    method: 'POST',
+    headers: {
+      'Authorization': state.getAuthorizationHeader(),
+    },
+  })
+}
+
+const sendTutorMessage = (state: State, uuid: string, content: string) => {
+  return fetch(`${state.getApiUrl()}/api/tutor-chats/${uuid}/messages`, {
+    method: 'POST',
+    headers: {
+      'Authorization': state.getAuthorizationHeader(),
+    },
+    body: JSON.stringify({ content }),
+  })
+}
+
 export const useFetch = () => {
   const state = useState()
   const notify = useNotifications()
@@ -122,9 +237,20 @@ export const useFetch = () => {
     getTestSession: (uuid: string) => getTestSession(state, notify, uuid),
     getTestSessions: () => getTestSessions(state, notify),
     updateUserAnswer: (uuid: string, status: UserAnswerStatus) => updateUserAnswer(state, notify, uuid, status),
+    regenerateRecommendations: (uuid: string) => regenerateRecommendations(state, notify, uuid),
     getAllCards: () => getAllCards(state, notify),
     getAllCourses: () => getAllCourses(state, notify),
+    uploadDeck: (slug: string, file: File) => uploadDeck(state, notify, slug, file),
+    getDeckInvites: () => getDeckInvites(state, notify),
+    answerDeckInvite: (slug: string, accept: boolean) => answerDeckInvite(state, notify, slug, accept),
     getModulesByCourseSlug: (slug: string) => getModulesByCourseSlug(state, notify, slug),
+    getCodeThemes: () => getCodeThemes(state, notify),
+    searchCards: (q: string) => searchCards(state, notify, q),
+    getLocales: () => getLocales(state, notify),
+    updateLocale: (locale: Locale | null) => updateLocale(state, notify, locale),
+    getChangelog: (slug: string) => getChangelog(state, notify, slug),
     getChanges: () => getChanges(state),
+    createTutorChat: (cardUuid: string, testSessionUuid: string | null) => createTutorChat(state, notify, cardUuid, testSessionUuid),
+    sendTutorMessage: (uuid: string, content: string) => sendTutorMessage(state, uuid, content),
   }
 }
@@ -1,6 +1,10 @@
 export const i18n = {
   'user answer status must be null': 'Вы уже ответили на этот вопрос, если хотите ответить на вопрос повторно, то начните новый тест',
   'test session is not active': 'Этот тест устарел и закрыт, начните новый тест',
+  'recommendations are already queued': 'Рекомендации уже рассчитываются, загляните сюда чуть позже',
+  'llm daily quota exceeded': 'Лимит запросов к нейросети на сегодня исчерпан, попробуйте завтра',
+  'tutor is still replying': 'Репетитор ещё отвечает на прошлый вопрос',
+  'tutor failed to reply': 'Репетитор не смог ответить, попробуйте ещё раз',
   'tma user not found: no rows in result set': 'Чтобы использовать мини-приложение, необходимо зарегистрироваться: введите команду /start в боте',
 } as Readonly<Record<string, string>>
 
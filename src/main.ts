@@ -1,6 +1,7 @@
 import '@/style.css'
 import { createApp } from 'vue'
 import { useState } from '@/composables/useState.ts'
+import { initCodeTheme } from '@/composables/useCodeTheme.ts'
 import { createRouter, createWebHistory } from 'vue-router'
 import App from '@/components/App.vue'
 import CardPage from '@/pages/CardPage.vue'
@@ -9,6 +10,7 @@ import LoginPage from '@/pages/LoginPage.vue'
 import StatsPage from '@/pages/StatsPage.vue'
 import CardsPage from '@/pages/CardsPage.vue'
 import CreateTestSessionPage from '@/pages/CreateTestSessionPage.vue'
+import ChangelogPage from '@/pages/ChangelogPage.vue'
 
 const router = createRouter({
   history: createWebHistory(),
@@ -19,6 +21,7 @@ const router = createRouter({
     { path: '/stats', name: 'stats', component: StatsPage },
     { path: '/cards', name: 'cards', component: CardsPage },
     { path: '/cards/create', name: 'cards.create', component: CreateTestSessionPage },
+    { path: '/changelog', name: 'changelog', component: ChangelogPage },
   ],
 })
 
@@ -36,4 +39,6 @@ router.beforeEach((to, _, next) => {
   }
 })
 
+initCodeTheme()
+
 createApp(App).use(router).mount('#app')
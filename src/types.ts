@@ -26,12 +26,14 @@ export interface FullUserAnswer {
     uid: number
     uuid: string
     card_id: number
+    card_uuid: string
     test_session_id: number
     status: UserAnswerStatus
     created_at: string
     updated_at: string
     answer: string
     question: string
+    question_body: string
     module_id: number
     module_name: string
 }
@@ -79,8 +81,10 @@ export interface Card {
     uid: number
     uuid: string
     question: string
+    question_body: string
     answer: string
     module_id: number
+    course_id: number
     is_active: boolean
     hash: string
     created_at: string
@@ -92,6 +96,7 @@ export interface Course {
     uuid: string
     slug: string
     name: string
+    owner_id: number | null
     updated_at: string
     created_at: string
 }
@@ -100,6 +105,95 @@ export interface Module {
     id: number
     uuid: string
     name: string
+    course_id: number | null
+    updated_at: string
+    created_at: string
+}
+
+export interface CodeTheme {
+    name: string
+    dark: boolean
+}
+
+export interface SearchHit {
+    uuid: string
+    uid: number
+    question: string
+    course_slug: string
+    module_name: string
+    rank: number
+    snippet: string
+}
+
+export type Locale = 'ru' | 'en'
+
+export interface Locales {
+    locales: { slug: Locale; title: string }[]
+    current: Locale
+    chosen: Locale | null
+}
+
+export type ChangeKind = 'added' | 'changed' | 'removed'
+
+export interface ChangelogEntry {
+    id: number
+    content_revision_id: number
+    course_id: number
+    module_id: number
+    module_name: string
+    card_uid: number
+    question: string
+    kind: ChangeKind
+    created_at: string
+}
+
+export interface DeckInvite {
+    course_slug: string
+    course_name: string
+    owner_first_name: string
+    owner_username: string | null
+    created_at: string
+}
+
+export interface DeckUpload {
+    course: Course
+    cards: number
+    changes: number
+}
+
+export type JobStatus = 'pending' | 'running' | 'done' | 'dead'
+
+export interface Job {
+    id: number
+    uuid: string
+    kind: string
+    key: string
+    status: JobStatus
+    attempts: number
+    max_attempts: number
+    run_at: string
+    locked_at: string | null
+    last_error: string | null
+    created_at: string
     updated_at: string
+}
+
+export type MessageRole = 'user' | 'assistant'
+
+export interface TutorChat {
+    id: number
+    uuid: string
+    user_id: number
+    card_id: number
+    test_session_id: number | null
+    created_at: string
+    updated_at: string
+}
+
+export interface TutorMessage {
+    id: number
+    chat_id: number
+    role: MessageRole
+    content: string
     created_at: string
 }